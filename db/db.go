@@ -11,7 +11,8 @@ import (
 
 var DB *sql.DB
 
-func InitDB() error {
+// OpenDB opens the SQLite database without touching the schema
+func OpenDB() error {
 	// Ensure the data directory exists
 	dataDir := "data"
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
@@ -22,26 +23,31 @@ func InitDB() error {
 	// Open SQLite database
 	dbPath := filepath.Join(dataDir, "forum.db")
 	var err error
-	DB, err = sql.Open("sqlite", dbPath)
+	// Store timestamps in a sortable format so keyset comparisons work on them,
+	// and wait on locks instead of failing while translation workers write
+	DB, err = sql.Open("sqlite", dbPath+"?_time_format=sqlite&_pragma=busy_timeout(5000)")
 	if err != nil {
 		log.Error().Err(err).Str("path", dbPath).Msg("Failed to open database")
 		return err
 	}
 
-	// Create tables
-	schema, err := os.ReadFile("db/sqlc/schema.sql")
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to read schema file")
+	log.Debug().Str("path", dbPath).Msg("Database opened")
+	return nil
+}
+
+// InitDB opens the database and applies any pending migrations
+func InitDB() error {
+	if err := OpenDB(); err != nil {
 		return err
 	}
 
-	_, err = DB.Exec(string(schema))
+	applied, err := MigrateUp(DB)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to execute schema")
+		log.Error().Err(err).Msg("Failed to apply migrations")
 		return err
 	}
 
-	log.Info().Str("path", dbPath).Msg("Database initialized successfully")
+	log.Info().Int("applied_migrations", applied).Msg("Database initialized successfully")
 	return nil
 }
 
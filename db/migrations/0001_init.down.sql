@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS comment_images;
+DROP TABLE IF EXISTS comment_translations;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS threads;
@@ -1,8 +1,3 @@
-DROP TABLE IF EXISTS comment_images;
-DROP TABLE IF EXISTS comment_translations;
-DROP TABLE IF EXISTS comments;
-DROP TABLE IF EXISTS threads;
-
 -- Create threads table
 CREATE TABLE IF NOT EXISTS threads (
     id VARCHAR(255) PRIMARY KEY,
@@ -38,4 +33,4 @@ CREATE TABLE IF NOT EXISTS comment_images (
     filepath VARCHAR(255) NOT NULL,
     created_at TIMESTAMP NOT NULL,
     FOREIGN KEY (comment_id) REFERENCES comments(id)
-); 
\ No newline at end of file
+);
@@ -0,0 +1,5 @@
+ALTER TABLE comments DROP COLUMN author_id;
+ALTER TABLE threads DROP COLUMN author_id;
+DROP INDEX IF EXISTS idx_sessions_user_id;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,22 @@
+-- Create users table
+CREATE TABLE IF NOT EXISTS users (
+    id VARCHAR(255) PRIMARY KEY,
+    username VARCHAR(50) NOT NULL UNIQUE COLLATE NOCASE,
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- Create sessions table, keyed by the SHA-256 of the session token
+CREATE TABLE IF NOT EXISTS sessions (
+    id VARCHAR(255) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+
+-- Record who wrote threads and comments; existing posts stay anonymous
+ALTER TABLE threads ADD COLUMN author_id VARCHAR(255);
+ALTER TABLE comments ADD COLUMN author_id VARCHAR(255);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_moderation_log_created_at;
+DROP TABLE IF EXISTS moderation_log;
+ALTER TABLE comments DROP COLUMN hidden;
+ALTER TABLE threads DROP COLUMN hidden;
+ALTER TABLE threads DROP COLUMN locked;
+ALTER TABLE threads DROP COLUMN pinned;
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,22 @@
+-- Roles: user, moderator, admin
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user';
+
+-- Moderation state for threads and comments
+ALTER TABLE threads ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE threads ADD COLUMN locked BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE threads ADD COLUMN hidden BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN hidden BOOLEAN NOT NULL DEFAULT 0;
+
+-- Create moderation audit log table
+CREATE TABLE IF NOT EXISTS moderation_log (
+    id VARCHAR(255) PRIMARY KEY,
+    moderator_id VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,
+    target_id VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (moderator_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_log_created_at ON moderation_log(created_at);
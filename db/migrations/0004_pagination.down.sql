@@ -0,0 +1,4 @@
+-- Normalized timestamps remain readable, so only the indexes are removed
+DROP INDEX IF EXISTS idx_comments_thread_listing;
+DROP INDEX IF EXISTS idx_threads_category_listing;
+DROP INDEX IF EXISTS idx_threads_listing;
@@ -0,0 +1,44 @@
+-- Timestamps used to be written with Go's time.String() format
+-- ("2006-01-02 15:04:05.999 +0000 UTC m=+1.23"), which does not sort or
+-- compare correctly as text. Rewrite them in the "2006-01-02 15:04:05.999-07:00"
+-- format the database is now opened with (_time_format=sqlite).
+
+UPDATE threads
+SET created_at = substr(created_at, 1, max(instr(created_at, ' +'), instr(created_at, ' -')) - 1)
+    || substr(created_at, max(instr(created_at, ' +'), instr(created_at, ' -')) + 1, 3) || ':' || substr(created_at, max(instr(created_at, ' +'), instr(created_at, ' -')) + 4, 2)
+WHERE created_at GLOB '????-??-?? ??:??:??* [+-][0-9][0-9][0-9][0-9] *';
+
+UPDATE comments
+SET created_at = substr(created_at, 1, max(instr(created_at, ' +'), instr(created_at, ' -')) - 1)
+    || substr(created_at, max(instr(created_at, ' +'), instr(created_at, ' -')) + 1, 3) || ':' || substr(created_at, max(instr(created_at, ' +'), instr(created_at, ' -')) + 4, 2)
+WHERE created_at GLOB '????-??-?? ??:??:??* [+-][0-9][0-9][0-9][0-9] *';
+
+UPDATE comment_images
+SET created_at = substr(created_at, 1, max(instr(created_at, ' +'), instr(created_at, ' -')) - 1)
+    || substr(created_at, max(instr(created_at, ' +'), instr(created_at, ' -')) + 1, 3) || ':' || substr(created_at, max(instr(created_at, ' +'), instr(created_at, ' -')) + 4, 2)
+WHERE created_at GLOB '????-??-?? ??:??:??* [+-][0-9][0-9][0-9][0-9] *';
+
+UPDATE users
+SET created_at = substr(created_at, 1, max(instr(created_at, ' +'), instr(created_at, ' -')) - 1)
+    || substr(created_at, max(instr(created_at, ' +'), instr(created_at, ' -')) + 1, 3) || ':' || substr(created_at, max(instr(created_at, ' +'), instr(created_at, ' -')) + 4, 2)
+WHERE created_at GLOB '????-??-?? ??:??:??* [+-][0-9][0-9][0-9][0-9] *';
+
+UPDATE sessions
+SET created_at = substr(created_at, 1, max(instr(created_at, ' +'), instr(created_at, ' -')) - 1)
+    || substr(created_at, max(instr(created_at, ' +'), instr(created_at, ' -')) + 1, 3) || ':' || substr(created_at, max(instr(created_at, ' +'), instr(created_at, ' -')) + 4, 2)
+WHERE created_at GLOB '????-??-?? ??:??:??* [+-][0-9][0-9][0-9][0-9] *';
+
+UPDATE sessions
+SET expires_at = substr(expires_at, 1, max(instr(expires_at, ' +'), instr(expires_at, ' -')) - 1)
+    || substr(expires_at, max(instr(expires_at, ' +'), instr(expires_at, ' -')) + 1, 3) || ':' || substr(expires_at, max(instr(expires_at, ' +'), instr(expires_at, ' -')) + 4, 2)
+WHERE expires_at GLOB '????-??-?? ??:??:??* [+-][0-9][0-9][0-9][0-9] *';
+
+UPDATE moderation_log
+SET created_at = substr(created_at, 1, max(instr(created_at, ' +'), instr(created_at, ' -')) - 1)
+    || substr(created_at, max(instr(created_at, ' +'), instr(created_at, ' -')) + 1, 3) || ':' || substr(created_at, max(instr(created_at, ' +'), instr(created_at, ' -')) + 4, 2)
+WHERE created_at GLOB '????-??-?? ??:??:??* [+-][0-9][0-9][0-9][0-9] *';
+
+-- Indexes backing keyset pagination of thread and comment listings
+CREATE INDEX IF NOT EXISTS idx_threads_listing ON threads(pinned, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_threads_category_listing ON threads(category, pinned, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_comments_thread_listing ON comments(thread_id, created_at, id);
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS comment_translations_search_delete;
+DROP TRIGGER IF EXISTS comment_translations_search_update;
+DROP TRIGGER IF EXISTS comment_translations_search_insert;
+DROP TRIGGER IF EXISTS threads_search_delete;
+DROP TRIGGER IF EXISTS threads_search_update;
+DROP TRIGGER IF EXISTS threads_search_insert;
+DROP TABLE IF EXISTS search_index;
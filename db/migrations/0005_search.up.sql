@@ -0,0 +1,55 @@
+-- Full-text index over thread titles/bodies and every comment translation.
+-- source_id is the thread ID for thread rows and the translation ID for comment rows.
+CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+    source_id UNINDEXED,
+    kind UNINDEXED,
+    thread_id UNINDEXED,
+    comment_id UNINDEXED,
+    language UNINDEXED,
+    title,
+    content,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+-- Keep the index in sync with threads
+CREATE TRIGGER IF NOT EXISTS threads_search_insert AFTER INSERT ON threads BEGIN
+    INSERT INTO search_index (source_id, kind, thread_id, comment_id, language, title, content)
+    VALUES (new.id, 'thread', new.id, '', '', new.title, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS threads_search_update AFTER UPDATE OF title, content ON threads BEGIN
+    DELETE FROM search_index WHERE kind = 'thread' AND source_id = old.id;
+    INSERT INTO search_index (source_id, kind, thread_id, comment_id, language, title, content)
+    VALUES (new.id, 'thread', new.id, '', '', new.title, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS threads_search_delete AFTER DELETE ON threads BEGIN
+    DELETE FROM search_index WHERE kind = 'thread' AND source_id = old.id;
+END;
+
+-- Keep the index in sync with comment translations, original and translated alike
+CREATE TRIGGER IF NOT EXISTS comment_translations_search_insert AFTER INSERT ON comment_translations BEGIN
+    INSERT INTO search_index (source_id, kind, thread_id, comment_id, language, title, content)
+    SELECT new.id, 'comment', c.thread_id, c.id, new.language, '', new.content
+    FROM comments c WHERE c.id = new.comment_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS comment_translations_search_update AFTER UPDATE OF content ON comment_translations BEGIN
+    DELETE FROM search_index WHERE kind = 'comment' AND source_id = old.id;
+    INSERT INTO search_index (source_id, kind, thread_id, comment_id, language, title, content)
+    SELECT new.id, 'comment', c.thread_id, c.id, new.language, '', new.content
+    FROM comments c WHERE c.id = new.comment_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS comment_translations_search_delete AFTER DELETE ON comment_translations BEGIN
+    DELETE FROM search_index WHERE kind = 'comment' AND source_id = old.id;
+END;
+
+-- Index existing content
+INSERT INTO search_index (source_id, kind, thread_id, comment_id, language, title, content)
+SELECT id, 'thread', id, '', '', title, content FROM threads;
+
+INSERT INTO search_index (source_id, kind, thread_id, comment_id, language, title, content)
+SELECT ct.id, 'comment', c.thread_id, c.id, ct.language, '', ct.content
+FROM comment_translations ct
+JOIN comments c ON c.id = ct.comment_id;
@@ -0,0 +1,25 @@
+DROP TRIGGER IF EXISTS thread_translations_search_delete;
+DROP TRIGGER IF EXISTS thread_translations_search_update;
+DROP TRIGGER IF EXISTS thread_translations_search_insert;
+
+DELETE FROM search_index WHERE kind = 'thread';
+
+CREATE TRIGGER IF NOT EXISTS threads_search_insert AFTER INSERT ON threads BEGIN
+    INSERT INTO search_index (source_id, kind, thread_id, comment_id, language, title, content)
+    VALUES (new.id, 'thread', new.id, '', '', new.title, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS threads_search_update AFTER UPDATE OF title, content ON threads BEGIN
+    DELETE FROM search_index WHERE kind = 'thread' AND source_id = old.id;
+    INSERT INTO search_index (source_id, kind, thread_id, comment_id, language, title, content)
+    VALUES (new.id, 'thread', new.id, '', '', new.title, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS threads_search_delete AFTER DELETE ON threads BEGIN
+    DELETE FROM search_index WHERE kind = 'thread' AND source_id = old.id;
+END;
+
+INSERT INTO search_index (source_id, kind, thread_id, comment_id, language, title, content)
+SELECT id, 'thread', id, '', '', title, content FROM threads;
+
+DROP TABLE IF EXISTS thread_translations;
@@ -0,0 +1,46 @@
+-- Create thread translations table. As with comments, the original text is
+-- stored as a translation too; threads.title/content keep the original.
+CREATE TABLE IF NOT EXISTS thread_translations (
+    id VARCHAR(255) PRIMARY KEY,
+    thread_id VARCHAR(255) NOT NULL,
+    language VARCHAR(10) NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    FOREIGN KEY (thread_id) REFERENCES threads(id),
+    UNIQUE (thread_id, language)
+);
+
+-- Record the original text of existing threads, detecting Russian the same
+-- way the API does: any character from the Cyrillic block.
+INSERT INTO thread_translations (id, thread_id, language, title, content)
+SELECT id || '-orig',
+       id,
+       CASE WHEN title || content GLOB '*[Ѐ-ӿ]*' THEN 'ru' ELSE 'en' END,
+       title,
+       content
+FROM threads;
+
+-- Search threads through their translations instead of the threads table
+DROP TRIGGER IF EXISTS threads_search_insert;
+DROP TRIGGER IF EXISTS threads_search_update;
+DROP TRIGGER IF EXISTS threads_search_delete;
+
+DELETE FROM search_index WHERE kind = 'thread';
+
+CREATE TRIGGER IF NOT EXISTS thread_translations_search_insert AFTER INSERT ON thread_translations BEGIN
+    INSERT INTO search_index (source_id, kind, thread_id, comment_id, language, title, content)
+    VALUES (new.id, 'thread', new.thread_id, '', new.language, new.title, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS thread_translations_search_update AFTER UPDATE OF title, content ON thread_translations BEGIN
+    DELETE FROM search_index WHERE kind = 'thread' AND source_id = old.id;
+    INSERT INTO search_index (source_id, kind, thread_id, comment_id, language, title, content)
+    VALUES (new.id, 'thread', new.thread_id, '', new.language, new.title, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS thread_translations_search_delete AFTER DELETE ON thread_translations BEGIN
+    DELETE FROM search_index WHERE kind = 'thread' AND source_id = old.id;
+END;
+
+INSERT INTO search_index (source_id, kind, thread_id, comment_id, language, title, content)
+SELECT id, 'thread', thread_id, '', language, title, content FROM thread_translations;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_translation_jobs_target;
+DROP INDEX IF EXISTS idx_translation_jobs_due;
+DROP TABLE IF EXISTS translation_jobs;
@@ -0,0 +1,46 @@
+-- Create translation jobs table. Jobs are deleted once they succeed, so
+-- the table only holds pending, running and dead-lettered (failed) work.
+CREATE TABLE IF NOT EXISTS translation_jobs (
+    id VARCHAR(255) PRIMARY KEY,
+    target_type VARCHAR(20) NOT NULL,
+    target_id VARCHAR(255) NOT NULL,
+    source_language VARCHAR(10) NOT NULL,
+    target_language VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    run_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_translation_jobs_due ON translation_jobs(status, run_at);
+CREATE INDEX IF NOT EXISTS idx_translation_jobs_target ON translation_jobs(target_type, target_id);
+
+-- Queue translations that were lost by the old fire-and-forget goroutines:
+-- anything that still only has its original language.
+INSERT INTO translation_jobs (id, target_type, target_id, source_language, target_language, run_at, created_at, updated_at)
+SELECT 'backfill-thread-' || tt.thread_id,
+       'thread',
+       tt.thread_id,
+       tt.language,
+       CASE tt.language WHEN 'ru' THEN 'en' ELSE 'ru' END,
+       t.created_at,
+       t.created_at,
+       t.created_at
+FROM thread_translations tt
+JOIN threads t ON t.id = tt.thread_id
+WHERE tt.thread_id IN (SELECT thread_id FROM thread_translations GROUP BY thread_id HAVING COUNT(*) = 1);
+
+INSERT INTO translation_jobs (id, target_type, target_id, source_language, target_language, run_at, created_at, updated_at)
+SELECT 'backfill-comment-' || ct.comment_id,
+       'comment',
+       ct.comment_id,
+       ct.language,
+       CASE ct.language WHEN 'ru' THEN 'en' ELSE 'ru' END,
+       c.created_at,
+       c.created_at,
+       c.created_at
+FROM comment_translations ct
+JOIN comments c ON c.id = ct.comment_id
+WHERE ct.comment_id IN (SELECT comment_id FROM comment_translations GROUP BY comment_id HAVING COUNT(*) = 1);
@@ -0,0 +1,2 @@
+ALTER TABLE comments DROP COLUMN language;
+ALTER TABLE threads DROP COLUMN language;
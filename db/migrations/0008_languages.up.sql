@@ -0,0 +1,21 @@
+-- Record the original language of threads and comments so translations into
+-- newly configured languages know their source. Existing rows take the
+-- language of their first translation, which is always the original.
+ALTER TABLE threads ADD COLUMN language VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE comments ADD COLUMN language VARCHAR(10) NOT NULL DEFAULT '';
+
+UPDATE threads
+SET language = COALESCE((
+    SELECT tt.language FROM thread_translations tt
+    WHERE tt.thread_id = threads.id
+    ORDER BY CAST(tt.id AS INTEGER)
+    LIMIT 1
+), '');
+
+UPDATE comments
+SET language = COALESCE((
+    SELECT ct.language FROM comment_translations ct
+    WHERE ct.comment_id = comments.id
+    ORDER BY CAST(ct.id AS INTEGER)
+    LIMIT 1
+), '');
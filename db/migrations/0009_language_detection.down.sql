@@ -0,0 +1,4 @@
+ALTER TABLE comment_translations DROP COLUMN detection_confidence;
+ALTER TABLE comment_translations DROP COLUMN detected_language;
+ALTER TABLE thread_translations DROP COLUMN detection_confidence;
+ALTER TABLE thread_translations DROP COLUMN detected_language;
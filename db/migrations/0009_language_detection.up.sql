@@ -0,0 +1,6 @@
+-- Keep the language detector's verdict next to the original text. The row's
+-- language may differ when the author overrode detection.
+ALTER TABLE thread_translations ADD COLUMN detected_language VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE thread_translations ADD COLUMN detection_confidence REAL NOT NULL DEFAULT 0;
+ALTER TABLE comment_translations ADD COLUMN detected_language VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE comment_translations ADD COLUMN detection_confidence REAL NOT NULL DEFAULT 0;
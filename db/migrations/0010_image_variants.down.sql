@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS comment_image_variants;
+ALTER TABLE comment_images DROP COLUMN height;
+ALTER TABLE comment_images DROP COLUMN width;
@@ -0,0 +1,17 @@
+-- Record the size of stored images. Images uploaded before this migration
+-- keep 0 until they are uploaded again.
+ALTER TABLE comment_images ADD COLUMN width INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE comment_images ADD COLUMN height INTEGER NOT NULL DEFAULT 0;
+
+-- Resized copies of comment images, served to clients as a srcset
+CREATE TABLE IF NOT EXISTS comment_image_variants (
+    id VARCHAR(255) PRIMARY KEY,
+    image_id VARCHAR(255) NOT NULL,
+    variant VARCHAR(32) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    filepath VARCHAR(255) NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    FOREIGN KEY (image_id) REFERENCES comment_images(id),
+    UNIQUE (image_id, variant)
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS thread_image_variants;
+DROP INDEX IF EXISTS idx_thread_images_thread;
+DROP TABLE IF EXISTS thread_images;
+ALTER TABLE comment_images DROP COLUMN caption;
+ALTER TABLE comment_images DROP COLUMN position;
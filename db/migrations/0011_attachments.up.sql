@@ -0,0 +1,31 @@
+-- Comments may carry several ordered, captioned images
+ALTER TABLE comment_images ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE comment_images ADD COLUMN caption TEXT NOT NULL DEFAULT '';
+
+-- Threads get the same attachments as comments
+CREATE TABLE IF NOT EXISTS thread_images (
+    id VARCHAR(255) PRIMARY KEY,
+    thread_id VARCHAR(255) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    filepath VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    width INTEGER NOT NULL DEFAULT 0,
+    height INTEGER NOT NULL DEFAULT 0,
+    position INTEGER NOT NULL DEFAULT 0,
+    caption TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (thread_id) REFERENCES threads(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_thread_images_thread ON thread_images(thread_id, position);
+
+CREATE TABLE IF NOT EXISTS thread_image_variants (
+    id VARCHAR(255) PRIMARY KEY,
+    image_id VARCHAR(255) NOT NULL,
+    variant VARCHAR(32) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    filepath VARCHAR(255) NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    FOREIGN KEY (image_id) REFERENCES thread_images(id),
+    UNIQUE (image_id, variant)
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_revisions_target;
+DROP TABLE IF EXISTS revisions;
+ALTER TABLE comments DROP COLUMN edited_at;
+ALTER TABLE threads DROP COLUMN edited_at;
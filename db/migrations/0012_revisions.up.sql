@@ -0,0 +1,19 @@
+-- When a thread or comment was last edited by its author or a moderator
+ALTER TABLE threads ADD COLUMN edited_at TIMESTAMP;
+ALTER TABLE comments ADD COLUMN edited_at TIMESTAMP;
+
+-- Prior versions of edited threads and comments. Each row is the text as it
+-- was before the edit made by edited_by at created_at.
+CREATE TABLE IF NOT EXISTS revisions (
+    id VARCHAR(255) PRIMARY KEY,
+    target_type VARCHAR(20) NOT NULL,
+    target_id VARCHAR(255) NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    content TEXT NOT NULL,
+    language VARCHAR(10) NOT NULL,
+    edited_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (edited_by) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_revisions_target ON revisions(target_type, target_id, created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_comments_parent;
+ALTER TABLE comments DROP COLUMN depth;
+ALTER TABLE comments DROP COLUMN parent_comment_id;
@@ -0,0 +1,6 @@
+-- Comments may reply to another comment of the same thread. depth counts the
+-- ancestors of a comment, so top-level comments have depth 0.
+ALTER TABLE comments ADD COLUMN parent_comment_id VARCHAR(255);
+ALTER TABLE comments ADD COLUMN depth INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_comments_parent ON comments(parent_comment_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_threads_category_activity;
+ALTER TABLE threads DROP COLUMN last_activity_at;
+DROP TABLE IF EXISTS category_translations;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,56 @@
+-- Categories, previously hard-coded in the API
+CREATE TABLE IF NOT EXISTS categories (
+    slug VARCHAR(50) PRIMARY KEY,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    icon VARCHAR(64) NOT NULL DEFAULT '',
+    -- Only moderators may start threads in read-only categories
+    read_only BOOLEAN NOT NULL DEFAULT 0
+);
+
+-- Category labels and descriptions, one row per language
+CREATE TABLE IF NOT EXISTS category_translations (
+    category_slug VARCHAR(50) NOT NULL,
+    language VARCHAR(10) NOT NULL,
+    label TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (category_slug, language),
+    FOREIGN KEY (category_slug) REFERENCES categories(slug)
+);
+
+INSERT OR IGNORE INTO categories (slug, sort_order, icon, read_only) VALUES
+    ('general', 10, '', 0),
+    ('help', 20, '', 0),
+    ('discussion', 30, '', 0),
+    ('announcement', 40, '', 1);
+
+INSERT OR IGNORE INTO category_translations (category_slug, language, label) VALUES
+    ('general', 'en', 'General'),
+    ('general', 'ru', 'Общее'),
+    ('general', 'pt', 'Geral'),
+    ('general', 'es', 'General'),
+    ('general', 'zh', '综合'),
+    ('help', 'en', 'Help'),
+    ('help', 'ru', 'Помощь'),
+    ('help', 'pt', 'Ajuda'),
+    ('help', 'es', 'Ayuda'),
+    ('help', 'zh', '帮助'),
+    ('discussion', 'en', 'Discussion'),
+    ('discussion', 'ru', 'Обсуждение'),
+    ('discussion', 'pt', 'Discussão'),
+    ('discussion', 'es', 'Discusión'),
+    ('discussion', 'zh', '讨论'),
+    ('announcement', 'en', 'Announcement'),
+    ('announcement', 'ru', 'Объявление'),
+    ('announcement', 'pt', 'Anúncio'),
+    ('announcement', 'es', 'Anuncio'),
+    ('announcement', 'zh', '公告');
+
+-- When a thread was last posted to, for per-category activity
+ALTER TABLE threads ADD COLUMN last_activity_at TIMESTAMP;
+
+UPDATE threads SET last_activity_at = COALESCE(
+    (SELECT MAX(c.created_at) FROM comments c WHERE c.thread_id = threads.id),
+    created_at
+);
+
+CREATE INDEX IF NOT EXISTS idx_threads_category_activity ON threads(category, last_activity_at);
@@ -0,0 +1,8 @@
+ALTER TABLE threads DROP COLUMN approved;
+
+ALTER TABLE categories ADD COLUMN read_only BOOLEAN NOT NULL DEFAULT 0;
+UPDATE categories SET read_only = 1 WHERE thread_role IN ('moderator', 'admin');
+
+ALTER TABLE categories DROP COLUMN requires_approval;
+ALTER TABLE categories DROP COLUMN comment_role;
+ALTER TABLE categories DROP COLUMN thread_role;
@@ -0,0 +1,12 @@
+-- Minimum role needed to start threads and to comment in a category. 'anyone'
+-- also admits guests; otherwise it is one of the user roles.
+ALTER TABLE categories ADD COLUMN thread_role VARCHAR(20) NOT NULL DEFAULT 'anyone';
+ALTER TABLE categories ADD COLUMN comment_role VARCHAR(20) NOT NULL DEFAULT 'anyone';
+-- New threads from non-moderators wait for a moderator to approve them
+ALTER TABLE categories ADD COLUMN requires_approval BOOLEAN NOT NULL DEFAULT 0;
+
+UPDATE categories SET thread_role = 'moderator' WHERE read_only = 1;
+ALTER TABLE categories DROP COLUMN read_only;
+
+-- Unapproved threads are only shown to their author and moderators
+ALTER TABLE threads ADD COLUMN approved BOOLEAN NOT NULL DEFAULT 1;
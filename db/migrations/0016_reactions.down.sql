@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_threads_active;
+DROP INDEX IF EXISTS idx_threads_top;
+ALTER TABLE comments DROP COLUMN last_activity_at;
+ALTER TABLE comments DROP COLUMN score;
+ALTER TABLE threads DROP COLUMN score;
+DROP TABLE IF EXISTS reactions;
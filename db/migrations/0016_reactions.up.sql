@@ -0,0 +1,26 @@
+-- Reactions to threads and comments, one row per user and reaction. 'up' and
+-- 'down' are votes and exclude each other; the others are emoji.
+CREATE TABLE IF NOT EXISTS reactions (
+    target_type VARCHAR(20) NOT NULL,
+    target_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    reaction VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (target_type, target_id, user_id, reaction),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- Up votes minus down votes, kept in step with reactions for sorting by score
+ALTER TABLE threads ADD COLUMN score INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN score INTEGER NOT NULL DEFAULT 0;
+
+-- When a comment was posted or last replied to
+ALTER TABLE comments ADD COLUMN last_activity_at TIMESTAMP;
+
+UPDATE comments SET last_activity_at = COALESCE(
+    (SELECT MAX(r.created_at) FROM comments r WHERE r.parent_comment_id = comments.id),
+    created_at
+);
+
+CREATE INDEX IF NOT EXISTS idx_threads_top ON threads(pinned, score, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_threads_active ON threads(pinned, last_activity_at, created_at, id);
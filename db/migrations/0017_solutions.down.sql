@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_threads_accepted_comment;
+ALTER TABLE threads DROP COLUMN accepted_comment_id;
+ALTER TABLE categories DROP COLUMN solutions_enabled;
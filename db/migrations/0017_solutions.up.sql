@@ -0,0 +1,9 @@
+-- Threads in categories with solutions enabled can have one comment marked as
+-- the accepted answer
+ALTER TABLE categories ADD COLUMN solutions_enabled BOOLEAN NOT NULL DEFAULT 0;
+
+UPDATE categories SET solutions_enabled = 1 WHERE slug = 'help';
+
+ALTER TABLE threads ADD COLUMN accepted_comment_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_threads_accepted_comment ON threads(accepted_comment_id);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_thread_subscriptions_thread;
+DROP TABLE IF EXISTS thread_subscriptions;
+DROP INDEX IF EXISTS idx_notifications_post;
+DROP INDEX IF EXISTS idx_notifications_comment;
+DROP INDEX IF EXISTS idx_notifications_thread;
+DROP INDEX IF EXISTS idx_notifications_user;
+DROP TABLE IF EXISTS notifications;
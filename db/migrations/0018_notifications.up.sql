@@ -0,0 +1,35 @@
+-- Activity users are told about: replies to their threads and comments,
+-- comments in threads they follow and mentions. actor_id is who caused it,
+-- NULL for guests; comment_id is NULL for mentions in a thread itself.
+CREATE TABLE IF NOT EXISTS notifications (
+    id VARCHAR(255) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    thread_id VARCHAR(255) NOT NULL,
+    comment_id VARCHAR(255),
+    actor_id VARCHAR(255),
+    created_at TIMESTAMP NOT NULL,
+    read_at TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_notifications_thread ON notifications(thread_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_comment ON notifications(comment_id);
+
+-- A user hears about each post once, however many reasons there are
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_post
+    ON notifications(user_id, thread_id, COALESCE(comment_id, ''));
+
+-- Thread subscriptions chosen by users. Authors follow their own threads
+-- unless they opt out, which is kept as subscribed = 0.
+CREATE TABLE IF NOT EXISTS thread_subscriptions (
+    user_id VARCHAR(255) NOT NULL,
+    thread_id VARCHAR(255) NOT NULL,
+    subscribed BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, thread_id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_thread_subscriptions_thread ON thread_subscriptions(thread_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_category_subscriptions_category;
+DROP TABLE IF EXISTS category_subscriptions;
+DROP INDEX IF EXISTS idx_email_settings_digest;
+DROP TABLE IF EXISTS email_settings;
@@ -0,0 +1,36 @@
+-- E-mail delivery settings, one row per user who gave an address. Removing
+-- the address empties email but keeps the row, so that verification_sent_at
+-- keeps throttling confirmation e-mails. Nothing is
+-- sent to an address until verified_at is set by following the confirmation
+-- link carrying verification_token. language picks the templates, digest is
+-- off, daily or weekly, and digests cover the threads created after
+-- last_digest_at. unsubscribe_token is put in every e-mail so recipients can
+-- opt out without logging in.
+CREATE TABLE IF NOT EXISTS email_settings (
+    user_id VARCHAR(255) PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    verified_at TIMESTAMP,
+    verification_token VARCHAR(64) UNIQUE,
+    verification_sent_at TIMESTAMP,
+    language VARCHAR(10) NOT NULL,
+    notifications BOOLEAN NOT NULL DEFAULT 1,
+    digest VARCHAR(10) NOT NULL DEFAULT 'off',
+    last_digest_at TIMESTAMP NOT NULL,
+    unsubscribe_token VARCHAR(64) NOT NULL UNIQUE,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_settings_digest ON email_settings(digest, last_digest_at);
+
+-- Categories whose new threads users receive in their digest
+CREATE TABLE IF NOT EXISTS category_subscriptions (
+    user_id VARCHAR(255) NOT NULL,
+    category_slug VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, category_slug),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (category_slug) REFERENCES categories(slug)
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_subscriptions_category ON category_subscriptions(category_slug);
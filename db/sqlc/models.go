@@ -5,13 +5,45 @@
 package db
 
 import (
+	"database/sql"
 	"time"
 )
 
+type Category struct {
+	Slug             string `json:"slug"`
+	SortOrder        int64  `json:"sort_order"`
+	Icon             string `json:"icon"`
+	ThreadRole       string `json:"thread_role"`
+	CommentRole      string `json:"comment_role"`
+	RequiresApproval bool   `json:"requires_approval"`
+	SolutionsEnabled bool   `json:"solutions_enabled"`
+}
+
+type CategorySubscription struct {
+	UserID       string    `json:"user_id"`
+	CategorySlug string    `json:"category_slug"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type CategoryTranslation struct {
+	CategorySlug string `json:"category_slug"`
+	Language     string `json:"language"`
+	Label        string `json:"label"`
+	Description  string `json:"description"`
+}
+
 type Comment struct {
-	ID        string    `json:"id"`
-	ThreadID  string    `json:"thread_id"`
-	CreatedAt time.Time `json:"created_at"`
+	ID              string         `json:"id"`
+	ThreadID        string         `json:"thread_id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	AuthorID        sql.NullString `json:"author_id"`
+	Hidden          bool           `json:"hidden"`
+	Language        string         `json:"language"`
+	EditedAt        sql.NullTime   `json:"edited_at"`
+	ParentCommentID sql.NullString `json:"parent_comment_id"`
+	Depth           int64          `json:"depth"`
+	Score           int64          `json:"score"`
+	LastActivityAt  sql.NullTime   `json:"last_activity_at"`
 }
 
 type CommentImage struct {
@@ -20,19 +52,167 @@ type CommentImage struct {
 	Filename  string    `json:"filename"`
 	Filepath  string    `json:"filepath"`
 	CreatedAt time.Time `json:"created_at"`
+	Width     int64     `json:"width"`
+	Height    int64     `json:"height"`
+	Position  int64     `json:"position"`
+	Caption   string    `json:"caption"`
+}
+
+type CommentImageVariant struct {
+	ID       string `json:"id"`
+	ImageID  string `json:"image_id"`
+	Variant  string `json:"variant"`
+	Filename string `json:"filename"`
+	Filepath string `json:"filepath"`
+	Width    int64  `json:"width"`
+	Height   int64  `json:"height"`
 }
 
 type CommentTranslation struct {
-	ID        string `json:"id"`
-	CommentID string `json:"comment_id"`
-	Language  string `json:"language"`
-	Content   string `json:"content"`
+	ID                  string  `json:"id"`
+	CommentID           string  `json:"comment_id"`
+	Language            string  `json:"language"`
+	Content             string  `json:"content"`
+	DetectedLanguage    string  `json:"detected_language"`
+	DetectionConfidence float64 `json:"detection_confidence"`
+}
+
+type EmailSetting struct {
+	UserID             string         `json:"user_id"`
+	Email              string         `json:"email"`
+	VerifiedAt         sql.NullTime   `json:"verified_at"`
+	VerificationToken  sql.NullString `json:"verification_token"`
+	VerificationSentAt sql.NullTime   `json:"verification_sent_at"`
+	Language           string         `json:"language"`
+	Notifications      bool           `json:"notifications"`
+	Digest             string         `json:"digest"`
+	LastDigestAt       time.Time      `json:"last_digest_at"`
+	UnsubscribeToken   string         `json:"unsubscribe_token"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+}
+
+type ModerationLog struct {
+	ID          string    `json:"id"`
+	ModeratorID string    `json:"moderator_id"`
+	Action      string    `json:"action"`
+	TargetType  string    `json:"target_type"`
+	TargetID    string    `json:"target_id"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Notification struct {
+	ID        string         `json:"id"`
+	UserID    string         `json:"user_id"`
+	Kind      string         `json:"kind"`
+	ThreadID  string         `json:"thread_id"`
+	CommentID sql.NullString `json:"comment_id"`
+	ActorID   sql.NullString `json:"actor_id"`
+	CreatedAt time.Time      `json:"created_at"`
+	ReadAt    sql.NullTime   `json:"read_at"`
+}
+
+type Reaction struct {
+	TargetType string    `json:"target_type"`
+	TargetID   string    `json:"target_id"`
+	UserID     string    `json:"user_id"`
+	Reaction   string    `json:"reaction"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Revision struct {
+	ID         string         `json:"id"`
+	TargetType string         `json:"target_type"`
+	TargetID   string         `json:"target_id"`
+	Title      string         `json:"title"`
+	Content    string         `json:"content"`
+	Language   string         `json:"language"`
+	EditedBy   sql.NullString `json:"edited_by"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+type Session struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 type Thread struct {
+	ID                string         `json:"id"`
+	Title             string         `json:"title"`
+	Content           string         `json:"content"`
+	Category          string         `json:"category"`
+	CreatedAt         time.Time      `json:"created_at"`
+	AuthorID          sql.NullString `json:"author_id"`
+	Pinned            bool           `json:"pinned"`
+	Locked            bool           `json:"locked"`
+	Hidden            bool           `json:"hidden"`
+	Language          string         `json:"language"`
+	EditedAt          sql.NullTime   `json:"edited_at"`
+	LastActivityAt    sql.NullTime   `json:"last_activity_at"`
+	Approved          bool           `json:"approved"`
+	Score             int64          `json:"score"`
+	AcceptedCommentID sql.NullString `json:"accepted_comment_id"`
+}
+
+type ThreadImage struct {
 	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Category  string    `json:"category"`
+	ThreadID  string    `json:"thread_id"`
+	Filename  string    `json:"filename"`
+	Filepath  string    `json:"filepath"`
 	CreatedAt time.Time `json:"created_at"`
+	Width     int64     `json:"width"`
+	Height    int64     `json:"height"`
+	Position  int64     `json:"position"`
+	Caption   string    `json:"caption"`
+}
+
+type ThreadImageVariant struct {
+	ID       string `json:"id"`
+	ImageID  string `json:"image_id"`
+	Variant  string `json:"variant"`
+	Filename string `json:"filename"`
+	Filepath string `json:"filepath"`
+	Width    int64  `json:"width"`
+	Height   int64  `json:"height"`
+}
+
+type ThreadSubscription struct {
+	UserID     string    `json:"user_id"`
+	ThreadID   string    `json:"thread_id"`
+	Subscribed bool      `json:"subscribed"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ThreadTranslation struct {
+	ID                  string  `json:"id"`
+	ThreadID            string  `json:"thread_id"`
+	Language            string  `json:"language"`
+	Title               string  `json:"title"`
+	Content             string  `json:"content"`
+	DetectedLanguage    string  `json:"detected_language"`
+	DetectionConfidence float64 `json:"detection_confidence"`
+}
+
+type TranslationJob struct {
+	ID             string    `json:"id"`
+	TargetType     string    `json:"target_type"`
+	TargetID       string    `json:"target_id"`
+	SourceLanguage string    `json:"source_language"`
+	TargetLanguage string    `json:"target_language"`
+	Status         string    `json:"status"`
+	Attempts       int64     `json:"attempts"`
+	LastError      string    `json:"last_error"`
+	RunAt          time.Time `json:"run_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type User struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+	Role         string    `json:"role"`
 }
@@ -6,17 +6,127 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 type Querier interface {
+	AddCategorySubscription(ctx context.Context, arg AddCategorySubscriptionParams) error
+	AddReaction(ctx context.Context, arg AddReactionParams) error
+	ClaimTranslationJob(ctx context.Context, now time.Time) (TranslationJob, error)
+	ClearAcceptedComment(ctx context.Context, acceptedCommentID sql.NullString) error
+	ClearEmailSettings(ctx context.Context, arg ClearEmailSettingsParams) error
+	ConfirmEmail(ctx context.Context, arg ConfirmEmailParams) error
+	CountUnreadNotifications(ctx context.Context, arg CountUnreadNotificationsParams) (int64, error)
+	CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error)
+	CreateCategoryTranslation(ctx context.Context, arg CreateCategoryTranslationParams) (CategoryTranslation, error)
 	CreateComment(ctx context.Context, arg CreateCommentParams) (Comment, error)
 	CreateCommentImage(ctx context.Context, arg CreateCommentImageParams) (CommentImage, error)
+	CreateCommentImageVariant(ctx context.Context, arg CreateCommentImageVariantParams) (CommentImageVariant, error)
 	CreateCommentTranslation(ctx context.Context, arg CreateCommentTranslationParams) (CommentTranslation, error)
+	CreateModerationLog(ctx context.Context, arg CreateModerationLogParams) (ModerationLog, error)
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) (int64, error)
+	CreateRevision(ctx context.Context, arg CreateRevisionParams) (Revision, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateThread(ctx context.Context, arg CreateThreadParams) (Thread, error)
-	GetThread(ctx context.Context, id string) (Thread, error)
-	GetThreadComments(ctx context.Context, threadID string) ([]GetThreadCommentsRow, error)
-	ListAllThreads(ctx context.Context) ([]Thread, error)
-	ListThreads(ctx context.Context, category string) ([]Thread, error)
+	CreateThreadImage(ctx context.Context, arg CreateThreadImageParams) (ThreadImage, error)
+	CreateThreadImageVariant(ctx context.Context, arg CreateThreadImageVariantParams) (ThreadImageVariant, error)
+	CreateThreadTranslation(ctx context.Context, arg CreateThreadTranslationParams) (ThreadTranslation, error)
+	CreateTranslationJob(ctx context.Context, arg CreateTranslationJobParams) (TranslationJob, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DecrementReplyDepths(ctx context.Context, commentID string) error
+	DeleteCategorySubscribers(ctx context.Context, categorySlug string) error
+	DeleteCategorySubscriptions(ctx context.Context, userID string) error
+	DeleteCategoryTranslations(ctx context.Context, categorySlug string) error
+	DeleteComment(ctx context.Context, id string) (int64, error)
+	DeleteCommentImageVariants(ctx context.Context, commentID string) error
+	DeleteCommentImages(ctx context.Context, commentID string) error
+	DeleteCommentNotifications(ctx context.Context, commentID sql.NullString) error
+	DeleteCommentTranslations(ctx context.Context, commentID string) error
+	DeleteExpiredSessions(ctx context.Context, expiresAt time.Time) error
+	DeleteReaction(ctx context.Context, arg DeleteReactionParams) (int64, error)
+	DeleteReactions(ctx context.Context, arg DeleteReactionsParams) error
+	DeleteRevisions(ctx context.Context, arg DeleteRevisionsParams) error
+	DeleteSession(ctx context.Context, id string) error
+	DeleteThread(ctx context.Context, id string) (int64, error)
+	DeleteThreadCommentImageVariants(ctx context.Context, threadID string) error
+	DeleteThreadCommentImages(ctx context.Context, threadID string) error
+	DeleteThreadCommentReactions(ctx context.Context, threadID string) error
+	DeleteThreadCommentRevisions(ctx context.Context, threadID string) error
+	DeleteThreadCommentTranslationJobs(ctx context.Context, threadID string) error
+	DeleteThreadCommentTranslations(ctx context.Context, threadID string) error
+	DeleteThreadComments(ctx context.Context, threadID string) error
+	DeleteThreadImageVariants(ctx context.Context, threadID string) error
+	DeleteThreadImages(ctx context.Context, threadID string) error
+	DeleteThreadNotifications(ctx context.Context, threadID string) error
+	DeleteThreadSubscriptions(ctx context.Context, threadID string) error
+	DeleteThreadTranslations(ctx context.Context, threadID string) error
+	DeleteTranslationJob(ctx context.Context, id string) error
+	DeleteTranslationJobs(ctx context.Context, arg DeleteTranslationJobsParams) error
+	DeleteUnusedCategory(ctx context.Context, slug string) (int64, error)
+	EnqueueMissingCommentTranslations(ctx context.Context, arg EnqueueMissingCommentTranslationsParams) (int64, error)
+	EnqueueMissingThreadTranslations(ctx context.Context, arg EnqueueMissingThreadTranslationsParams) (int64, error)
+	FailTranslationJob(ctx context.Context, arg FailTranslationJobParams) error
+	GetCategory(ctx context.Context, slug string) (Category, error)
+	GetComment(ctx context.Context, id string) (Comment, error)
+	GetCommentTranslation(ctx context.Context, arg GetCommentTranslationParams) (CommentTranslation, error)
+	GetEmailSettings(ctx context.Context, userID string) (EmailSetting, error)
+	GetEmailSettingsByToken(ctx context.Context, unsubscribeToken string) (EmailSetting, error)
+	GetEmailSettingsByVerificationToken(ctx context.Context, verificationToken sql.NullString) (EmailSetting, error)
+	GetReactions(ctx context.Context, arg GetReactionsParams) (GetReactionsRow, error)
+	GetSessionUser(ctx context.Context, arg GetSessionUserParams) (User, error)
+	GetThread(ctx context.Context, arg GetThreadParams) (GetThreadRow, error)
+	GetThreadComments(ctx context.Context, arg GetThreadCommentsParams) ([]GetThreadCommentsRow, error)
+	GetThreadSubscription(ctx context.Context, arg GetThreadSubscriptionParams) (ThreadSubscription, error)
+	GetThreadTranslation(ctx context.Context, arg GetThreadTranslationParams) (ThreadTranslation, error)
+	GetUser(ctx context.Context, id string) (User, error)
+	GetUserByUsername(ctx context.Context, username string) (User, error)
+	ListCategories(ctx context.Context) ([]ListCategoriesRow, error)
+	ListCategorySubscriptions(ctx context.Context, userID string) ([]string, error)
+	ListCategoryTranslations(ctx context.Context) ([]CategoryTranslation, error)
+	ListCommentImageVariants(ctx context.Context, commentID string) ([]CommentImageVariant, error)
+	ListCommentImages(ctx context.Context, commentID string) ([]CommentImage, error)
+	ListDigestThreads(ctx context.Context, arg ListDigestThreadsParams) ([]ListDigestThreadsRow, error)
+	ListDueDigests(ctx context.Context, arg ListDueDigestsParams) ([]EmailSetting, error)
+	ListModerationLog(ctx context.Context, limit int64) ([]ListModerationLogRow, error)
+	ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]ListNotificationsRow, error)
+	ListRevisions(ctx context.Context, arg ListRevisionsParams) ([]ListRevisionsRow, error)
+	ListThreadCommentImageVariants(ctx context.Context, threadID string) ([]CommentImageVariant, error)
+	ListThreadCommentImages(ctx context.Context, threadID string) ([]CommentImage, error)
+	ListThreadImageVariants(ctx context.Context, threadID string) ([]ThreadImageVariant, error)
+	ListThreadImages(ctx context.Context, threadID string) ([]ThreadImage, error)
+	ListThreadSubscribers(ctx context.Context, threadID string) ([]string, error)
+	ListThreads(ctx context.Context, arg ListThreadsParams) ([]ListThreadsRow, error)
+	ListTranslationJobs(ctx context.Context, arg ListTranslationJobsParams) ([]TranslationJob, error)
+	MarkAllNotificationsRead(ctx context.Context, arg MarkAllNotificationsReadParams) (int64, error)
+	MarkDigestSent(ctx context.Context, arg MarkDigestSentParams) error
+	ReparentCommentReplies(ctx context.Context, arg ReparentCommentRepliesParams) error
+	RequeueFailedTranslationJobs(ctx context.Context, now time.Time) (int64, error)
+	RequeueTranslationJob(ctx context.Context, arg RequeueTranslationJobParams) (int64, error)
+	ResetRunningTranslationJobs(ctx context.Context, updatedAt time.Time) error
+	SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error)
+	SetAcceptedComment(ctx context.Context, arg SetAcceptedCommentParams) (int64, error)
+	SetCommentHidden(ctx context.Context, arg SetCommentHiddenParams) (int64, error)
+	SetEmailDigest(ctx context.Context, arg SetEmailDigestParams) error
+	SetEmailNotifications(ctx context.Context, arg SetEmailNotificationsParams) error
+	SetEmailVerification(ctx context.Context, arg SetEmailVerificationParams) error
+	SetNotificationRead(ctx context.Context, arg SetNotificationReadParams) (int64, error)
+	SetThreadApproved(ctx context.Context, arg SetThreadApprovedParams) (int64, error)
+	SetThreadHidden(ctx context.Context, arg SetThreadHiddenParams) (int64, error)
+	SetThreadLocked(ctx context.Context, arg SetThreadLockedParams) (int64, error)
+	SetThreadPinned(ctx context.Context, arg SetThreadPinnedParams) (int64, error)
+	SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error)
+	TouchCommentActivity(ctx context.Context, arg TouchCommentActivityParams) error
+	TouchThreadActivity(ctx context.Context, arg TouchThreadActivityParams) error
+	UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (int64, error)
+	UpdateComment(ctx context.Context, arg UpdateCommentParams) (int64, error)
+	UpdateCommentScore(ctx context.Context, id string) error
+	UpdateThread(ctx context.Context, arg UpdateThreadParams) (int64, error)
+	UpdateThreadScore(ctx context.Context, id string) error
+	UpsertCommentTranslation(ctx context.Context, arg UpsertCommentTranslationParams) (CommentTranslation, error)
+	UpsertEmailSettings(ctx context.Context, arg UpsertEmailSettingsParams) (EmailSetting, error)
+	UpsertThreadSubscription(ctx context.Context, arg UpsertThreadSubscriptionParams) error
+	UpsertThreadTranslation(ctx context.Context, arg UpsertThreadTranslationParams) (ThreadTranslation, error)
 }
 
 var _ Querier = (*Queries)(nil)
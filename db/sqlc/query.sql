@@ -1,42 +1,674 @@
 -- name: CreateThread :one
-INSERT INTO threads (id, title, content, category, created_at)
-VALUES (?, ?, ?, ?, ?) RETURNING *;
+INSERT INTO threads (id, title, content, category, created_at, author_id, language, last_activity_at, approved)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
 
 -- name: GetThread :one
-SELECT * FROM threads WHERE id = ?;
+SELECT t.*, u.username AS author_name, tt.title AS translated_title, tt.content AS translated_content
+FROM threads t
+LEFT JOIN users u ON u.id = t.author_id
+LEFT JOIN thread_translations tt ON tt.thread_id = t.id AND tt.language = sqlc.arg(language)
+WHERE t.id = sqlc.arg(id);
 
 -- name: ListThreads :many
-SELECT * FROM threads 
-WHERE category = sqlc.arg(category)
-ORDER BY created_at DESC;
+SELECT t.*, u.username AS author_name, tt.title AS translated_title, tt.content AS translated_content,
+    CAST((SELECT json_group_object(reaction, n) FROM (
+        SELECT reaction, COUNT(*) AS n FROM reactions
+        WHERE target_type = 'thread' AND target_id = t.id
+        GROUP BY reaction
+    )) AS TEXT) AS reaction_counts,
+    CAST((SELECT json_group_array(reaction) FROM reactions
+        WHERE target_type = 'thread' AND target_id = t.id AND user_id = sqlc.narg(user_id)
+    ) AS TEXT) AS user_reactions
+FROM threads t
+LEFT JOIN users u ON u.id = t.author_id
+LEFT JOIN thread_translations tt ON tt.thread_id = t.id AND tt.language = sqlc.arg(language)
+WHERE (sqlc.narg(category) IS NULL OR t.category = sqlc.narg(category))
+  AND ((t.hidden = 0 AND t.approved = 1) OR CAST(sqlc.arg(include_hidden) AS BOOLEAN))
+  AND (sqlc.narg(solved) IS NULL OR (
+       (t.accepted_comment_id IS NOT NULL) = sqlc.narg(solved)
+       AND t.category IN (SELECT slug FROM categories WHERE solutions_enabled = 1)))
+  AND (NOT CAST(sqlc.arg(has_cursor) AS BOOLEAN) OR CASE sqlc.arg(sort)
+       WHEN 'top' THEN (t.pinned, t.score, t.created_at, t.id)
+           < (sqlc.arg(cursor_pinned), sqlc.arg(cursor_score), sqlc.arg(cursor_created_at), sqlc.arg(cursor_id))
+       WHEN 'active' THEN (t.pinned, COALESCE(t.last_activity_at, t.created_at), t.created_at, t.id)
+           < (sqlc.arg(cursor_pinned), sqlc.arg(cursor_active_at), sqlc.arg(cursor_created_at), sqlc.arg(cursor_id))
+       ELSE (t.pinned, t.created_at, t.id) < (sqlc.arg(cursor_pinned), sqlc.arg(cursor_created_at), sqlc.arg(cursor_id))
+       END)
+ORDER BY t.pinned DESC,
+    CASE WHEN sqlc.arg(sort) = 'top' THEN t.score END DESC,
+    CASE WHEN sqlc.arg(sort) = 'active' THEN COALESCE(t.last_activity_at, t.created_at) END DESC,
+    t.created_at DESC, t.id DESC
+LIMIT sqlc.arg(limit);
 
--- name: ListAllThreads :many
-SELECT * FROM threads ORDER BY created_at DESC;
+-- name: CreateThreadTranslation :one
+INSERT INTO thread_translations (id, thread_id, language, title, content, detected_language, detection_confidence)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING *;
 
 -- name: CreateComment :one
-INSERT INTO comments (id, thread_id, created_at)
-VALUES (?, ?, ?) RETURNING *;
+INSERT INTO comments (id, thread_id, created_at, author_id, language, parent_comment_id, depth, last_activity_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
 
 -- name: CreateCommentTranslation :one
-INSERT INTO comment_translations (id, comment_id, language, content)
-VALUES (?, ?, ?, ?) RETURNING *;
+INSERT INTO comment_translations (id, comment_id, language, content, detected_language, detection_confidence)
+VALUES (?, ?, ?, ?, ?, ?) RETURNING *;
 
 -- name: CreateCommentImage :one
-INSERT INTO comment_images (id, comment_id, filename, filepath, created_at)
-VALUES (?, ?, ?, ?, ?) RETURNING *;
+INSERT INTO comment_images (id, comment_id, filename, filepath, created_at, width, height, position, caption)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: CreateCommentImageVariant :one
+INSERT INTO comment_image_variants (id, image_id, variant, filename, filepath, width, height)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING *;
 
 -- name: GetThreadComments :many
-SELECT 
+SELECT
     c.id,
     c.thread_id,
     c.created_at,
+    c.author_id,
+    c.hidden,
+    c.edited_at,
+    c.parent_comment_id,
+    c.depth,
+    c.score,
+    c.last_activity_at,
+    c.language AS original_language,
+    c.accepted,
+    c.reaction_counts,
+    c.user_reactions,
+    u.username as author_name,
     ct.content,
     ct.language,
     ci.id as image_id,
     ci.filename,
-    ci.filepath
-FROM comments c
+    ci.filepath,
+    ci.width AS image_width,
+    ci.height AS image_height,
+    ci.position AS image_position,
+    ci.caption AS image_caption
+FROM (
+    SELECT comments.*,
+        EXISTS (SELECT 1 FROM threads WHERE threads.accepted_comment_id = comments.id) AS accepted,
+        CAST((SELECT json_group_object(reaction, n) FROM (
+            SELECT reaction, COUNT(*) AS n FROM reactions
+            WHERE target_type = 'comment' AND target_id = comments.id
+            GROUP BY reaction
+        )) AS TEXT) AS reaction_counts,
+        CAST((SELECT json_group_array(reaction) FROM reactions
+            WHERE target_type = 'comment' AND target_id = comments.id AND user_id = sqlc.narg(user_id)
+        ) AS TEXT) AS user_reactions
+    FROM comments
+    WHERE thread_id = sqlc.arg(thread_id)
+      AND (hidden = 0 OR CAST(sqlc.arg(include_hidden) AS BOOLEAN))
+      AND (sqlc.narg(comment_id) IS NULL OR id = sqlc.narg(comment_id))
+      AND (NOT CAST(sqlc.arg(has_cursor) AS BOOLEAN) OR CASE sqlc.arg(sort)
+           WHEN 'top' THEN score < sqlc.arg(cursor_score)
+               OR (score = sqlc.arg(cursor_score) AND (created_at, id) > (sqlc.arg(cursor_created_at), sqlc.arg(cursor_id)))
+           WHEN 'new' THEN (created_at, id) < (sqlc.arg(cursor_created_at), sqlc.arg(cursor_id))
+           WHEN 'active' THEN (COALESCE(last_activity_at, created_at), created_at, id)
+               < (sqlc.arg(cursor_active_at), sqlc.arg(cursor_created_at), sqlc.arg(cursor_id))
+           ELSE (created_at, id) > (sqlc.arg(cursor_created_at), sqlc.arg(cursor_id))
+           END)
+    ORDER BY
+        CASE WHEN sqlc.arg(sort) = 'top' THEN score END DESC,
+        CASE WHEN sqlc.arg(sort) = 'active' THEN COALESCE(last_activity_at, created_at) END DESC,
+        CASE WHEN sqlc.arg(sort) IN ('new', 'active') THEN created_at END DESC,
+        CASE WHEN sqlc.arg(sort) IN ('new', 'active') THEN id END DESC,
+        created_at ASC, id ASC
+    LIMIT sqlc.arg(limit)
+) c
+LEFT JOIN users u ON u.id = c.author_id
 LEFT JOIN comment_translations ct ON c.id = ct.comment_id
 LEFT JOIN comment_images ci ON c.id = ci.comment_id
+ORDER BY
+    CASE WHEN sqlc.arg(sort) = 'top' THEN c.score END DESC,
+    CASE WHEN sqlc.arg(sort) = 'active' THEN COALESCE(c.last_activity_at, c.created_at) END DESC,
+    CASE WHEN sqlc.arg(sort) IN ('new', 'active') THEN c.created_at END DESC,
+    CASE WHEN sqlc.arg(sort) IN ('new', 'active') THEN c.id END DESC,
+    c.created_at ASC, c.id ASC, ci.position ASC;
+
+-- name: CreateUser :one
+INSERT INTO users (id, username, password_hash, created_at)
+VALUES (?, ?, ?, ?) RETURNING *;
+
+-- name: GetUser :one
+SELECT * FROM users WHERE id = ?;
+
+-- name: GetUserByUsername :one
+SELECT * FROM users WHERE username = ?;
+
+-- name: CreateSession :one
+INSERT INTO sessions (id, user_id, created_at, expires_at)
+VALUES (?, ?, ?, ?) RETURNING *;
+
+-- name: GetSessionUser :one
+SELECT u.*
+FROM sessions s
+JOIN users u ON u.id = s.user_id
+WHERE s.id = ? AND s.expires_at > ?;
+
+-- name: DeleteSession :exec
+DELETE FROM sessions WHERE id = ?;
+
+-- name: DeleteExpiredSessions :exec
+DELETE FROM sessions WHERE expires_at <= ?;
+
+-- name: SetUserRole :execrows
+UPDATE users SET role = ? WHERE id = ?;
+
+-- name: GetComment :one
+SELECT * FROM comments WHERE id = ?;
+
+-- name: SetThreadPinned :execrows
+UPDATE threads SET pinned = ? WHERE id = ?;
+
+-- name: SetThreadLocked :execrows
+UPDATE threads SET locked = ? WHERE id = ?;
+
+-- name: SetThreadHidden :execrows
+UPDATE threads SET hidden = ? WHERE id = ?;
+
+-- name: SetThreadApproved :execrows
+UPDATE threads SET approved = sqlc.arg(approved) WHERE id = sqlc.arg(id) AND approved != sqlc.arg(approved);
+
+-- name: SetCommentHidden :execrows
+UPDATE comments SET hidden = ? WHERE id = ?;
+
+-- name: ListThreadCommentImages :many
+SELECT ci.*
+FROM comment_images ci
+JOIN comments c ON c.id = ci.comment_id
+WHERE c.thread_id = ?;
+
+-- name: ListCommentImages :many
+SELECT * FROM comment_images WHERE comment_id = ? ORDER BY position ASC;
+
+-- name: ListThreadCommentImageVariants :many
+SELECT v.*
+FROM comment_image_variants v
+JOIN comment_images ci ON ci.id = v.image_id
+JOIN comments c ON c.id = ci.comment_id
 WHERE c.thread_id = ?
-ORDER BY c.created_at ASC; 
\ No newline at end of file
+ORDER BY v.width ASC;
+
+-- name: ListCommentImageVariants :many
+SELECT v.*
+FROM comment_image_variants v
+JOIN comment_images ci ON ci.id = v.image_id
+WHERE ci.comment_id = ?
+ORDER BY v.width ASC;
+
+-- name: CreateThreadImage :one
+INSERT INTO thread_images (id, thread_id, filename, filepath, created_at, width, height, position, caption)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: CreateThreadImageVariant :one
+INSERT INTO thread_image_variants (id, image_id, variant, filename, filepath, width, height)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: ListThreadImages :many
+SELECT * FROM thread_images WHERE thread_id = ? ORDER BY position ASC;
+
+-- name: ListThreadImageVariants :many
+SELECT v.*
+FROM thread_image_variants v
+JOIN thread_images ti ON ti.id = v.image_id
+WHERE ti.thread_id = ?
+ORDER BY v.width ASC;
+
+-- name: DeleteThreadImageVariants :exec
+DELETE FROM thread_image_variants
+WHERE image_id IN (SELECT id FROM thread_images WHERE thread_id = ?);
+
+-- name: DeleteThreadImages :exec
+DELETE FROM thread_images WHERE thread_id = ?;
+
+-- name: UpdateThread :execrows
+UPDATE threads SET title = ?, content = ?, language = ?, edited_at = ? WHERE id = ?;
+
+-- name: UpdateComment :execrows
+UPDATE comments SET language = ?, edited_at = ? WHERE id = ?;
+
+-- name: CreateRevision :one
+INSERT INTO revisions (id, target_type, target_id, title, content, language, edited_by, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: ListRevisions :many
+SELECT r.*, u.username AS editor_name
+FROM revisions r
+LEFT JOIN users u ON u.id = r.edited_by
+WHERE r.target_type = ? AND r.target_id = ?
+ORDER BY r.created_at DESC, r.id DESC;
+
+-- name: DeleteRevisions :exec
+DELETE FROM revisions WHERE target_type = ? AND target_id = ?;
+
+-- name: DeleteThreadCommentRevisions :exec
+DELETE FROM revisions
+WHERE target_type = 'comment'
+  AND target_id IN (SELECT id FROM comments WHERE thread_id = ?);
+
+-- name: DeleteThreadTranslations :exec
+DELETE FROM thread_translations WHERE thread_id = ?;
+
+-- name: DeleteThreadCommentTranslations :exec
+DELETE FROM comment_translations
+WHERE comment_id IN (SELECT id FROM comments WHERE thread_id = ?);
+
+-- name: DeleteThreadCommentImageVariants :exec
+DELETE FROM comment_image_variants
+WHERE image_id IN (
+    SELECT ci.id FROM comment_images ci
+    JOIN comments c ON c.id = ci.comment_id
+    WHERE c.thread_id = ?
+);
+
+-- name: DeleteThreadCommentImages :exec
+DELETE FROM comment_images
+WHERE comment_id IN (SELECT id FROM comments WHERE thread_id = ?);
+
+-- name: DeleteThreadComments :exec
+DELETE FROM comments WHERE thread_id = ?;
+
+-- name: DeleteThread :execrows
+DELETE FROM threads WHERE id = ?;
+
+-- name: DeleteCommentTranslations :exec
+DELETE FROM comment_translations WHERE comment_id = ?;
+
+-- name: DeleteCommentImageVariants :exec
+DELETE FROM comment_image_variants
+WHERE image_id IN (SELECT id FROM comment_images WHERE comment_id = ?);
+
+-- name: DeleteCommentImages :exec
+DELETE FROM comment_images WHERE comment_id = ?;
+
+-- name: ReparentCommentReplies :exec
+UPDATE comments SET parent_comment_id = sqlc.arg(new_parent_id)
+WHERE parent_comment_id = sqlc.arg(comment_id);
+
+-- name: DecrementReplyDepths :exec
+WITH RECURSIVE replies(id) AS (
+    SELECT id FROM comments WHERE parent_comment_id = sqlc.arg(comment_id)
+    UNION
+    SELECT c.id FROM comments c JOIN replies r ON c.parent_comment_id = r.id
+)
+UPDATE comments SET depth = depth - 1 WHERE id IN (SELECT id FROM replies);
+
+-- name: DeleteComment :execrows
+DELETE FROM comments WHERE id = ?;
+
+-- name: CreateModerationLog :one
+INSERT INTO moderation_log (id, moderator_id, action, target_type, target_id, reason, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: ListModerationLog :many
+SELECT m.*, u.username AS moderator_name
+FROM moderation_log m
+LEFT JOIN users u ON u.id = m.moderator_id
+ORDER BY m.created_at DESC
+LIMIT ?;
+
+-- name: SearchPosts :many
+SELECT
+    CAST(search_index.kind AS TEXT) AS kind,
+    t.id AS thread_id,
+    c.id AS comment_id,
+    CAST(search_index.language AS TEXT) AS language,
+    t.title,
+    tt.title AS translated_title,
+    t.category,
+    t.created_at,
+    c.created_at AS comment_created_at,
+    CAST(snippet(search_index, -1, char(57344), char(57345), '…', 24) AS TEXT) AS snippet,
+    CAST(bm25(search_index, 0, 0, 0, 0, 0, 10.0, 1.0) AS REAL) AS rank
+FROM search_index
+JOIN threads t ON t.id = search_index.thread_id
+LEFT JOIN comments c ON c.id = search_index.comment_id
+LEFT JOIN thread_translations tt ON tt.thread_id = t.id AND tt.language = sqlc.arg(display_language)
+WHERE search_index MATCH CAST(sqlc.arg(query) AS TEXT)
+  AND (sqlc.narg(category) IS NULL OR t.category = sqlc.narg(category))
+  AND (sqlc.narg(language) IS NULL OR search_index.language = sqlc.narg(language))
+  AND ((t.hidden = 0 AND t.approved = 1 AND COALESCE(c.hidden, 0) = 0) OR CAST(sqlc.arg(include_hidden) AS BOOLEAN))
+ORDER BY rank
+LIMIT sqlc.arg(limit);
+
+-- name: GetThreadTranslation :one
+SELECT * FROM thread_translations WHERE thread_id = ? AND language = ?;
+
+-- name: UpsertThreadTranslation :one
+INSERT INTO thread_translations (id, thread_id, language, title, content)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (thread_id, language) DO UPDATE SET title = excluded.title, content = excluded.content
+RETURNING *;
+
+-- name: GetCommentTranslation :one
+SELECT * FROM comment_translations WHERE comment_id = ? AND language = ?;
+
+-- name: UpsertCommentTranslation :one
+INSERT INTO comment_translations (id, comment_id, language, content)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (comment_id, language) DO UPDATE SET content = excluded.content
+RETURNING *;
+
+-- name: CreateTranslationJob :one
+INSERT INTO translation_jobs (id, target_type, target_id, source_language, target_language, run_at, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: ClaimTranslationJob :one
+UPDATE translation_jobs
+SET status = 'running', attempts = attempts + 1, updated_at = sqlc.arg(now)
+WHERE id = (
+    SELECT id FROM translation_jobs
+    WHERE status = 'pending' AND run_at <= sqlc.arg(now)
+    ORDER BY run_at, id
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: FailTranslationJob :exec
+UPDATE translation_jobs
+SET status = ?, last_error = ?, run_at = ?, updated_at = ?
+WHERE id = ?;
+
+-- name: DeleteTranslationJob :exec
+DELETE FROM translation_jobs WHERE id = ?;
+
+-- name: ResetRunningTranslationJobs :exec
+UPDATE translation_jobs SET status = 'pending', updated_at = ? WHERE status = 'running';
+
+-- name: ListTranslationJobs :many
+SELECT * FROM translation_jobs
+WHERE status = ?
+ORDER BY updated_at DESC
+LIMIT ?;
+
+-- name: RequeueTranslationJob :execrows
+UPDATE translation_jobs
+SET status = 'pending', attempts = 0, last_error = '', run_at = sqlc.arg(now), updated_at = sqlc.arg(now)
+WHERE id = sqlc.arg(id) AND status = 'failed';
+
+-- name: RequeueFailedTranslationJobs :execrows
+UPDATE translation_jobs
+SET status = 'pending', attempts = 0, last_error = '', run_at = sqlc.arg(now), updated_at = sqlc.arg(now)
+WHERE status = 'failed';
+
+-- name: DeleteTranslationJobs :exec
+DELETE FROM translation_jobs WHERE target_type = ? AND target_id = ?;
+
+-- name: DeleteThreadCommentTranslationJobs :exec
+DELETE FROM translation_jobs
+WHERE target_type = 'comment'
+  AND target_id IN (SELECT id FROM comments WHERE thread_id = ?);
+
+-- name: EnqueueMissingThreadTranslations :execrows
+INSERT INTO translation_jobs (id, target_type, target_id, source_language, target_language, run_at, created_at, updated_at)
+SELECT 'fill-thread-' || t.id || '-' || sqlc.arg(target_language),
+       'thread',
+       t.id,
+       t.language,
+       sqlc.arg(target_language),
+       sqlc.arg(now),
+       sqlc.arg(now),
+       sqlc.arg(now)
+FROM threads t
+WHERE t.language != '' AND t.language != sqlc.arg(target_language)
+  AND NOT EXISTS (
+      SELECT 1 FROM thread_translations tt
+      WHERE tt.thread_id = t.id AND tt.language = sqlc.arg(target_language))
+  AND NOT EXISTS (
+      SELECT 1 FROM translation_jobs j
+      WHERE j.target_type = 'thread' AND j.target_id = t.id AND j.target_language = sqlc.arg(target_language));
+
+-- name: EnqueueMissingCommentTranslations :execrows
+INSERT INTO translation_jobs (id, target_type, target_id, source_language, target_language, run_at, created_at, updated_at)
+SELECT 'fill-comment-' || c.id || '-' || sqlc.arg(target_language),
+       'comment',
+       c.id,
+       c.language,
+       sqlc.arg(target_language),
+       sqlc.arg(now),
+       sqlc.arg(now),
+       sqlc.arg(now)
+FROM comments c
+WHERE c.language != '' AND c.language != sqlc.arg(target_language)
+  AND NOT EXISTS (
+      SELECT 1 FROM comment_translations ct
+      WHERE ct.comment_id = c.id AND ct.language = sqlc.arg(target_language))
+  AND NOT EXISTS (
+      SELECT 1 FROM translation_jobs j
+      WHERE j.target_type = 'comment' AND j.target_id = c.id AND j.target_language = sqlc.arg(target_language));
+
+-- name: TouchThreadActivity :exec
+UPDATE threads SET last_activity_at = ? WHERE id = ?;
+
+-- name: TouchCommentActivity :exec
+UPDATE comments SET last_activity_at = ? WHERE id = ?;
+
+-- name: ListCategories :many
+SELECT
+    c.*,
+    (SELECT COUNT(*) FROM threads WHERE category = c.slug AND hidden = 0 AND approved = 1) AS thread_count,
+    t.last_activity_at
+FROM categories c
+LEFT JOIN threads t ON t.id = (
+    SELECT id FROM threads
+    WHERE category = c.slug AND hidden = 0 AND approved = 1
+    ORDER BY last_activity_at DESC, id DESC
+    LIMIT 1
+)
+ORDER BY c.sort_order ASC, c.slug ASC;
+
+-- name: GetCategory :one
+SELECT * FROM categories WHERE slug = ?;
+
+-- name: ListCategoryTranslations :many
+SELECT * FROM category_translations ORDER BY category_slug, language;
+
+-- name: CreateCategory :one
+INSERT INTO categories (slug, sort_order, icon, thread_role, comment_role, requires_approval, solutions_enabled)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: UpdateCategory :execrows
+UPDATE categories
+SET sort_order = ?, icon = ?, thread_role = ?, comment_role = ?, requires_approval = ?, solutions_enabled = ?
+WHERE slug = ?;
+
+-- name: DeleteUnusedCategory :execrows
+DELETE FROM categories
+WHERE slug = ?
+  AND NOT EXISTS (SELECT 1 FROM threads WHERE category = categories.slug);
+
+-- name: CreateCategoryTranslation :one
+INSERT INTO category_translations (category_slug, language, label, description)
+VALUES (?, ?, ?, ?) RETURNING *;
+
+-- name: DeleteCategoryTranslations :exec
+DELETE FROM category_translations WHERE category_slug = ?;
+
+-- name: AddReaction :exec
+INSERT INTO reactions (target_type, target_id, user_id, reaction, created_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (target_type, target_id, user_id, reaction) DO NOTHING;
+
+-- name: DeleteReaction :execrows
+DELETE FROM reactions WHERE target_type = ? AND target_id = ? AND user_id = ? AND reaction = ?;
+
+-- name: DeleteReactions :exec
+DELETE FROM reactions WHERE target_type = ? AND target_id = ?;
+
+-- name: DeleteThreadCommentReactions :exec
+DELETE FROM reactions
+WHERE target_type = 'comment'
+  AND target_id IN (SELECT id FROM comments WHERE thread_id = ?);
+
+-- name: GetReactions :one
+SELECT
+    CAST((SELECT json_group_object(reaction, n) FROM (
+        SELECT reaction, COUNT(*) AS n FROM reactions
+        WHERE target_type = sqlc.arg(target_type) AND target_id = sqlc.arg(target_id)
+        GROUP BY reaction
+    )) AS TEXT) AS reaction_counts,
+    CAST((SELECT json_group_array(reaction) FROM reactions
+        WHERE target_type = sqlc.arg(target_type) AND target_id = sqlc.arg(target_id) AND user_id = sqlc.narg(user_id)
+    ) AS TEXT) AS user_reactions;
+
+-- name: UpdateThreadScore :exec
+UPDATE threads SET score = (
+    SELECT COALESCE(SUM(CASE reaction WHEN 'up' THEN 1 WHEN 'down' THEN -1 ELSE 0 END), 0)
+    FROM reactions WHERE target_type = 'thread' AND target_id = threads.id
+) WHERE id = ?;
+
+-- name: UpdateCommentScore :exec
+UPDATE comments SET score = (
+    SELECT COALESCE(SUM(CASE reaction WHEN 'up' THEN 1 WHEN 'down' THEN -1 ELSE 0 END), 0)
+    FROM reactions WHERE target_type = 'comment' AND target_id = comments.id
+) WHERE id = ?;
+
+-- name: SetAcceptedComment :execrows
+UPDATE threads SET accepted_comment_id = ? WHERE id = ?;
+
+-- name: ClearAcceptedComment :exec
+UPDATE threads SET accepted_comment_id = NULL WHERE accepted_comment_id = ?;
+
+-- name: CreateNotification :execrows
+INSERT OR IGNORE INTO notifications (id, user_id, kind, thread_id, comment_id, actor_id, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListNotifications :many
+SELECT n.*, u.username AS actor_name, t.title AS thread_title, tt.title AS translated_title
+FROM notifications n
+JOIN threads t ON t.id = n.thread_id
+LEFT JOIN users u ON u.id = n.actor_id
+LEFT JOIN thread_translations tt ON tt.thread_id = t.id AND tt.language = sqlc.arg(language)
+WHERE n.user_id = sqlc.arg(user_id)
+  AND ((t.hidden = 0 AND t.approved = 1
+        AND NOT EXISTS (SELECT 1 FROM comments c WHERE c.id = n.comment_id AND c.hidden = 1))
+       OR CAST(sqlc.arg(include_hidden) AS BOOLEAN))
+  AND (NOT CAST(sqlc.arg(unread_only) AS BOOLEAN) OR n.read_at IS NULL)
+  AND (NOT CAST(sqlc.arg(has_cursor) AS BOOLEAN)
+       OR (n.created_at, n.id) < (sqlc.arg(cursor_created_at), sqlc.arg(cursor_id)))
+ORDER BY n.created_at DESC, n.id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) AS count
+FROM notifications n
+JOIN threads t ON t.id = n.thread_id
+WHERE n.user_id = sqlc.arg(user_id) AND n.read_at IS NULL
+  AND ((t.hidden = 0 AND t.approved = 1
+        AND NOT EXISTS (SELECT 1 FROM comments c WHERE c.id = n.comment_id AND c.hidden = 1))
+       OR CAST(sqlc.arg(include_hidden) AS BOOLEAN));
+
+-- name: SetNotificationRead :execrows
+UPDATE notifications SET read_at = ? WHERE id = ? AND user_id = ?;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL;
+
+-- name: DeleteThreadNotifications :exec
+DELETE FROM notifications WHERE thread_id = ?;
+
+-- name: DeleteCommentNotifications :exec
+DELETE FROM notifications WHERE comment_id = ?;
+
+-- name: GetThreadSubscription :one
+SELECT * FROM thread_subscriptions WHERE user_id = ? AND thread_id = ?;
+
+-- name: UpsertThreadSubscription :exec
+INSERT INTO thread_subscriptions (user_id, thread_id, subscribed, created_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (user_id, thread_id) DO UPDATE SET subscribed = excluded.subscribed;
+
+-- name: ListThreadSubscribers :many
+SELECT user_id FROM thread_subscriptions WHERE thread_id = ? AND subscribed = 1;
+
+-- name: DeleteThreadSubscriptions :exec
+DELETE FROM thread_subscriptions WHERE thread_id = ?;
+
+-- name: GetEmailSettings :one
+SELECT * FROM email_settings WHERE user_id = ?;
+
+-- name: GetEmailSettingsByToken :one
+SELECT * FROM email_settings WHERE unsubscribe_token = ?;
+
+-- name: GetEmailSettingsByVerificationToken :one
+SELECT * FROM email_settings WHERE verification_token = ?;
+
+-- name: UpsertEmailSettings :one
+INSERT INTO email_settings (user_id, email, verification_token, verification_sent_at, language, notifications, digest,
+    last_digest_at, unsubscribe_token, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE SET
+    -- A changed address has to be confirmed again
+    verified_at = CASE WHEN email_settings.email = excluded.email
+        THEN email_settings.verified_at ELSE NULL END,
+    verification_token = CASE WHEN email_settings.email = excluded.email
+        THEN email_settings.verification_token ELSE excluded.verification_token END,
+    verification_sent_at = CASE WHEN email_settings.email = excluded.email
+        THEN email_settings.verification_sent_at ELSE excluded.verification_sent_at END,
+    email = excluded.email,
+    language = excluded.language,
+    notifications = excluded.notifications,
+    digest = excluded.digest,
+    -- A digest that was just turned on or changed starts from now
+    last_digest_at = CASE WHEN email_settings.digest = excluded.digest
+        THEN email_settings.last_digest_at ELSE excluded.last_digest_at END,
+    updated_at = excluded.updated_at
+RETURNING *;
+
+-- name: SetEmailVerification :exec
+UPDATE email_settings SET verification_token = ?, verification_sent_at = ?, updated_at = ? WHERE user_id = ?;
+
+-- name: ConfirmEmail :exec
+UPDATE email_settings SET verified_at = ?, verification_token = NULL, updated_at = ? WHERE user_id = ?;
+
+-- name: ClearEmailSettings :exec
+-- The row is kept so that verification_sent_at still throttles confirmation e-mails
+UPDATE email_settings
+SET email = '', verified_at = NULL, verification_token = NULL, notifications = 1, digest = 'off', updated_at = ?
+WHERE user_id = ?;
+
+-- name: SetEmailNotifications :exec
+UPDATE email_settings SET notifications = ?, updated_at = ? WHERE user_id = ?;
+
+-- name: SetEmailDigest :exec
+UPDATE email_settings SET digest = ?, updated_at = ? WHERE user_id = ?;
+
+-- name: ListDueDigests :many
+SELECT * FROM email_settings
+WHERE verified_at IS NOT NULL
+  AND ((digest = 'daily' AND last_digest_at <= sqlc.arg(daily_before))
+    OR (digest = 'weekly' AND last_digest_at <= sqlc.arg(weekly_before)))
+ORDER BY last_digest_at;
+
+-- name: MarkDigestSent :exec
+UPDATE email_settings SET last_digest_at = ? WHERE user_id = ?;
+
+-- name: ListDigestThreads :many
+SELECT t.id, t.title, t.category, t.created_at, u.username AS author_name, tt.title AS translated_title,
+    CAST(COALESCE(ct.label, t.category) AS TEXT) AS category_label
+FROM threads t
+JOIN category_subscriptions cs ON cs.category_slug = t.category AND cs.user_id = sqlc.arg(user_id)
+LEFT JOIN users u ON u.id = t.author_id
+LEFT JOIN thread_translations tt ON tt.thread_id = t.id AND tt.language = sqlc.arg(language)
+LEFT JOIN category_translations ct ON ct.category_slug = t.category AND ct.language = sqlc.arg(language)
+WHERE t.created_at > sqlc.arg(since)
+  AND t.hidden = 0 AND t.approved = 1
+  AND (t.author_id IS NULL OR t.author_id != sqlc.arg(user_id))
+ORDER BY t.category, t.created_at DESC
+LIMIT sqlc.arg(limit);
+
+-- name: ListCategorySubscriptions :many
+SELECT category_slug FROM category_subscriptions WHERE user_id = ? ORDER BY category_slug;
+
+-- name: AddCategorySubscription :exec
+INSERT INTO category_subscriptions (user_id, category_slug, created_at)
+VALUES (?, ?, ?)
+ON CONFLICT (user_id, category_slug) DO NOTHING;
+
+-- name: DeleteCategorySubscriptions :exec
+DELETE FROM category_subscriptions WHERE user_id = ?;
+
+-- name: DeleteCategorySubscribers :exec
+DELETE FROM category_subscriptions WHERE category_slug = ?;
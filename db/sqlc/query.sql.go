@@ -11,27 +11,256 @@ import (
 	"time"
 )
 
+const addCategorySubscription = `-- name: AddCategorySubscription :exec
+INSERT INTO category_subscriptions (user_id, category_slug, created_at)
+VALUES (?, ?, ?)
+ON CONFLICT (user_id, category_slug) DO NOTHING
+`
+
+type AddCategorySubscriptionParams struct {
+	UserID       string    `json:"user_id"`
+	CategorySlug string    `json:"category_slug"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (q *Queries) AddCategorySubscription(ctx context.Context, arg AddCategorySubscriptionParams) error {
+	_, err := q.db.ExecContext(ctx, addCategorySubscription, arg.UserID, arg.CategorySlug, arg.CreatedAt)
+	return err
+}
+
+const addReaction = `-- name: AddReaction :exec
+INSERT INTO reactions (target_type, target_id, user_id, reaction, created_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (target_type, target_id, user_id, reaction) DO NOTHING
+`
+
+type AddReactionParams struct {
+	TargetType string    `json:"target_type"`
+	TargetID   string    `json:"target_id"`
+	UserID     string    `json:"user_id"`
+	Reaction   string    `json:"reaction"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (q *Queries) AddReaction(ctx context.Context, arg AddReactionParams) error {
+	_, err := q.db.ExecContext(ctx, addReaction,
+		arg.TargetType,
+		arg.TargetID,
+		arg.UserID,
+		arg.Reaction,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const claimTranslationJob = `-- name: ClaimTranslationJob :one
+UPDATE translation_jobs
+SET status = 'running', attempts = attempts + 1, updated_at = ?1
+WHERE id = (
+    SELECT id FROM translation_jobs
+    WHERE status = 'pending' AND run_at <= ?1
+    ORDER BY run_at, id
+    LIMIT 1
+)
+RETURNING id, target_type, target_id, source_language, target_language, status, attempts, last_error, run_at, created_at, updated_at
+`
+
+func (q *Queries) ClaimTranslationJob(ctx context.Context, now time.Time) (TranslationJob, error) {
+	row := q.db.QueryRowContext(ctx, claimTranslationJob, now)
+	var i TranslationJob
+	err := row.Scan(
+		&i.ID,
+		&i.TargetType,
+		&i.TargetID,
+		&i.SourceLanguage,
+		&i.TargetLanguage,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.RunAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const clearAcceptedComment = `-- name: ClearAcceptedComment :exec
+UPDATE threads SET accepted_comment_id = NULL WHERE accepted_comment_id = ?
+`
+
+func (q *Queries) ClearAcceptedComment(ctx context.Context, acceptedCommentID sql.NullString) error {
+	_, err := q.db.ExecContext(ctx, clearAcceptedComment, acceptedCommentID)
+	return err
+}
+
+const clearEmailSettings = `-- name: ClearEmailSettings :exec
+-- The row is kept so that verification_sent_at still throttles confirmation e-mails
+UPDATE email_settings
+SET email = '', verified_at = NULL, verification_token = NULL, notifications = 1, digest = 'off', updated_at = ?
+WHERE user_id = ?
+`
+
+type ClearEmailSettingsParams struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    string    `json:"user_id"`
+}
+
+func (q *Queries) ClearEmailSettings(ctx context.Context, arg ClearEmailSettingsParams) error {
+	_, err := q.db.ExecContext(ctx, clearEmailSettings, arg.UpdatedAt, arg.UserID)
+	return err
+}
+
+const confirmEmail = `-- name: ConfirmEmail :exec
+UPDATE email_settings SET verified_at = ?, verification_token = NULL, updated_at = ? WHERE user_id = ?
+`
+
+type ConfirmEmailParams struct {
+	VerifiedAt sql.NullTime `json:"verified_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+	UserID     string       `json:"user_id"`
+}
+
+func (q *Queries) ConfirmEmail(ctx context.Context, arg ConfirmEmailParams) error {
+	_, err := q.db.ExecContext(ctx, confirmEmail, arg.VerifiedAt, arg.UpdatedAt, arg.UserID)
+	return err
+}
+
+const countUnreadNotifications = `-- name: CountUnreadNotifications :one
+SELECT COUNT(*) AS count
+FROM notifications n
+JOIN threads t ON t.id = n.thread_id
+WHERE n.user_id = ?1 AND n.read_at IS NULL
+  AND ((t.hidden = 0 AND t.approved = 1
+        AND NOT EXISTS (SELECT 1 FROM comments c WHERE c.id = n.comment_id AND c.hidden = 1))
+       OR CAST(?2 AS BOOLEAN))
+`
+
+type CountUnreadNotificationsParams struct {
+	UserID        string `json:"user_id"`
+	IncludeHidden bool   `json:"include_hidden"`
+}
+
+func (q *Queries) CountUnreadNotifications(ctx context.Context, arg CountUnreadNotificationsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnreadNotifications, arg.UserID, arg.IncludeHidden)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createCategory = `-- name: CreateCategory :one
+INSERT INTO categories (slug, sort_order, icon, thread_role, comment_role, requires_approval, solutions_enabled)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING slug, sort_order, icon, thread_role, comment_role, requires_approval, solutions_enabled
+`
+
+type CreateCategoryParams struct {
+	Slug             string `json:"slug"`
+	SortOrder        int64  `json:"sort_order"`
+	Icon             string `json:"icon"`
+	ThreadRole       string `json:"thread_role"`
+	CommentRole      string `json:"comment_role"`
+	RequiresApproval bool   `json:"requires_approval"`
+	SolutionsEnabled bool   `json:"solutions_enabled"`
+}
+
+func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error) {
+	row := q.db.QueryRowContext(ctx, createCategory,
+		arg.Slug,
+		arg.SortOrder,
+		arg.Icon,
+		arg.ThreadRole,
+		arg.CommentRole,
+		arg.RequiresApproval,
+		arg.SolutionsEnabled,
+	)
+	var i Category
+	err := row.Scan(
+		&i.Slug,
+		&i.SortOrder,
+		&i.Icon,
+		&i.ThreadRole,
+		&i.CommentRole,
+		&i.RequiresApproval,
+		&i.SolutionsEnabled,
+	)
+	return i, err
+}
+
+const createCategoryTranslation = `-- name: CreateCategoryTranslation :one
+INSERT INTO category_translations (category_slug, language, label, description)
+VALUES (?, ?, ?, ?) RETURNING category_slug, language, label, description
+`
+
+type CreateCategoryTranslationParams struct {
+	CategorySlug string `json:"category_slug"`
+	Language     string `json:"language"`
+	Label        string `json:"label"`
+	Description  string `json:"description"`
+}
+
+func (q *Queries) CreateCategoryTranslation(ctx context.Context, arg CreateCategoryTranslationParams) (CategoryTranslation, error) {
+	row := q.db.QueryRowContext(ctx, createCategoryTranslation,
+		arg.CategorySlug,
+		arg.Language,
+		arg.Label,
+		arg.Description,
+	)
+	var i CategoryTranslation
+	err := row.Scan(
+		&i.CategorySlug,
+		&i.Language,
+		&i.Label,
+		&i.Description,
+	)
+	return i, err
+}
+
 const createComment = `-- name: CreateComment :one
-INSERT INTO comments (id, thread_id, created_at)
-VALUES (?, ?, ?) RETURNING id, thread_id, created_at
+INSERT INTO comments (id, thread_id, created_at, author_id, language, parent_comment_id, depth, last_activity_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, thread_id, created_at, author_id, hidden, language, edited_at, parent_comment_id, depth, score, last_activity_at
 `
 
 type CreateCommentParams struct {
-	ID        string    `json:"id"`
-	ThreadID  string    `json:"thread_id"`
-	CreatedAt time.Time `json:"created_at"`
+	ID              string         `json:"id"`
+	ThreadID        string         `json:"thread_id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	AuthorID        sql.NullString `json:"author_id"`
+	Language        string         `json:"language"`
+	ParentCommentID sql.NullString `json:"parent_comment_id"`
+	Depth           int64          `json:"depth"`
+	LastActivityAt  sql.NullTime   `json:"last_activity_at"`
 }
 
 func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (Comment, error) {
-	row := q.db.QueryRowContext(ctx, createComment, arg.ID, arg.ThreadID, arg.CreatedAt)
+	row := q.db.QueryRowContext(ctx, createComment,
+		arg.ID,
+		arg.ThreadID,
+		arg.CreatedAt,
+		arg.AuthorID,
+		arg.Language,
+		arg.ParentCommentID,
+		arg.Depth,
+		arg.LastActivityAt,
+	)
 	var i Comment
-	err := row.Scan(&i.ID, &i.ThreadID, &i.CreatedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.ThreadID,
+		&i.CreatedAt,
+		&i.AuthorID,
+		&i.Hidden,
+		&i.Language,
+		&i.EditedAt,
+		&i.ParentCommentID,
+		&i.Depth,
+		&i.Score,
+		&i.LastActivityAt,
+	)
 	return i, err
 }
 
 const createCommentImage = `-- name: CreateCommentImage :one
-INSERT INTO comment_images (id, comment_id, filename, filepath, created_at)
-VALUES (?, ?, ?, ?, ?) RETURNING id, comment_id, filename, filepath, created_at
+INSERT INTO comment_images (id, comment_id, filename, filepath, created_at, width, height, position, caption)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, comment_id, filename, filepath, created_at, width, height, position, caption
 `
 
 type CreateCommentImageParams struct {
@@ -40,6 +269,10 @@ type CreateCommentImageParams struct {
 	Filename  string    `json:"filename"`
 	Filepath  string    `json:"filepath"`
 	CreatedAt time.Time `json:"created_at"`
+	Width     int64     `json:"width"`
+	Height    int64     `json:"height"`
+	Position  int64     `json:"position"`
+	Caption   string    `json:"caption"`
 }
 
 func (q *Queries) CreateCommentImage(ctx context.Context, arg CreateCommentImageParams) (CommentImage, error) {
@@ -49,6 +282,10 @@ func (q *Queries) CreateCommentImage(ctx context.Context, arg CreateCommentImage
 		arg.Filename,
 		arg.Filepath,
 		arg.CreatedAt,
+		arg.Width,
+		arg.Height,
+		arg.Position,
+		arg.Caption,
 	)
 	var i CommentImage
 	err := row.Scan(
@@ -57,20 +294,64 @@ func (q *Queries) CreateCommentImage(ctx context.Context, arg CreateCommentImage
 		&i.Filename,
 		&i.Filepath,
 		&i.CreatedAt,
+		&i.Width,
+		&i.Height,
+		&i.Position,
+		&i.Caption,
+	)
+	return i, err
+}
+
+const createCommentImageVariant = `-- name: CreateCommentImageVariant :one
+INSERT INTO comment_image_variants (id, image_id, variant, filename, filepath, width, height)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id, image_id, variant, filename, filepath, width, height
+`
+
+type CreateCommentImageVariantParams struct {
+	ID       string `json:"id"`
+	ImageID  string `json:"image_id"`
+	Variant  string `json:"variant"`
+	Filename string `json:"filename"`
+	Filepath string `json:"filepath"`
+	Width    int64  `json:"width"`
+	Height   int64  `json:"height"`
+}
+
+func (q *Queries) CreateCommentImageVariant(ctx context.Context, arg CreateCommentImageVariantParams) (CommentImageVariant, error) {
+	row := q.db.QueryRowContext(ctx, createCommentImageVariant,
+		arg.ID,
+		arg.ImageID,
+		arg.Variant,
+		arg.Filename,
+		arg.Filepath,
+		arg.Width,
+		arg.Height,
+	)
+	var i CommentImageVariant
+	err := row.Scan(
+		&i.ID,
+		&i.ImageID,
+		&i.Variant,
+		&i.Filename,
+		&i.Filepath,
+		&i.Width,
+		&i.Height,
 	)
 	return i, err
 }
 
 const createCommentTranslation = `-- name: CreateCommentTranslation :one
-INSERT INTO comment_translations (id, comment_id, language, content)
-VALUES (?, ?, ?, ?) RETURNING id, comment_id, language, content
+INSERT INTO comment_translations (id, comment_id, language, content, detected_language, detection_confidence)
+VALUES (?, ?, ?, ?, ?, ?) RETURNING id, comment_id, language, content, detected_language, detection_confidence
 `
 
 type CreateCommentTranslationParams struct {
-	ID        string `json:"id"`
-	CommentID string `json:"comment_id"`
-	Language  string `json:"language"`
-	Content   string `json:"content"`
+	ID                  string  `json:"id"`
+	CommentID           string  `json:"comment_id"`
+	Language            string  `json:"language"`
+	Content             string  `json:"content"`
+	DetectedLanguage    string  `json:"detected_language"`
+	DetectionConfidence float64 `json:"detection_confidence"`
 }
 
 func (q *Queries) CreateCommentTranslation(ctx context.Context, arg CreateCommentTranslationParams) (CommentTranslation, error) {
@@ -79,6 +360,8 @@ func (q *Queries) CreateCommentTranslation(ctx context.Context, arg CreateCommen
 		arg.CommentID,
 		arg.Language,
 		arg.Content,
+		arg.DetectedLanguage,
+		arg.DetectionConfidence,
 	)
 	var i CommentTranslation
 	err := row.Scan(
@@ -86,21 +369,166 @@ func (q *Queries) CreateCommentTranslation(ctx context.Context, arg CreateCommen
 		&i.CommentID,
 		&i.Language,
 		&i.Content,
+		&i.DetectedLanguage,
+		&i.DetectionConfidence,
 	)
 	return i, err
 }
 
-const createThread = `-- name: CreateThread :one
-INSERT INTO threads (id, title, content, category, created_at)
-VALUES (?, ?, ?, ?, ?) RETURNING id, title, content, category, created_at
+const createModerationLog = `-- name: CreateModerationLog :one
+INSERT INTO moderation_log (id, moderator_id, action, target_type, target_id, reason, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id, moderator_id, action, target_type, target_id, reason, created_at
 `
 
-type CreateThreadParams struct {
+type CreateModerationLogParams struct {
+	ID          string    `json:"id"`
+	ModeratorID string    `json:"moderator_id"`
+	Action      string    `json:"action"`
+	TargetType  string    `json:"target_type"`
+	TargetID    string    `json:"target_id"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (q *Queries) CreateModerationLog(ctx context.Context, arg CreateModerationLogParams) (ModerationLog, error) {
+	row := q.db.QueryRowContext(ctx, createModerationLog,
+		arg.ID,
+		arg.ModeratorID,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Reason,
+		arg.CreatedAt,
+	)
+	var i ModerationLog
+	err := row.Scan(
+		&i.ID,
+		&i.ModeratorID,
+		&i.Action,
+		&i.TargetType,
+		&i.TargetID,
+		&i.Reason,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createNotification = `-- name: CreateNotification :execrows
+INSERT OR IGNORE INTO notifications (id, user_id, kind, thread_id, comment_id, actor_id, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateNotificationParams struct {
+	ID        string         `json:"id"`
+	UserID    string         `json:"user_id"`
+	Kind      string         `json:"kind"`
+	ThreadID  string         `json:"thread_id"`
+	CommentID sql.NullString `json:"comment_id"`
+	ActorID   sql.NullString `json:"actor_id"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createNotification,
+		arg.ID,
+		arg.UserID,
+		arg.Kind,
+		arg.ThreadID,
+		arg.CommentID,
+		arg.ActorID,
+		arg.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createRevision = `-- name: CreateRevision :one
+INSERT INTO revisions (id, target_type, target_id, title, content, language, edited_by, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, target_type, target_id, title, content, language, edited_by, created_at
+`
+
+type CreateRevisionParams struct {
+	ID         string         `json:"id"`
+	TargetType string         `json:"target_type"`
+	TargetID   string         `json:"target_id"`
+	Title      string         `json:"title"`
+	Content    string         `json:"content"`
+	Language   string         `json:"language"`
+	EditedBy   sql.NullString `json:"edited_by"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+func (q *Queries) CreateRevision(ctx context.Context, arg CreateRevisionParams) (Revision, error) {
+	row := q.db.QueryRowContext(ctx, createRevision,
+		arg.ID,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Title,
+		arg.Content,
+		arg.Language,
+		arg.EditedBy,
+		arg.CreatedAt,
+	)
+	var i Revision
+	err := row.Scan(
+		&i.ID,
+		&i.TargetType,
+		&i.TargetID,
+		&i.Title,
+		&i.Content,
+		&i.Language,
+		&i.EditedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createSession = `-- name: CreateSession :one
+INSERT INTO sessions (id, user_id, created_at, expires_at)
+VALUES (?, ?, ?, ?) RETURNING id, user_id, created_at, expires_at
+`
+
+type CreateSessionParams struct {
 	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Category  string    `json:"category"`
+	UserID    string    `json:"user_id"`
 	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, createSession,
+		arg.ID,
+		arg.UserID,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const createThread = `-- name: CreateThread :one
+INSERT INTO threads (id, title, content, category, created_at, author_id, language, last_activity_at, approved)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, title, content, category, created_at, author_id, pinned, locked, hidden, language, edited_at, last_activity_at, approved, score, accepted_comment_id
+`
+
+type CreateThreadParams struct {
+	ID             string         `json:"id"`
+	Title          string         `json:"title"`
+	Content        string         `json:"content"`
+	Category       string         `json:"category"`
+	CreatedAt      time.Time      `json:"created_at"`
+	AuthorID       sql.NullString `json:"author_id"`
+	Language       string         `json:"language"`
+	LastActivityAt sql.NullTime   `json:"last_activity_at"`
+	Approved       bool           `json:"approved"`
 }
 
 func (q *Queries) CreateThread(ctx context.Context, arg CreateThreadParams) (Thread, error) {
@@ -110,6 +538,10 @@ func (q *Queries) CreateThread(ctx context.Context, arg CreateThreadParams) (Thr
 		arg.Content,
 		arg.Category,
 		arg.CreatedAt,
+		arg.AuthorID,
+		arg.Language,
+		arg.LastActivityAt,
+		arg.Approved,
 	)
 	var i Thread
 	err := row.Scan(
@@ -118,108 +550,1986 @@ func (q *Queries) CreateThread(ctx context.Context, arg CreateThreadParams) (Thr
 		&i.Content,
 		&i.Category,
 		&i.CreatedAt,
+		&i.AuthorID,
+		&i.Pinned,
+		&i.Locked,
+		&i.Hidden,
+		&i.Language,
+		&i.EditedAt,
+		&i.LastActivityAt,
+		&i.Approved,
+		&i.Score,
+		&i.AcceptedCommentID,
 	)
 	return i, err
 }
 
-const getThread = `-- name: GetThread :one
-SELECT id, title, content, category, created_at FROM threads WHERE id = ?
+const createThreadImage = `-- name: CreateThreadImage :one
+INSERT INTO thread_images (id, thread_id, filename, filepath, created_at, width, height, position, caption)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, thread_id, filename, filepath, created_at, width, height, position, caption
 `
 
-func (q *Queries) GetThread(ctx context.Context, id string) (Thread, error) {
-	row := q.db.QueryRowContext(ctx, getThread, id)
-	var i Thread
+type CreateThreadImageParams struct {
+	ID        string    `json:"id"`
+	ThreadID  string    `json:"thread_id"`
+	Filename  string    `json:"filename"`
+	Filepath  string    `json:"filepath"`
+	CreatedAt time.Time `json:"created_at"`
+	Width     int64     `json:"width"`
+	Height    int64     `json:"height"`
+	Position  int64     `json:"position"`
+	Caption   string    `json:"caption"`
+}
+
+func (q *Queries) CreateThreadImage(ctx context.Context, arg CreateThreadImageParams) (ThreadImage, error) {
+	row := q.db.QueryRowContext(ctx, createThreadImage,
+		arg.ID,
+		arg.ThreadID,
+		arg.Filename,
+		arg.Filepath,
+		arg.CreatedAt,
+		arg.Width,
+		arg.Height,
+		arg.Position,
+		arg.Caption,
+	)
+	var i ThreadImage
 	err := row.Scan(
 		&i.ID,
-		&i.Title,
-		&i.Content,
-		&i.Category,
+		&i.ThreadID,
+		&i.Filename,
+		&i.Filepath,
 		&i.CreatedAt,
+		&i.Width,
+		&i.Height,
+		&i.Position,
+		&i.Caption,
 	)
 	return i, err
 }
 
-const getThreadComments = `-- name: GetThreadComments :many
-SELECT 
-    c.id,
-    c.thread_id,
-    c.created_at,
-    ct.content,
-    ct.language,
-    ci.id as image_id,
-    ci.filename,
-    ci.filepath
-FROM comments c
-LEFT JOIN comment_translations ct ON c.id = ct.comment_id
-LEFT JOIN comment_images ci ON c.id = ci.comment_id
-WHERE c.thread_id = ?
-ORDER BY c.created_at ASC
+const createThreadImageVariant = `-- name: CreateThreadImageVariant :one
+INSERT INTO thread_image_variants (id, image_id, variant, filename, filepath, width, height)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id, image_id, variant, filename, filepath, width, height
 `
 
-type GetThreadCommentsRow struct {
-	ID        string         `json:"id"`
-	ThreadID  string         `json:"thread_id"`
-	CreatedAt time.Time      `json:"created_at"`
-	Content   sql.NullString `json:"content"`
-	Language  sql.NullString `json:"language"`
-	ImageID   sql.NullString `json:"image_id"`
-	Filename  sql.NullString `json:"filename"`
-	Filepath  sql.NullString `json:"filepath"`
+type CreateThreadImageVariantParams struct {
+	ID       string `json:"id"`
+	ImageID  string `json:"image_id"`
+	Variant  string `json:"variant"`
+	Filename string `json:"filename"`
+	Filepath string `json:"filepath"`
+	Width    int64  `json:"width"`
+	Height   int64  `json:"height"`
 }
 
-func (q *Queries) GetThreadComments(ctx context.Context, threadID string) ([]GetThreadCommentsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getThreadComments, threadID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []GetThreadCommentsRow{}
-	for rows.Next() {
-		var i GetThreadCommentsRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.ThreadID,
-			&i.CreatedAt,
-			&i.Content,
-			&i.Language,
-			&i.ImageID,
-			&i.Filename,
-			&i.Filepath,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) CreateThreadImageVariant(ctx context.Context, arg CreateThreadImageVariantParams) (ThreadImageVariant, error) {
+	row := q.db.QueryRowContext(ctx, createThreadImageVariant,
+		arg.ID,
+		arg.ImageID,
+		arg.Variant,
+		arg.Filename,
+		arg.Filepath,
+		arg.Width,
+		arg.Height,
+	)
+	var i ThreadImageVariant
+	err := row.Scan(
+		&i.ID,
+		&i.ImageID,
+		&i.Variant,
+		&i.Filename,
+		&i.Filepath,
+		&i.Width,
+		&i.Height,
+	)
+	return i, err
 }
 
-const listAllThreads = `-- name: ListAllThreads :many
-SELECT id, title, content, category, created_at FROM threads ORDER BY created_at DESC
+const createThreadTranslation = `-- name: CreateThreadTranslation :one
+INSERT INTO thread_translations (id, thread_id, language, title, content, detected_language, detection_confidence)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id, thread_id, language, title, content, detected_language, detection_confidence
 `
 
-func (q *Queries) ListAllThreads(ctx context.Context) ([]Thread, error) {
-	rows, err := q.db.QueryContext(ctx, listAllThreads)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []Thread{}
-	for rows.Next() {
-		var i Thread
-		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.Content,
-			&i.Category,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
+type CreateThreadTranslationParams struct {
+	ID                  string  `json:"id"`
+	ThreadID            string  `json:"thread_id"`
+	Language            string  `json:"language"`
+	Title               string  `json:"title"`
+	Content             string  `json:"content"`
+	DetectedLanguage    string  `json:"detected_language"`
+	DetectionConfidence float64 `json:"detection_confidence"`
+}
+
+func (q *Queries) CreateThreadTranslation(ctx context.Context, arg CreateThreadTranslationParams) (ThreadTranslation, error) {
+	row := q.db.QueryRowContext(ctx, createThreadTranslation,
+		arg.ID,
+		arg.ThreadID,
+		arg.Language,
+		arg.Title,
+		arg.Content,
+		arg.DetectedLanguage,
+		arg.DetectionConfidence,
+	)
+	var i ThreadTranslation
+	err := row.Scan(
+		&i.ID,
+		&i.ThreadID,
+		&i.Language,
+		&i.Title,
+		&i.Content,
+		&i.DetectedLanguage,
+		&i.DetectionConfidence,
+	)
+	return i, err
+}
+
+const createTranslationJob = `-- name: CreateTranslationJob :one
+INSERT INTO translation_jobs (id, target_type, target_id, source_language, target_language, run_at, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, target_type, target_id, source_language, target_language, status, attempts, last_error, run_at, created_at, updated_at
+`
+
+type CreateTranslationJobParams struct {
+	ID             string    `json:"id"`
+	TargetType     string    `json:"target_type"`
+	TargetID       string    `json:"target_id"`
+	SourceLanguage string    `json:"source_language"`
+	TargetLanguage string    `json:"target_language"`
+	RunAt          time.Time `json:"run_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (q *Queries) CreateTranslationJob(ctx context.Context, arg CreateTranslationJobParams) (TranslationJob, error) {
+	row := q.db.QueryRowContext(ctx, createTranslationJob,
+		arg.ID,
+		arg.TargetType,
+		arg.TargetID,
+		arg.SourceLanguage,
+		arg.TargetLanguage,
+		arg.RunAt,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	var i TranslationJob
+	err := row.Scan(
+		&i.ID,
+		&i.TargetType,
+		&i.TargetID,
+		&i.SourceLanguage,
+		&i.TargetLanguage,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.RunAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createUser = `-- name: CreateUser :one
+INSERT INTO users (id, username, password_hash, created_at)
+VALUES (?, ?, ?, ?) RETURNING id, username, password_hash, created_at, role
+`
+
+type CreateUserParams struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createUser,
+		arg.ID,
+		arg.Username,
+		arg.PasswordHash,
+		arg.CreatedAt,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const decrementReplyDepths = `-- name: DecrementReplyDepths :exec
+WITH RECURSIVE replies(id) AS (
+    SELECT id FROM comments WHERE parent_comment_id = ?1
+    UNION
+    SELECT c.id FROM comments c JOIN replies r ON c.parent_comment_id = r.id
+)
+UPDATE comments SET depth = depth - 1 WHERE id IN (SELECT id FROM replies)
+`
+
+func (q *Queries) DecrementReplyDepths(ctx context.Context, commentID string) error {
+	_, err := q.db.ExecContext(ctx, decrementReplyDepths, commentID)
+	return err
+}
+
+const deleteCategorySubscribers = `-- name: DeleteCategorySubscribers :exec
+DELETE FROM category_subscriptions WHERE category_slug = ?
+`
+
+func (q *Queries) DeleteCategorySubscribers(ctx context.Context, categorySlug string) error {
+	_, err := q.db.ExecContext(ctx, deleteCategorySubscribers, categorySlug)
+	return err
+}
+
+const deleteCategorySubscriptions = `-- name: DeleteCategorySubscriptions :exec
+DELETE FROM category_subscriptions WHERE user_id = ?
+`
+
+func (q *Queries) DeleteCategorySubscriptions(ctx context.Context, userID string) error {
+	_, err := q.db.ExecContext(ctx, deleteCategorySubscriptions, userID)
+	return err
+}
+
+const deleteCategoryTranslations = `-- name: DeleteCategoryTranslations :exec
+DELETE FROM category_translations WHERE category_slug = ?
+`
+
+func (q *Queries) DeleteCategoryTranslations(ctx context.Context, categorySlug string) error {
+	_, err := q.db.ExecContext(ctx, deleteCategoryTranslations, categorySlug)
+	return err
+}
+
+const deleteComment = `-- name: DeleteComment :execrows
+DELETE FROM comments WHERE id = ?
+`
+
+func (q *Queries) DeleteComment(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteComment, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteCommentImageVariants = `-- name: DeleteCommentImageVariants :exec
+DELETE FROM comment_image_variants
+WHERE image_id IN (SELECT id FROM comment_images WHERE comment_id = ?)
+`
+
+func (q *Queries) DeleteCommentImageVariants(ctx context.Context, commentID string) error {
+	_, err := q.db.ExecContext(ctx, deleteCommentImageVariants, commentID)
+	return err
+}
+
+const deleteCommentImages = `-- name: DeleteCommentImages :exec
+DELETE FROM comment_images WHERE comment_id = ?
+`
+
+func (q *Queries) DeleteCommentImages(ctx context.Context, commentID string) error {
+	_, err := q.db.ExecContext(ctx, deleteCommentImages, commentID)
+	return err
+}
+
+const deleteCommentNotifications = `-- name: DeleteCommentNotifications :exec
+DELETE FROM notifications WHERE comment_id = ?
+`
+
+func (q *Queries) DeleteCommentNotifications(ctx context.Context, commentID sql.NullString) error {
+	_, err := q.db.ExecContext(ctx, deleteCommentNotifications, commentID)
+	return err
+}
+
+const deleteCommentTranslations = `-- name: DeleteCommentTranslations :exec
+DELETE FROM comment_translations WHERE comment_id = ?
+`
+
+func (q *Queries) DeleteCommentTranslations(ctx context.Context, commentID string) error {
+	_, err := q.db.ExecContext(ctx, deleteCommentTranslations, commentID)
+	return err
+}
+
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :exec
+DELETE FROM sessions WHERE expires_at <= ?
+`
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context, expiresAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredSessions, expiresAt)
+	return err
+}
+
+const deleteReaction = `-- name: DeleteReaction :execrows
+DELETE FROM reactions WHERE target_type = ? AND target_id = ? AND user_id = ? AND reaction = ?
+`
+
+type DeleteReactionParams struct {
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	UserID     string `json:"user_id"`
+	Reaction   string `json:"reaction"`
+}
+
+func (q *Queries) DeleteReaction(ctx context.Context, arg DeleteReactionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteReaction,
+		arg.TargetType,
+		arg.TargetID,
+		arg.UserID,
+		arg.Reaction,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteReactions = `-- name: DeleteReactions :exec
+DELETE FROM reactions WHERE target_type = ? AND target_id = ?
+`
+
+type DeleteReactionsParams struct {
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+}
+
+func (q *Queries) DeleteReactions(ctx context.Context, arg DeleteReactionsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteReactions, arg.TargetType, arg.TargetID)
+	return err
+}
+
+const deleteRevisions = `-- name: DeleteRevisions :exec
+DELETE FROM revisions WHERE target_type = ? AND target_id = ?
+`
+
+type DeleteRevisionsParams struct {
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+}
+
+func (q *Queries) DeleteRevisions(ctx context.Context, arg DeleteRevisionsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteRevisions, arg.TargetType, arg.TargetID)
+	return err
+}
+
+const deleteSession = `-- name: DeleteSession :exec
+DELETE FROM sessions WHERE id = ?
+`
+
+func (q *Queries) DeleteSession(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteSession, id)
+	return err
+}
+
+const deleteThread = `-- name: DeleteThread :execrows
+DELETE FROM threads WHERE id = ?
+`
+
+func (q *Queries) DeleteThread(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteThread, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteThreadCommentImageVariants = `-- name: DeleteThreadCommentImageVariants :exec
+DELETE FROM comment_image_variants
+WHERE image_id IN (
+    SELECT ci.id FROM comment_images ci
+    JOIN comments c ON c.id = ci.comment_id
+    WHERE c.thread_id = ?
+)
+`
+
+func (q *Queries) DeleteThreadCommentImageVariants(ctx context.Context, threadID string) error {
+	_, err := q.db.ExecContext(ctx, deleteThreadCommentImageVariants, threadID)
+	return err
+}
+
+const deleteThreadCommentImages = `-- name: DeleteThreadCommentImages :exec
+DELETE FROM comment_images
+WHERE comment_id IN (SELECT id FROM comments WHERE thread_id = ?)
+`
+
+func (q *Queries) DeleteThreadCommentImages(ctx context.Context, threadID string) error {
+	_, err := q.db.ExecContext(ctx, deleteThreadCommentImages, threadID)
+	return err
+}
+
+const deleteThreadCommentReactions = `-- name: DeleteThreadCommentReactions :exec
+DELETE FROM reactions
+WHERE target_type = 'comment'
+  AND target_id IN (SELECT id FROM comments WHERE thread_id = ?)
+`
+
+func (q *Queries) DeleteThreadCommentReactions(ctx context.Context, threadID string) error {
+	_, err := q.db.ExecContext(ctx, deleteThreadCommentReactions, threadID)
+	return err
+}
+
+const deleteThreadCommentRevisions = `-- name: DeleteThreadCommentRevisions :exec
+DELETE FROM revisions
+WHERE target_type = 'comment'
+  AND target_id IN (SELECT id FROM comments WHERE thread_id = ?)
+`
+
+func (q *Queries) DeleteThreadCommentRevisions(ctx context.Context, threadID string) error {
+	_, err := q.db.ExecContext(ctx, deleteThreadCommentRevisions, threadID)
+	return err
+}
+
+const deleteThreadCommentTranslationJobs = `-- name: DeleteThreadCommentTranslationJobs :exec
+DELETE FROM translation_jobs
+WHERE target_type = 'comment'
+  AND target_id IN (SELECT id FROM comments WHERE thread_id = ?)
+`
+
+func (q *Queries) DeleteThreadCommentTranslationJobs(ctx context.Context, threadID string) error {
+	_, err := q.db.ExecContext(ctx, deleteThreadCommentTranslationJobs, threadID)
+	return err
+}
+
+const deleteThreadCommentTranslations = `-- name: DeleteThreadCommentTranslations :exec
+DELETE FROM comment_translations
+WHERE comment_id IN (SELECT id FROM comments WHERE thread_id = ?)
+`
+
+func (q *Queries) DeleteThreadCommentTranslations(ctx context.Context, threadID string) error {
+	_, err := q.db.ExecContext(ctx, deleteThreadCommentTranslations, threadID)
+	return err
+}
+
+const deleteThreadComments = `-- name: DeleteThreadComments :exec
+DELETE FROM comments WHERE thread_id = ?
+`
+
+func (q *Queries) DeleteThreadComments(ctx context.Context, threadID string) error {
+	_, err := q.db.ExecContext(ctx, deleteThreadComments, threadID)
+	return err
+}
+
+const deleteThreadImageVariants = `-- name: DeleteThreadImageVariants :exec
+DELETE FROM thread_image_variants
+WHERE image_id IN (SELECT id FROM thread_images WHERE thread_id = ?)
+`
+
+func (q *Queries) DeleteThreadImageVariants(ctx context.Context, threadID string) error {
+	_, err := q.db.ExecContext(ctx, deleteThreadImageVariants, threadID)
+	return err
+}
+
+const deleteThreadImages = `-- name: DeleteThreadImages :exec
+DELETE FROM thread_images WHERE thread_id = ?
+`
+
+func (q *Queries) DeleteThreadImages(ctx context.Context, threadID string) error {
+	_, err := q.db.ExecContext(ctx, deleteThreadImages, threadID)
+	return err
+}
+
+const deleteThreadNotifications = `-- name: DeleteThreadNotifications :exec
+DELETE FROM notifications WHERE thread_id = ?
+`
+
+func (q *Queries) DeleteThreadNotifications(ctx context.Context, threadID string) error {
+	_, err := q.db.ExecContext(ctx, deleteThreadNotifications, threadID)
+	return err
+}
+
+const deleteThreadSubscriptions = `-- name: DeleteThreadSubscriptions :exec
+DELETE FROM thread_subscriptions WHERE thread_id = ?
+`
+
+func (q *Queries) DeleteThreadSubscriptions(ctx context.Context, threadID string) error {
+	_, err := q.db.ExecContext(ctx, deleteThreadSubscriptions, threadID)
+	return err
+}
+
+const deleteThreadTranslations = `-- name: DeleteThreadTranslations :exec
+DELETE FROM thread_translations WHERE thread_id = ?
+`
+
+func (q *Queries) DeleteThreadTranslations(ctx context.Context, threadID string) error {
+	_, err := q.db.ExecContext(ctx, deleteThreadTranslations, threadID)
+	return err
+}
+
+const deleteTranslationJob = `-- name: DeleteTranslationJob :exec
+DELETE FROM translation_jobs WHERE id = ?
+`
+
+func (q *Queries) DeleteTranslationJob(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteTranslationJob, id)
+	return err
+}
+
+const deleteTranslationJobs = `-- name: DeleteTranslationJobs :exec
+DELETE FROM translation_jobs WHERE target_type = ? AND target_id = ?
+`
+
+type DeleteTranslationJobsParams struct {
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+}
+
+func (q *Queries) DeleteTranslationJobs(ctx context.Context, arg DeleteTranslationJobsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteTranslationJobs, arg.TargetType, arg.TargetID)
+	return err
+}
+
+const deleteUnusedCategory = `-- name: DeleteUnusedCategory :execrows
+DELETE FROM categories
+WHERE slug = ?
+  AND NOT EXISTS (SELECT 1 FROM threads WHERE category = categories.slug)
+`
+
+func (q *Queries) DeleteUnusedCategory(ctx context.Context, slug string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUnusedCategory, slug)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const enqueueMissingCommentTranslations = `-- name: EnqueueMissingCommentTranslations :execrows
+INSERT INTO translation_jobs (id, target_type, target_id, source_language, target_language, run_at, created_at, updated_at)
+SELECT 'fill-comment-' || c.id || '-' || ?1,
+       'comment',
+       c.id,
+       c.language,
+       ?1,
+       ?2,
+       ?2,
+       ?2
+FROM comments c
+WHERE c.language != '' AND c.language != ?1
+  AND NOT EXISTS (
+      SELECT 1 FROM comment_translations ct
+      WHERE ct.comment_id = c.id AND ct.language = ?1)
+  AND NOT EXISTS (
+      SELECT 1 FROM translation_jobs j
+      WHERE j.target_type = 'comment' AND j.target_id = c.id AND j.target_language = ?1)
+`
+
+type EnqueueMissingCommentTranslationsParams struct {
+	TargetLanguage string    `json:"target_language"`
+	Now            time.Time `json:"now"`
+}
+
+func (q *Queries) EnqueueMissingCommentTranslations(ctx context.Context, arg EnqueueMissingCommentTranslationsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enqueueMissingCommentTranslations, arg.TargetLanguage, arg.Now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const enqueueMissingThreadTranslations = `-- name: EnqueueMissingThreadTranslations :execrows
+INSERT INTO translation_jobs (id, target_type, target_id, source_language, target_language, run_at, created_at, updated_at)
+SELECT 'fill-thread-' || t.id || '-' || ?1,
+       'thread',
+       t.id,
+       t.language,
+       ?1,
+       ?2,
+       ?2,
+       ?2
+FROM threads t
+WHERE t.language != '' AND t.language != ?1
+  AND NOT EXISTS (
+      SELECT 1 FROM thread_translations tt
+      WHERE tt.thread_id = t.id AND tt.language = ?1)
+  AND NOT EXISTS (
+      SELECT 1 FROM translation_jobs j
+      WHERE j.target_type = 'thread' AND j.target_id = t.id AND j.target_language = ?1)
+`
+
+type EnqueueMissingThreadTranslationsParams struct {
+	TargetLanguage string    `json:"target_language"`
+	Now            time.Time `json:"now"`
+}
+
+func (q *Queries) EnqueueMissingThreadTranslations(ctx context.Context, arg EnqueueMissingThreadTranslationsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enqueueMissingThreadTranslations, arg.TargetLanguage, arg.Now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const failTranslationJob = `-- name: FailTranslationJob :exec
+UPDATE translation_jobs
+SET status = ?, last_error = ?, run_at = ?, updated_at = ?
+WHERE id = ?
+`
+
+type FailTranslationJobParams struct {
+	Status    string    `json:"status"`
+	LastError string    `json:"last_error"`
+	RunAt     time.Time `json:"run_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ID        string    `json:"id"`
+}
+
+func (q *Queries) FailTranslationJob(ctx context.Context, arg FailTranslationJobParams) error {
+	_, err := q.db.ExecContext(ctx, failTranslationJob,
+		arg.Status,
+		arg.LastError,
+		arg.RunAt,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const getCategory = `-- name: GetCategory :one
+SELECT slug, sort_order, icon, thread_role, comment_role, requires_approval, solutions_enabled FROM categories WHERE slug = ?
+`
+
+func (q *Queries) GetCategory(ctx context.Context, slug string) (Category, error) {
+	row := q.db.QueryRowContext(ctx, getCategory, slug)
+	var i Category
+	err := row.Scan(
+		&i.Slug,
+		&i.SortOrder,
+		&i.Icon,
+		&i.ThreadRole,
+		&i.CommentRole,
+		&i.RequiresApproval,
+		&i.SolutionsEnabled,
+	)
+	return i, err
+}
+
+const getComment = `-- name: GetComment :one
+SELECT id, thread_id, created_at, author_id, hidden, language, edited_at, parent_comment_id, depth, score, last_activity_at FROM comments WHERE id = ?
+`
+
+func (q *Queries) GetComment(ctx context.Context, id string) (Comment, error) {
+	row := q.db.QueryRowContext(ctx, getComment, id)
+	var i Comment
+	err := row.Scan(
+		&i.ID,
+		&i.ThreadID,
+		&i.CreatedAt,
+		&i.AuthorID,
+		&i.Hidden,
+		&i.Language,
+		&i.EditedAt,
+		&i.ParentCommentID,
+		&i.Depth,
+		&i.Score,
+		&i.LastActivityAt,
+	)
+	return i, err
+}
+
+const getCommentTranslation = `-- name: GetCommentTranslation :one
+SELECT id, comment_id, language, content, detected_language, detection_confidence FROM comment_translations WHERE comment_id = ? AND language = ?
+`
+
+type GetCommentTranslationParams struct {
+	CommentID string `json:"comment_id"`
+	Language  string `json:"language"`
+}
+
+func (q *Queries) GetCommentTranslation(ctx context.Context, arg GetCommentTranslationParams) (CommentTranslation, error) {
+	row := q.db.QueryRowContext(ctx, getCommentTranslation, arg.CommentID, arg.Language)
+	var i CommentTranslation
+	err := row.Scan(
+		&i.ID,
+		&i.CommentID,
+		&i.Language,
+		&i.Content,
+		&i.DetectedLanguage,
+		&i.DetectionConfidence,
+	)
+	return i, err
+}
+
+const getEmailSettings = `-- name: GetEmailSettings :one
+SELECT user_id, email, verified_at, verification_token, verification_sent_at, language, notifications, digest, last_digest_at, unsubscribe_token, updated_at FROM email_settings WHERE user_id = ?
+`
+
+func (q *Queries) GetEmailSettings(ctx context.Context, userID string) (EmailSetting, error) {
+	row := q.db.QueryRowContext(ctx, getEmailSettings, userID)
+	var i EmailSetting
+	err := row.Scan(
+		&i.UserID,
+		&i.Email,
+		&i.VerifiedAt,
+		&i.VerificationToken,
+		&i.VerificationSentAt,
+		&i.Language,
+		&i.Notifications,
+		&i.Digest,
+		&i.LastDigestAt,
+		&i.UnsubscribeToken,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getEmailSettingsByToken = `-- name: GetEmailSettingsByToken :one
+SELECT user_id, email, verified_at, verification_token, verification_sent_at, language, notifications, digest, last_digest_at, unsubscribe_token, updated_at FROM email_settings WHERE unsubscribe_token = ?
+`
+
+func (q *Queries) GetEmailSettingsByToken(ctx context.Context, unsubscribeToken string) (EmailSetting, error) {
+	row := q.db.QueryRowContext(ctx, getEmailSettingsByToken, unsubscribeToken)
+	var i EmailSetting
+	err := row.Scan(
+		&i.UserID,
+		&i.Email,
+		&i.VerifiedAt,
+		&i.VerificationToken,
+		&i.VerificationSentAt,
+		&i.Language,
+		&i.Notifications,
+		&i.Digest,
+		&i.LastDigestAt,
+		&i.UnsubscribeToken,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getEmailSettingsByVerificationToken = `-- name: GetEmailSettingsByVerificationToken :one
+SELECT user_id, email, verified_at, verification_token, verification_sent_at, language, notifications, digest, last_digest_at, unsubscribe_token, updated_at FROM email_settings WHERE verification_token = ?
+`
+
+func (q *Queries) GetEmailSettingsByVerificationToken(ctx context.Context, verificationToken sql.NullString) (EmailSetting, error) {
+	row := q.db.QueryRowContext(ctx, getEmailSettingsByVerificationToken, verificationToken)
+	var i EmailSetting
+	err := row.Scan(
+		&i.UserID,
+		&i.Email,
+		&i.VerifiedAt,
+		&i.VerificationToken,
+		&i.VerificationSentAt,
+		&i.Language,
+		&i.Notifications,
+		&i.Digest,
+		&i.LastDigestAt,
+		&i.UnsubscribeToken,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getReactions = `-- name: GetReactions :one
+SELECT
+    CAST((SELECT json_group_object(reaction, n) FROM (
+        SELECT reaction, COUNT(*) AS n FROM reactions
+        WHERE target_type = ?1 AND target_id = ?2
+        GROUP BY reaction
+    )) AS TEXT) AS reaction_counts,
+    CAST((SELECT json_group_array(reaction) FROM reactions
+        WHERE target_type = ?1 AND target_id = ?2 AND user_id = ?3
+    ) AS TEXT) AS user_reactions
+`
+
+type GetReactionsParams struct {
+	TargetType string         `json:"target_type"`
+	TargetID   string         `json:"target_id"`
+	UserID     sql.NullString `json:"user_id"`
+}
+
+type GetReactionsRow struct {
+	ReactionCounts string `json:"reaction_counts"`
+	UserReactions  string `json:"user_reactions"`
+}
+
+func (q *Queries) GetReactions(ctx context.Context, arg GetReactionsParams) (GetReactionsRow, error) {
+	row := q.db.QueryRowContext(ctx, getReactions, arg.TargetType, arg.TargetID, arg.UserID)
+	var i GetReactionsRow
+	err := row.Scan(&i.ReactionCounts, &i.UserReactions)
+	return i, err
+}
+
+const getSessionUser = `-- name: GetSessionUser :one
+SELECT u.id, u.username, u.password_hash, u.created_at, u.role
+FROM sessions s
+JOIN users u ON u.id = s.user_id
+WHERE s.id = ? AND s.expires_at > ?
+`
+
+type GetSessionUserParams struct {
+	ID        string    `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) GetSessionUser(ctx context.Context, arg GetSessionUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getSessionUser, arg.ID, arg.ExpiresAt)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getThread = `-- name: GetThread :one
+SELECT t.id, t.title, t.content, t.category, t.created_at, t.author_id, t.pinned, t.locked, t.hidden, t.language, t.edited_at, t.last_activity_at, t.approved, t.score, t.accepted_comment_id, u.username AS author_name, tt.title AS translated_title, tt.content AS translated_content
+FROM threads t
+LEFT JOIN users u ON u.id = t.author_id
+LEFT JOIN thread_translations tt ON tt.thread_id = t.id AND tt.language = ?1
+WHERE t.id = ?2
+`
+
+type GetThreadParams struct {
+	Language string `json:"language"`
+	ID       string `json:"id"`
+}
+
+type GetThreadRow struct {
+	ID                string         `json:"id"`
+	Title             string         `json:"title"`
+	Content           string         `json:"content"`
+	Category          string         `json:"category"`
+	CreatedAt         time.Time      `json:"created_at"`
+	AuthorID          sql.NullString `json:"author_id"`
+	Pinned            bool           `json:"pinned"`
+	Locked            bool           `json:"locked"`
+	Hidden            bool           `json:"hidden"`
+	Language          string         `json:"language"`
+	EditedAt          sql.NullTime   `json:"edited_at"`
+	LastActivityAt    sql.NullTime   `json:"last_activity_at"`
+	Approved          bool           `json:"approved"`
+	Score             int64          `json:"score"`
+	AcceptedCommentID sql.NullString `json:"accepted_comment_id"`
+	AuthorName        sql.NullString `json:"author_name"`
+	TranslatedTitle   sql.NullString `json:"translated_title"`
+	TranslatedContent sql.NullString `json:"translated_content"`
+}
+
+func (q *Queries) GetThread(ctx context.Context, arg GetThreadParams) (GetThreadRow, error) {
+	row := q.db.QueryRowContext(ctx, getThread, arg.Language, arg.ID)
+	var i GetThreadRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Content,
+		&i.Category,
+		&i.CreatedAt,
+		&i.AuthorID,
+		&i.Pinned,
+		&i.Locked,
+		&i.Hidden,
+		&i.Language,
+		&i.EditedAt,
+		&i.LastActivityAt,
+		&i.Approved,
+		&i.Score,
+		&i.AcceptedCommentID,
+		&i.AuthorName,
+		&i.TranslatedTitle,
+		&i.TranslatedContent,
+	)
+	return i, err
+}
+
+const getThreadComments = `-- name: GetThreadComments :many
+SELECT
+    c.id,
+    c.thread_id,
+    c.created_at,
+    c.author_id,
+    c.hidden,
+    c.edited_at,
+    c.parent_comment_id,
+    c.depth,
+    c.score,
+    c.last_activity_at,
+    c.language AS original_language,
+    c.accepted,
+    c.reaction_counts,
+    c.user_reactions,
+    u.username as author_name,
+    ct.content,
+    ct.language,
+    ci.id as image_id,
+    ci.filename,
+    ci.filepath,
+    ci.width AS image_width,
+    ci.height AS image_height,
+    ci.position AS image_position,
+    ci.caption AS image_caption
+FROM (
+    SELECT comments.id, comments.thread_id, comments.created_at, comments.author_id, comments.hidden, comments.language, comments.edited_at, comments.parent_comment_id, comments.depth, comments.score, comments.last_activity_at,
+        EXISTS (SELECT 1 FROM threads WHERE threads.accepted_comment_id = comments.id) AS accepted,
+        CAST((SELECT json_group_object(reaction, n) FROM (
+            SELECT reaction, COUNT(*) AS n FROM reactions
+            WHERE target_type = 'comment' AND target_id = comments.id
+            GROUP BY reaction
+        )) AS TEXT) AS reaction_counts,
+        CAST((SELECT json_group_array(reaction) FROM reactions
+            WHERE target_type = 'comment' AND target_id = comments.id AND user_id = ?1
+        ) AS TEXT) AS user_reactions
+    FROM comments
+    WHERE thread_id = ?2
+      AND (hidden = 0 OR CAST(?3 AS BOOLEAN))
+      AND (?4 IS NULL OR id = ?4)
+      AND (NOT CAST(?5 AS BOOLEAN) OR CASE ?6
+           WHEN 'top' THEN score < ?7
+               OR (score = ?7 AND (created_at, id) > (?8, ?9))
+           WHEN 'new' THEN (created_at, id) < (?8, ?9)
+           WHEN 'active' THEN (COALESCE(last_activity_at, created_at), created_at, id)
+               < (?10, ?8, ?9)
+           ELSE (created_at, id) > (?8, ?9)
+           END)
+    ORDER BY
+        CASE WHEN ?6 = 'top' THEN score END DESC,
+        CASE WHEN ?6 = 'active' THEN COALESCE(last_activity_at, created_at) END DESC,
+        CASE WHEN ?6 IN ('new', 'active') THEN created_at END DESC,
+        CASE WHEN ?6 IN ('new', 'active') THEN id END DESC,
+        created_at ASC, id ASC
+    LIMIT ?11
+) c
+LEFT JOIN users u ON u.id = c.author_id
+LEFT JOIN comment_translations ct ON c.id = ct.comment_id
+LEFT JOIN comment_images ci ON c.id = ci.comment_id
+ORDER BY
+    CASE WHEN ?6 = 'top' THEN c.score END DESC,
+    CASE WHEN ?6 = 'active' THEN COALESCE(c.last_activity_at, c.created_at) END DESC,
+    CASE WHEN ?6 IN ('new', 'active') THEN c.created_at END DESC,
+    CASE WHEN ?6 IN ('new', 'active') THEN c.id END DESC,
+    c.created_at ASC, c.id ASC, ci.position ASC
+`
+
+type GetThreadCommentsParams struct {
+	UserID          sql.NullString `json:"user_id"`
+	ThreadID        string         `json:"thread_id"`
+	IncludeHidden   bool           `json:"include_hidden"`
+	CommentID       sql.NullString `json:"comment_id"`
+	HasCursor       bool           `json:"has_cursor"`
+	Sort            string         `json:"sort"`
+	CursorScore     int64          `json:"cursor_score"`
+	CursorCreatedAt time.Time      `json:"cursor_created_at"`
+	CursorID        string         `json:"cursor_id"`
+	CursorActiveAt  time.Time      `json:"cursor_active_at"`
+	Limit           int64          `json:"limit"`
+}
+
+type GetThreadCommentsRow struct {
+	ID               string         `json:"id"`
+	ThreadID         string         `json:"thread_id"`
+	CreatedAt        time.Time      `json:"created_at"`
+	AuthorID         sql.NullString `json:"author_id"`
+	Hidden           bool           `json:"hidden"`
+	EditedAt         sql.NullTime   `json:"edited_at"`
+	ParentCommentID  sql.NullString `json:"parent_comment_id"`
+	Depth            int64          `json:"depth"`
+	Score            int64          `json:"score"`
+	LastActivityAt   sql.NullTime   `json:"last_activity_at"`
+	OriginalLanguage string         `json:"original_language"`
+	Accepted         bool           `json:"accepted"`
+	ReactionCounts   string         `json:"reaction_counts"`
+	UserReactions    string         `json:"user_reactions"`
+	AuthorName       sql.NullString `json:"author_name"`
+	Content          sql.NullString `json:"content"`
+	Language         sql.NullString `json:"language"`
+	ImageID          sql.NullString `json:"image_id"`
+	Filename         sql.NullString `json:"filename"`
+	Filepath         sql.NullString `json:"filepath"`
+	ImageWidth       sql.NullInt64  `json:"image_width"`
+	ImageHeight      sql.NullInt64  `json:"image_height"`
+	ImagePosition    sql.NullInt64  `json:"image_position"`
+	ImageCaption     sql.NullString `json:"image_caption"`
+}
+
+func (q *Queries) GetThreadComments(ctx context.Context, arg GetThreadCommentsParams) ([]GetThreadCommentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getThreadComments,
+		arg.UserID,
+		arg.ThreadID,
+		arg.IncludeHidden,
+		arg.CommentID,
+		arg.HasCursor,
+		arg.Sort,
+		arg.CursorScore,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.CursorActiveAt,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetThreadCommentsRow{}
+	for rows.Next() {
+		var i GetThreadCommentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ThreadID,
+			&i.CreatedAt,
+			&i.AuthorID,
+			&i.Hidden,
+			&i.EditedAt,
+			&i.ParentCommentID,
+			&i.Depth,
+			&i.Score,
+			&i.LastActivityAt,
+			&i.OriginalLanguage,
+			&i.Accepted,
+			&i.ReactionCounts,
+			&i.UserReactions,
+			&i.AuthorName,
+			&i.Content,
+			&i.Language,
+			&i.ImageID,
+			&i.Filename,
+			&i.Filepath,
+			&i.ImageWidth,
+			&i.ImageHeight,
+			&i.ImagePosition,
+			&i.ImageCaption,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getThreadSubscription = `-- name: GetThreadSubscription :one
+SELECT user_id, thread_id, subscribed, created_at FROM thread_subscriptions WHERE user_id = ? AND thread_id = ?
+`
+
+type GetThreadSubscriptionParams struct {
+	UserID   string `json:"user_id"`
+	ThreadID string `json:"thread_id"`
+}
+
+func (q *Queries) GetThreadSubscription(ctx context.Context, arg GetThreadSubscriptionParams) (ThreadSubscription, error) {
+	row := q.db.QueryRowContext(ctx, getThreadSubscription, arg.UserID, arg.ThreadID)
+	var i ThreadSubscription
+	err := row.Scan(
+		&i.UserID,
+		&i.ThreadID,
+		&i.Subscribed,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getThreadTranslation = `-- name: GetThreadTranslation :one
+SELECT id, thread_id, language, title, content, detected_language, detection_confidence FROM thread_translations WHERE thread_id = ? AND language = ?
+`
+
+type GetThreadTranslationParams struct {
+	ThreadID string `json:"thread_id"`
+	Language string `json:"language"`
+}
+
+func (q *Queries) GetThreadTranslation(ctx context.Context, arg GetThreadTranslationParams) (ThreadTranslation, error) {
+	row := q.db.QueryRowContext(ctx, getThreadTranslation, arg.ThreadID, arg.Language)
+	var i ThreadTranslation
+	err := row.Scan(
+		&i.ID,
+		&i.ThreadID,
+		&i.Language,
+		&i.Title,
+		&i.Content,
+		&i.DetectedLanguage,
+		&i.DetectionConfidence,
+	)
+	return i, err
+}
+
+const getUser = `-- name: GetUser :one
+SELECT id, username, password_hash, created_at, role FROM users WHERE id = ?
+`
+
+func (q *Queries) GetUser(ctx context.Context, id string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getUserByUsername = `-- name: GetUserByUsername :one
+SELECT id, username, password_hash, created_at, role FROM users WHERE username = ?
+`
+
+func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByUsername, username)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const listCategories = `-- name: ListCategories :many
+SELECT
+    c.slug, c.sort_order, c.icon, c.thread_role, c.comment_role, c.requires_approval, c.solutions_enabled,
+    (SELECT COUNT(*) FROM threads WHERE category = c.slug AND hidden = 0 AND approved = 1) AS thread_count,
+    t.last_activity_at
+FROM categories c
+LEFT JOIN threads t ON t.id = (
+    SELECT id FROM threads
+    WHERE category = c.slug AND hidden = 0 AND approved = 1
+    ORDER BY last_activity_at DESC, id DESC
+    LIMIT 1
+)
+ORDER BY c.sort_order ASC, c.slug ASC
+`
+
+type ListCategoriesRow struct {
+	Slug             string       `json:"slug"`
+	SortOrder        int64        `json:"sort_order"`
+	Icon             string       `json:"icon"`
+	ThreadRole       string       `json:"thread_role"`
+	CommentRole      string       `json:"comment_role"`
+	RequiresApproval bool         `json:"requires_approval"`
+	SolutionsEnabled bool         `json:"solutions_enabled"`
+	ThreadCount      int64        `json:"thread_count"`
+	LastActivityAt   sql.NullTime `json:"last_activity_at"`
+}
+
+func (q *Queries) ListCategories(ctx context.Context) ([]ListCategoriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListCategoriesRow{}
+	for rows.Next() {
+		var i ListCategoriesRow
+		if err := rows.Scan(
+			&i.Slug,
+			&i.SortOrder,
+			&i.Icon,
+			&i.ThreadRole,
+			&i.CommentRole,
+			&i.RequiresApproval,
+			&i.SolutionsEnabled,
+			&i.ThreadCount,
+			&i.LastActivityAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategorySubscriptions = `-- name: ListCategorySubscriptions :many
+SELECT category_slug FROM category_subscriptions WHERE user_id = ? ORDER BY category_slug
+`
+
+func (q *Queries) ListCategorySubscriptions(ctx context.Context, userID string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listCategorySubscriptions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var categorySlug string
+		if err := rows.Scan(&categorySlug); err != nil {
+			return nil, err
+		}
+		items = append(items, categorySlug)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategoryTranslations = `-- name: ListCategoryTranslations :many
+SELECT category_slug, language, label, description FROM category_translations ORDER BY category_slug, language
+`
+
+func (q *Queries) ListCategoryTranslations(ctx context.Context) ([]CategoryTranslation, error) {
+	rows, err := q.db.QueryContext(ctx, listCategoryTranslations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CategoryTranslation{}
+	for rows.Next() {
+		var i CategoryTranslation
+		if err := rows.Scan(
+			&i.CategorySlug,
+			&i.Language,
+			&i.Label,
+			&i.Description,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCommentImageVariants = `-- name: ListCommentImageVariants :many
+SELECT v.id, v.image_id, v.variant, v.filename, v.filepath, v.width, v.height
+FROM comment_image_variants v
+JOIN comment_images ci ON ci.id = v.image_id
+WHERE ci.comment_id = ?
+ORDER BY v.width ASC
+`
+
+func (q *Queries) ListCommentImageVariants(ctx context.Context, commentID string) ([]CommentImageVariant, error) {
+	rows, err := q.db.QueryContext(ctx, listCommentImageVariants, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CommentImageVariant{}
+	for rows.Next() {
+		var i CommentImageVariant
+		if err := rows.Scan(
+			&i.ID,
+			&i.ImageID,
+			&i.Variant,
+			&i.Filename,
+			&i.Filepath,
+			&i.Width,
+			&i.Height,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCommentImages = `-- name: ListCommentImages :many
+SELECT id, comment_id, filename, filepath, created_at, width, height, position, caption FROM comment_images WHERE comment_id = ? ORDER BY position ASC
+`
+
+func (q *Queries) ListCommentImages(ctx context.Context, commentID string) ([]CommentImage, error) {
+	rows, err := q.db.QueryContext(ctx, listCommentImages, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CommentImage{}
+	for rows.Next() {
+		var i CommentImage
+		if err := rows.Scan(
+			&i.ID,
+			&i.CommentID,
+			&i.Filename,
+			&i.Filepath,
+			&i.CreatedAt,
+			&i.Width,
+			&i.Height,
+			&i.Position,
+			&i.Caption,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDigestThreads = `-- name: ListDigestThreads :many
+SELECT t.id, t.title, t.category, t.created_at, u.username AS author_name, tt.title AS translated_title,
+    CAST(COALESCE(ct.label, t.category) AS TEXT) AS category_label
+FROM threads t
+JOIN category_subscriptions cs ON cs.category_slug = t.category AND cs.user_id = ?1
+LEFT JOIN users u ON u.id = t.author_id
+LEFT JOIN thread_translations tt ON tt.thread_id = t.id AND tt.language = ?2
+LEFT JOIN category_translations ct ON ct.category_slug = t.category AND ct.language = ?2
+WHERE t.created_at > ?3
+  AND t.hidden = 0 AND t.approved = 1
+  AND (t.author_id IS NULL OR t.author_id != ?1)
+ORDER BY t.category, t.created_at DESC
+LIMIT ?4
+`
+
+type ListDigestThreadsParams struct {
+	UserID   string    `json:"user_id"`
+	Language string    `json:"language"`
+	Since    time.Time `json:"since"`
+	Limit    int64     `json:"limit"`
+}
+
+type ListDigestThreadsRow struct {
+	ID              string         `json:"id"`
+	Title           string         `json:"title"`
+	Category        string         `json:"category"`
+	CreatedAt       time.Time      `json:"created_at"`
+	AuthorName      sql.NullString `json:"author_name"`
+	TranslatedTitle sql.NullString `json:"translated_title"`
+	CategoryLabel   string         `json:"category_label"`
+}
+
+func (q *Queries) ListDigestThreads(ctx context.Context, arg ListDigestThreadsParams) ([]ListDigestThreadsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDigestThreads,
+		arg.UserID,
+		arg.Language,
+		arg.Since,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListDigestThreadsRow{}
+	for rows.Next() {
+		var i ListDigestThreadsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Category,
+			&i.CreatedAt,
+			&i.AuthorName,
+			&i.TranslatedTitle,
+			&i.CategoryLabel,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueDigests = `-- name: ListDueDigests :many
+SELECT user_id, email, verified_at, verification_token, verification_sent_at, language, notifications, digest, last_digest_at, unsubscribe_token, updated_at FROM email_settings
+WHERE verified_at IS NOT NULL
+  AND ((digest = 'daily' AND last_digest_at <= ?1)
+    OR (digest = 'weekly' AND last_digest_at <= ?2))
+ORDER BY last_digest_at
+`
+
+type ListDueDigestsParams struct {
+	DailyBefore  time.Time `json:"daily_before"`
+	WeeklyBefore time.Time `json:"weekly_before"`
+}
+
+func (q *Queries) ListDueDigests(ctx context.Context, arg ListDueDigestsParams) ([]EmailSetting, error) {
+	rows, err := q.db.QueryContext(ctx, listDueDigests, arg.DailyBefore, arg.WeeklyBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EmailSetting{}
+	for rows.Next() {
+		var i EmailSetting
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Email,
+			&i.VerifiedAt,
+			&i.VerificationToken,
+			&i.VerificationSentAt,
+			&i.Language,
+			&i.Notifications,
+			&i.Digest,
+			&i.LastDigestAt,
+			&i.UnsubscribeToken,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listModerationLog = `-- name: ListModerationLog :many
+SELECT m.id, m.moderator_id, m.action, m.target_type, m.target_id, m.reason, m.created_at, u.username AS moderator_name
+FROM moderation_log m
+LEFT JOIN users u ON u.id = m.moderator_id
+ORDER BY m.created_at DESC
+LIMIT ?
+`
+
+type ListModerationLogRow struct {
+	ID            string         `json:"id"`
+	ModeratorID   string         `json:"moderator_id"`
+	Action        string         `json:"action"`
+	TargetType    string         `json:"target_type"`
+	TargetID      string         `json:"target_id"`
+	Reason        string         `json:"reason"`
+	CreatedAt     time.Time      `json:"created_at"`
+	ModeratorName sql.NullString `json:"moderator_name"`
+}
+
+func (q *Queries) ListModerationLog(ctx context.Context, limit int64) ([]ListModerationLogRow, error) {
+	rows, err := q.db.QueryContext(ctx, listModerationLog, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListModerationLogRow{}
+	for rows.Next() {
+		var i ListModerationLogRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ModeratorID,
+			&i.Action,
+			&i.TargetType,
+			&i.TargetID,
+			&i.Reason,
+			&i.CreatedAt,
+			&i.ModeratorName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotifications = `-- name: ListNotifications :many
+SELECT n.id, n.user_id, n.kind, n.thread_id, n.comment_id, n.actor_id, n.created_at, n.read_at, u.username AS actor_name, t.title AS thread_title, tt.title AS translated_title
+FROM notifications n
+JOIN threads t ON t.id = n.thread_id
+LEFT JOIN users u ON u.id = n.actor_id
+LEFT JOIN thread_translations tt ON tt.thread_id = t.id AND tt.language = ?1
+WHERE n.user_id = ?2
+  AND ((t.hidden = 0 AND t.approved = 1
+        AND NOT EXISTS (SELECT 1 FROM comments c WHERE c.id = n.comment_id AND c.hidden = 1))
+       OR CAST(?3 AS BOOLEAN))
+  AND (NOT CAST(?4 AS BOOLEAN) OR n.read_at IS NULL)
+  AND (NOT CAST(?5 AS BOOLEAN)
+       OR (n.created_at, n.id) < (?6, ?7))
+ORDER BY n.created_at DESC, n.id DESC
+LIMIT ?8
+`
+
+type ListNotificationsParams struct {
+	Language        string    `json:"language"`
+	UserID          string    `json:"user_id"`
+	IncludeHidden   bool      `json:"include_hidden"`
+	UnreadOnly      bool      `json:"unread_only"`
+	HasCursor       bool      `json:"has_cursor"`
+	CursorCreatedAt time.Time `json:"cursor_created_at"`
+	CursorID        string    `json:"cursor_id"`
+	Limit           int64     `json:"limit"`
+}
+
+type ListNotificationsRow struct {
+	ID              string         `json:"id"`
+	UserID          string         `json:"user_id"`
+	Kind            string         `json:"kind"`
+	ThreadID        string         `json:"thread_id"`
+	CommentID       sql.NullString `json:"comment_id"`
+	ActorID         sql.NullString `json:"actor_id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	ReadAt          sql.NullTime   `json:"read_at"`
+	ActorName       sql.NullString `json:"actor_name"`
+	ThreadTitle     string         `json:"thread_title"`
+	TranslatedTitle sql.NullString `json:"translated_title"`
+}
+
+func (q *Queries) ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]ListNotificationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listNotifications,
+		arg.Language,
+		arg.UserID,
+		arg.IncludeHidden,
+		arg.UnreadOnly,
+		arg.HasCursor,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListNotificationsRow{}
+	for rows.Next() {
+		var i ListNotificationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Kind,
+			&i.ThreadID,
+			&i.CommentID,
+			&i.ActorID,
+			&i.CreatedAt,
+			&i.ReadAt,
+			&i.ActorName,
+			&i.ThreadTitle,
+			&i.TranslatedTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRevisions = `-- name: ListRevisions :many
+SELECT r.id, r.target_type, r.target_id, r.title, r.content, r.language, r.edited_by, r.created_at, u.username AS editor_name
+FROM revisions r
+LEFT JOIN users u ON u.id = r.edited_by
+WHERE r.target_type = ? AND r.target_id = ?
+ORDER BY r.created_at DESC, r.id DESC
+`
+
+type ListRevisionsParams struct {
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+}
+
+type ListRevisionsRow struct {
+	ID         string         `json:"id"`
+	TargetType string         `json:"target_type"`
+	TargetID   string         `json:"target_id"`
+	Title      string         `json:"title"`
+	Content    string         `json:"content"`
+	Language   string         `json:"language"`
+	EditedBy   sql.NullString `json:"edited_by"`
+	CreatedAt  time.Time      `json:"created_at"`
+	EditorName sql.NullString `json:"editor_name"`
+}
+
+func (q *Queries) ListRevisions(ctx context.Context, arg ListRevisionsParams) ([]ListRevisionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRevisions, arg.TargetType, arg.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListRevisionsRow{}
+	for rows.Next() {
+		var i ListRevisionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TargetType,
+			&i.TargetID,
+			&i.Title,
+			&i.Content,
+			&i.Language,
+			&i.EditedBy,
+			&i.CreatedAt,
+			&i.EditorName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadCommentImageVariants = `-- name: ListThreadCommentImageVariants :many
+SELECT v.id, v.image_id, v.variant, v.filename, v.filepath, v.width, v.height
+FROM comment_image_variants v
+JOIN comment_images ci ON ci.id = v.image_id
+JOIN comments c ON c.id = ci.comment_id
+WHERE c.thread_id = ?
+ORDER BY v.width ASC
+`
+
+func (q *Queries) ListThreadCommentImageVariants(ctx context.Context, threadID string) ([]CommentImageVariant, error) {
+	rows, err := q.db.QueryContext(ctx, listThreadCommentImageVariants, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CommentImageVariant{}
+	for rows.Next() {
+		var i CommentImageVariant
+		if err := rows.Scan(
+			&i.ID,
+			&i.ImageID,
+			&i.Variant,
+			&i.Filename,
+			&i.Filepath,
+			&i.Width,
+			&i.Height,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadCommentImages = `-- name: ListThreadCommentImages :many
+SELECT ci.id, ci.comment_id, ci.filename, ci.filepath, ci.created_at, ci.width, ci.height, ci.position, ci.caption
+FROM comment_images ci
+JOIN comments c ON c.id = ci.comment_id
+WHERE c.thread_id = ?
+`
+
+func (q *Queries) ListThreadCommentImages(ctx context.Context, threadID string) ([]CommentImage, error) {
+	rows, err := q.db.QueryContext(ctx, listThreadCommentImages, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CommentImage{}
+	for rows.Next() {
+		var i CommentImage
+		if err := rows.Scan(
+			&i.ID,
+			&i.CommentID,
+			&i.Filename,
+			&i.Filepath,
+			&i.CreatedAt,
+			&i.Width,
+			&i.Height,
+			&i.Position,
+			&i.Caption,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadImageVariants = `-- name: ListThreadImageVariants :many
+SELECT v.id, v.image_id, v.variant, v.filename, v.filepath, v.width, v.height
+FROM thread_image_variants v
+JOIN thread_images ti ON ti.id = v.image_id
+WHERE ti.thread_id = ?
+ORDER BY v.width ASC
+`
+
+func (q *Queries) ListThreadImageVariants(ctx context.Context, threadID string) ([]ThreadImageVariant, error) {
+	rows, err := q.db.QueryContext(ctx, listThreadImageVariants, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ThreadImageVariant{}
+	for rows.Next() {
+		var i ThreadImageVariant
+		if err := rows.Scan(
+			&i.ID,
+			&i.ImageID,
+			&i.Variant,
+			&i.Filename,
+			&i.Filepath,
+			&i.Width,
+			&i.Height,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadImages = `-- name: ListThreadImages :many
+SELECT id, thread_id, filename, filepath, created_at, width, height, position, caption FROM thread_images WHERE thread_id = ? ORDER BY position ASC
+`
+
+func (q *Queries) ListThreadImages(ctx context.Context, threadID string) ([]ThreadImage, error) {
+	rows, err := q.db.QueryContext(ctx, listThreadImages, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ThreadImage{}
+	for rows.Next() {
+		var i ThreadImage
+		if err := rows.Scan(
+			&i.ID,
+			&i.ThreadID,
+			&i.Filename,
+			&i.Filepath,
+			&i.CreatedAt,
+			&i.Width,
+			&i.Height,
+			&i.Position,
+			&i.Caption,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadSubscribers = `-- name: ListThreadSubscribers :many
+SELECT user_id FROM thread_subscriptions WHERE thread_id = ? AND subscribed = 1
+`
+
+func (q *Queries) ListThreadSubscribers(ctx context.Context, threadID string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listThreadSubscribers, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		items = append(items, userID)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreads = `-- name: ListThreads :many
+SELECT t.id, t.title, t.content, t.category, t.created_at, t.author_id, t.pinned, t.locked, t.hidden, t.language, t.edited_at, t.last_activity_at, t.approved, t.score, t.accepted_comment_id, u.username AS author_name, tt.title AS translated_title, tt.content AS translated_content,
+    CAST((SELECT json_group_object(reaction, n) FROM (
+        SELECT reaction, COUNT(*) AS n FROM reactions
+        WHERE target_type = 'thread' AND target_id = t.id
+        GROUP BY reaction
+    )) AS TEXT) AS reaction_counts,
+    CAST((SELECT json_group_array(reaction) FROM reactions
+        WHERE target_type = 'thread' AND target_id = t.id AND user_id = ?1
+    ) AS TEXT) AS user_reactions
+FROM threads t
+LEFT JOIN users u ON u.id = t.author_id
+LEFT JOIN thread_translations tt ON tt.thread_id = t.id AND tt.language = ?2
+WHERE (?3 IS NULL OR t.category = ?3)
+  AND ((t.hidden = 0 AND t.approved = 1) OR CAST(?4 AS BOOLEAN))
+  AND (?5 IS NULL OR (
+       (t.accepted_comment_id IS NOT NULL) = ?5
+       AND t.category IN (SELECT slug FROM categories WHERE solutions_enabled = 1)))
+  AND (NOT CAST(?6 AS BOOLEAN) OR CASE ?7
+       WHEN 'top' THEN (t.pinned, t.score, t.created_at, t.id)
+           < (?8, ?9, ?10, ?11)
+       WHEN 'active' THEN (t.pinned, COALESCE(t.last_activity_at, t.created_at), t.created_at, t.id)
+           < (?8, ?12, ?10, ?11)
+       ELSE (t.pinned, t.created_at, t.id) < (?8, ?10, ?11)
+       END)
+ORDER BY t.pinned DESC,
+    CASE WHEN ?7 = 'top' THEN t.score END DESC,
+    CASE WHEN ?7 = 'active' THEN COALESCE(t.last_activity_at, t.created_at) END DESC,
+    t.created_at DESC, t.id DESC
+LIMIT ?13
+`
+
+type ListThreadsParams struct {
+	UserID          sql.NullString `json:"user_id"`
+	Language        string         `json:"language"`
+	Category        sql.NullString `json:"category"`
+	IncludeHidden   bool           `json:"include_hidden"`
+	Solved          sql.NullBool   `json:"solved"`
+	HasCursor       bool           `json:"has_cursor"`
+	Sort            string         `json:"sort"`
+	CursorPinned    bool           `json:"cursor_pinned"`
+	CursorScore     int64          `json:"cursor_score"`
+	CursorCreatedAt time.Time      `json:"cursor_created_at"`
+	CursorID        string         `json:"cursor_id"`
+	CursorActiveAt  time.Time      `json:"cursor_active_at"`
+	Limit           int64          `json:"limit"`
+}
+
+type ListThreadsRow struct {
+	ID                string         `json:"id"`
+	Title             string         `json:"title"`
+	Content           string         `json:"content"`
+	Category          string         `json:"category"`
+	CreatedAt         time.Time      `json:"created_at"`
+	AuthorID          sql.NullString `json:"author_id"`
+	Pinned            bool           `json:"pinned"`
+	Locked            bool           `json:"locked"`
+	Hidden            bool           `json:"hidden"`
+	Language          string         `json:"language"`
+	EditedAt          sql.NullTime   `json:"edited_at"`
+	LastActivityAt    sql.NullTime   `json:"last_activity_at"`
+	Approved          bool           `json:"approved"`
+	Score             int64          `json:"score"`
+	AcceptedCommentID sql.NullString `json:"accepted_comment_id"`
+	AuthorName        sql.NullString `json:"author_name"`
+	TranslatedTitle   sql.NullString `json:"translated_title"`
+	TranslatedContent sql.NullString `json:"translated_content"`
+	ReactionCounts    string         `json:"reaction_counts"`
+	UserReactions     string         `json:"user_reactions"`
+}
+
+func (q *Queries) ListThreads(ctx context.Context, arg ListThreadsParams) ([]ListThreadsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listThreads,
+		arg.UserID,
+		arg.Language,
+		arg.Category,
+		arg.IncludeHidden,
+		arg.Solved,
+		arg.HasCursor,
+		arg.Sort,
+		arg.CursorPinned,
+		arg.CursorScore,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.CursorActiveAt,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListThreadsRow{}
+	for rows.Next() {
+		var i ListThreadsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Content,
+			&i.Category,
+			&i.CreatedAt,
+			&i.AuthorID,
+			&i.Pinned,
+			&i.Locked,
+			&i.Hidden,
+			&i.Language,
+			&i.EditedAt,
+			&i.LastActivityAt,
+			&i.Approved,
+			&i.Score,
+			&i.AcceptedCommentID,
+			&i.AuthorName,
+			&i.TranslatedTitle,
+			&i.TranslatedContent,
+			&i.ReactionCounts,
+			&i.UserReactions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranslationJobs = `-- name: ListTranslationJobs :many
+SELECT id, target_type, target_id, source_language, target_language, status, attempts, last_error, run_at, created_at, updated_at FROM translation_jobs
+WHERE status = ?
+ORDER BY updated_at DESC
+LIMIT ?
+`
+
+type ListTranslationJobsParams struct {
+	Status string `json:"status"`
+	Limit  int64  `json:"limit"`
+}
+
+func (q *Queries) ListTranslationJobs(ctx context.Context, arg ListTranslationJobsParams) ([]TranslationJob, error) {
+	rows, err := q.db.QueryContext(ctx, listTranslationJobs, arg.Status, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TranslationJob{}
+	for rows.Next() {
+		var i TranslationJob
+		if err := rows.Scan(
+			&i.ID,
+			&i.TargetType,
+			&i.TargetID,
+			&i.SourceLanguage,
+			&i.TargetLanguage,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.RunAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
 		}
 		items = append(items, i)
 	}
@@ -232,27 +2542,170 @@ func (q *Queries) ListAllThreads(ctx context.Context) ([]Thread, error) {
 	return items, nil
 }
 
-const listThreads = `-- name: ListThreads :many
-SELECT id, title, content, category, created_at FROM threads 
-WHERE category = ?1
-ORDER BY created_at DESC
+const markAllNotificationsRead = `-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL
+`
+
+type MarkAllNotificationsReadParams struct {
+	ReadAt sql.NullTime `json:"read_at"`
+	UserID string       `json:"user_id"`
+}
+
+func (q *Queries) MarkAllNotificationsRead(ctx context.Context, arg MarkAllNotificationsReadParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markAllNotificationsRead, arg.ReadAt, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markDigestSent = `-- name: MarkDigestSent :exec
+UPDATE email_settings SET last_digest_at = ? WHERE user_id = ?
+`
+
+type MarkDigestSentParams struct {
+	LastDigestAt time.Time `json:"last_digest_at"`
+	UserID       string    `json:"user_id"`
+}
+
+func (q *Queries) MarkDigestSent(ctx context.Context, arg MarkDigestSentParams) error {
+	_, err := q.db.ExecContext(ctx, markDigestSent, arg.LastDigestAt, arg.UserID)
+	return err
+}
+
+const reparentCommentReplies = `-- name: ReparentCommentReplies :exec
+UPDATE comments SET parent_comment_id = ?1
+WHERE parent_comment_id = ?2
+`
+
+type ReparentCommentRepliesParams struct {
+	NewParentID sql.NullString `json:"new_parent_id"`
+	CommentID   string         `json:"comment_id"`
+}
+
+func (q *Queries) ReparentCommentReplies(ctx context.Context, arg ReparentCommentRepliesParams) error {
+	_, err := q.db.ExecContext(ctx, reparentCommentReplies, arg.NewParentID, arg.CommentID)
+	return err
+}
+
+const requeueFailedTranslationJobs = `-- name: RequeueFailedTranslationJobs :execrows
+UPDATE translation_jobs
+SET status = 'pending', attempts = 0, last_error = '', run_at = ?1, updated_at = ?1
+WHERE status = 'failed'
 `
 
-func (q *Queries) ListThreads(ctx context.Context, category string) ([]Thread, error) {
-	rows, err := q.db.QueryContext(ctx, listThreads, category)
+func (q *Queries) RequeueFailedTranslationJobs(ctx context.Context, now time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, requeueFailedTranslationJobs, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const requeueTranslationJob = `-- name: RequeueTranslationJob :execrows
+UPDATE translation_jobs
+SET status = 'pending', attempts = 0, last_error = '', run_at = ?1, updated_at = ?1
+WHERE id = ?2 AND status = 'failed'
+`
+
+type RequeueTranslationJobParams struct {
+	Now time.Time `json:"now"`
+	ID  string    `json:"id"`
+}
+
+func (q *Queries) RequeueTranslationJob(ctx context.Context, arg RequeueTranslationJobParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, requeueTranslationJob, arg.Now, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const resetRunningTranslationJobs = `-- name: ResetRunningTranslationJobs :exec
+UPDATE translation_jobs SET status = 'pending', updated_at = ? WHERE status = 'running'
+`
+
+func (q *Queries) ResetRunningTranslationJobs(ctx context.Context, updatedAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, resetRunningTranslationJobs, updatedAt)
+	return err
+}
+
+const searchPosts = `-- name: SearchPosts :many
+SELECT
+    CAST(search_index.kind AS TEXT) AS kind,
+    t.id AS thread_id,
+    c.id AS comment_id,
+    CAST(search_index.language AS TEXT) AS language,
+    t.title,
+    tt.title AS translated_title,
+    t.category,
+    t.created_at,
+    c.created_at AS comment_created_at,
+    CAST(snippet(search_index, -1, char(57344), char(57345), '…', 24) AS TEXT) AS snippet,
+    CAST(bm25(search_index, 0, 0, 0, 0, 0, 10.0, 1.0) AS REAL) AS rank
+FROM search_index
+JOIN threads t ON t.id = search_index.thread_id
+LEFT JOIN comments c ON c.id = search_index.comment_id
+LEFT JOIN thread_translations tt ON tt.thread_id = t.id AND tt.language = ?1
+WHERE search_index MATCH CAST(?2 AS TEXT)
+  AND (?3 IS NULL OR t.category = ?3)
+  AND (?4 IS NULL OR search_index.language = ?4)
+  AND ((t.hidden = 0 AND t.approved = 1 AND COALESCE(c.hidden, 0) = 0) OR CAST(?5 AS BOOLEAN))
+ORDER BY rank
+LIMIT ?6
+`
+
+type SearchPostsParams struct {
+	DisplayLanguage string         `json:"display_language"`
+	Query           string         `json:"query"`
+	Category        sql.NullString `json:"category"`
+	Language        sql.NullString `json:"language"`
+	IncludeHidden   bool           `json:"include_hidden"`
+	Limit           int64          `json:"limit"`
+}
+
+type SearchPostsRow struct {
+	Kind             string         `json:"kind"`
+	ThreadID         string         `json:"thread_id"`
+	CommentID        sql.NullString `json:"comment_id"`
+	Language         string         `json:"language"`
+	Title            string         `json:"title"`
+	TranslatedTitle  sql.NullString `json:"translated_title"`
+	Category         string         `json:"category"`
+	CreatedAt        time.Time      `json:"created_at"`
+	CommentCreatedAt sql.NullTime   `json:"comment_created_at"`
+	Snippet          string         `json:"snippet"`
+	Rank             float64        `json:"rank"`
+}
+
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPosts,
+		arg.DisplayLanguage,
+		arg.Query,
+		arg.Category,
+		arg.Language,
+		arg.IncludeHidden,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Thread{}
+	items := []SearchPostsRow{}
 	for rows.Next() {
-		var i Thread
+		var i SearchPostsRow
 		if err := rows.Scan(
-			&i.ID,
+			&i.Kind,
+			&i.ThreadID,
+			&i.CommentID,
+			&i.Language,
 			&i.Title,
-			&i.Content,
+			&i.TranslatedTitle,
 			&i.Category,
 			&i.CreatedAt,
+			&i.CommentCreatedAt,
+			&i.Snippet,
+			&i.Rank,
 		); err != nil {
 			return nil, err
 		}
@@ -266,3 +2719,477 @@ func (q *Queries) ListThreads(ctx context.Context, category string) ([]Thread, e
 	}
 	return items, nil
 }
+
+const setAcceptedComment = `-- name: SetAcceptedComment :execrows
+UPDATE threads SET accepted_comment_id = ? WHERE id = ?
+`
+
+type SetAcceptedCommentParams struct {
+	AcceptedCommentID sql.NullString `json:"accepted_comment_id"`
+	ID                string         `json:"id"`
+}
+
+func (q *Queries) SetAcceptedComment(ctx context.Context, arg SetAcceptedCommentParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setAcceptedComment, arg.AcceptedCommentID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setCommentHidden = `-- name: SetCommentHidden :execrows
+UPDATE comments SET hidden = ? WHERE id = ?
+`
+
+type SetCommentHiddenParams struct {
+	Hidden bool   `json:"hidden"`
+	ID     string `json:"id"`
+}
+
+func (q *Queries) SetCommentHidden(ctx context.Context, arg SetCommentHiddenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setCommentHidden, arg.Hidden, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setEmailDigest = `-- name: SetEmailDigest :exec
+UPDATE email_settings SET digest = ?, updated_at = ? WHERE user_id = ?
+`
+
+type SetEmailDigestParams struct {
+	Digest    string    `json:"digest"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    string    `json:"user_id"`
+}
+
+func (q *Queries) SetEmailDigest(ctx context.Context, arg SetEmailDigestParams) error {
+	_, err := q.db.ExecContext(ctx, setEmailDigest, arg.Digest, arg.UpdatedAt, arg.UserID)
+	return err
+}
+
+const setEmailNotifications = `-- name: SetEmailNotifications :exec
+UPDATE email_settings SET notifications = ?, updated_at = ? WHERE user_id = ?
+`
+
+type SetEmailNotificationsParams struct {
+	Notifications bool      `json:"notifications"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	UserID        string    `json:"user_id"`
+}
+
+func (q *Queries) SetEmailNotifications(ctx context.Context, arg SetEmailNotificationsParams) error {
+	_, err := q.db.ExecContext(ctx, setEmailNotifications, arg.Notifications, arg.UpdatedAt, arg.UserID)
+	return err
+}
+
+const setEmailVerification = `-- name: SetEmailVerification :exec
+UPDATE email_settings SET verification_token = ?, verification_sent_at = ?, updated_at = ? WHERE user_id = ?
+`
+
+type SetEmailVerificationParams struct {
+	VerificationToken  sql.NullString `json:"verification_token"`
+	VerificationSentAt sql.NullTime   `json:"verification_sent_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	UserID             string         `json:"user_id"`
+}
+
+func (q *Queries) SetEmailVerification(ctx context.Context, arg SetEmailVerificationParams) error {
+	_, err := q.db.ExecContext(ctx, setEmailVerification,
+		arg.VerificationToken,
+		arg.VerificationSentAt,
+		arg.UpdatedAt,
+		arg.UserID,
+	)
+	return err
+}
+
+const setNotificationRead = `-- name: SetNotificationRead :execrows
+UPDATE notifications SET read_at = ? WHERE id = ? AND user_id = ?
+`
+
+type SetNotificationReadParams struct {
+	ReadAt sql.NullTime `json:"read_at"`
+	ID     string       `json:"id"`
+	UserID string       `json:"user_id"`
+}
+
+func (q *Queries) SetNotificationRead(ctx context.Context, arg SetNotificationReadParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setNotificationRead, arg.ReadAt, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setThreadApproved = `-- name: SetThreadApproved :execrows
+UPDATE threads SET approved = ?1 WHERE id = ?2 AND approved != ?1
+`
+
+type SetThreadApprovedParams struct {
+	Approved bool   `json:"approved"`
+	ID       string `json:"id"`
+}
+
+func (q *Queries) SetThreadApproved(ctx context.Context, arg SetThreadApprovedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setThreadApproved, arg.Approved, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setThreadHidden = `-- name: SetThreadHidden :execrows
+UPDATE threads SET hidden = ? WHERE id = ?
+`
+
+type SetThreadHiddenParams struct {
+	Hidden bool   `json:"hidden"`
+	ID     string `json:"id"`
+}
+
+func (q *Queries) SetThreadHidden(ctx context.Context, arg SetThreadHiddenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setThreadHidden, arg.Hidden, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setThreadLocked = `-- name: SetThreadLocked :execrows
+UPDATE threads SET locked = ? WHERE id = ?
+`
+
+type SetThreadLockedParams struct {
+	Locked bool   `json:"locked"`
+	ID     string `json:"id"`
+}
+
+func (q *Queries) SetThreadLocked(ctx context.Context, arg SetThreadLockedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setThreadLocked, arg.Locked, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setThreadPinned = `-- name: SetThreadPinned :execrows
+UPDATE threads SET pinned = ? WHERE id = ?
+`
+
+type SetThreadPinnedParams struct {
+	Pinned bool   `json:"pinned"`
+	ID     string `json:"id"`
+}
+
+func (q *Queries) SetThreadPinned(ctx context.Context, arg SetThreadPinnedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setThreadPinned, arg.Pinned, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserRole = `-- name: SetUserRole :execrows
+UPDATE users SET role = ? WHERE id = ?
+`
+
+type SetUserRoleParams struct {
+	Role string `json:"role"`
+	ID   string `json:"id"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserRole, arg.Role, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const touchCommentActivity = `-- name: TouchCommentActivity :exec
+UPDATE comments SET last_activity_at = ? WHERE id = ?
+`
+
+type TouchCommentActivityParams struct {
+	LastActivityAt sql.NullTime `json:"last_activity_at"`
+	ID             string       `json:"id"`
+}
+
+func (q *Queries) TouchCommentActivity(ctx context.Context, arg TouchCommentActivityParams) error {
+	_, err := q.db.ExecContext(ctx, touchCommentActivity, arg.LastActivityAt, arg.ID)
+	return err
+}
+
+const touchThreadActivity = `-- name: TouchThreadActivity :exec
+UPDATE threads SET last_activity_at = ? WHERE id = ?
+`
+
+type TouchThreadActivityParams struct {
+	LastActivityAt sql.NullTime `json:"last_activity_at"`
+	ID             string       `json:"id"`
+}
+
+func (q *Queries) TouchThreadActivity(ctx context.Context, arg TouchThreadActivityParams) error {
+	_, err := q.db.ExecContext(ctx, touchThreadActivity, arg.LastActivityAt, arg.ID)
+	return err
+}
+
+const updateCategory = `-- name: UpdateCategory :execrows
+UPDATE categories
+SET sort_order = ?, icon = ?, thread_role = ?, comment_role = ?, requires_approval = ?, solutions_enabled = ?
+WHERE slug = ?
+`
+
+type UpdateCategoryParams struct {
+	SortOrder        int64  `json:"sort_order"`
+	Icon             string `json:"icon"`
+	ThreadRole       string `json:"thread_role"`
+	CommentRole      string `json:"comment_role"`
+	RequiresApproval bool   `json:"requires_approval"`
+	SolutionsEnabled bool   `json:"solutions_enabled"`
+	Slug             string `json:"slug"`
+}
+
+func (q *Queries) UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateCategory,
+		arg.SortOrder,
+		arg.Icon,
+		arg.ThreadRole,
+		arg.CommentRole,
+		arg.RequiresApproval,
+		arg.SolutionsEnabled,
+		arg.Slug,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateComment = `-- name: UpdateComment :execrows
+UPDATE comments SET language = ?, edited_at = ? WHERE id = ?
+`
+
+type UpdateCommentParams struct {
+	Language string       `json:"language"`
+	EditedAt sql.NullTime `json:"edited_at"`
+	ID       string       `json:"id"`
+}
+
+func (q *Queries) UpdateComment(ctx context.Context, arg UpdateCommentParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateComment, arg.Language, arg.EditedAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateCommentScore = `-- name: UpdateCommentScore :exec
+UPDATE comments SET score = (
+    SELECT COALESCE(SUM(CASE reaction WHEN 'up' THEN 1 WHEN 'down' THEN -1 ELSE 0 END), 0)
+    FROM reactions WHERE target_type = 'comment' AND target_id = comments.id
+) WHERE id = ?
+`
+
+func (q *Queries) UpdateCommentScore(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, updateCommentScore, id)
+	return err
+}
+
+const updateThread = `-- name: UpdateThread :execrows
+UPDATE threads SET title = ?, content = ?, language = ?, edited_at = ? WHERE id = ?
+`
+
+type UpdateThreadParams struct {
+	Title    string       `json:"title"`
+	Content  string       `json:"content"`
+	Language string       `json:"language"`
+	EditedAt sql.NullTime `json:"edited_at"`
+	ID       string       `json:"id"`
+}
+
+func (q *Queries) UpdateThread(ctx context.Context, arg UpdateThreadParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateThread,
+		arg.Title,
+		arg.Content,
+		arg.Language,
+		arg.EditedAt,
+		arg.ID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateThreadScore = `-- name: UpdateThreadScore :exec
+UPDATE threads SET score = (
+    SELECT COALESCE(SUM(CASE reaction WHEN 'up' THEN 1 WHEN 'down' THEN -1 ELSE 0 END), 0)
+    FROM reactions WHERE target_type = 'thread' AND target_id = threads.id
+) WHERE id = ?
+`
+
+func (q *Queries) UpdateThreadScore(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, updateThreadScore, id)
+	return err
+}
+
+const upsertCommentTranslation = `-- name: UpsertCommentTranslation :one
+INSERT INTO comment_translations (id, comment_id, language, content)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (comment_id, language) DO UPDATE SET content = excluded.content
+RETURNING id, comment_id, language, content, detected_language, detection_confidence
+`
+
+type UpsertCommentTranslationParams struct {
+	ID        string `json:"id"`
+	CommentID string `json:"comment_id"`
+	Language  string `json:"language"`
+	Content   string `json:"content"`
+}
+
+func (q *Queries) UpsertCommentTranslation(ctx context.Context, arg UpsertCommentTranslationParams) (CommentTranslation, error) {
+	row := q.db.QueryRowContext(ctx, upsertCommentTranslation,
+		arg.ID,
+		arg.CommentID,
+		arg.Language,
+		arg.Content,
+	)
+	var i CommentTranslation
+	err := row.Scan(
+		&i.ID,
+		&i.CommentID,
+		&i.Language,
+		&i.Content,
+		&i.DetectedLanguage,
+		&i.DetectionConfidence,
+	)
+	return i, err
+}
+
+const upsertEmailSettings = `-- name: UpsertEmailSettings :one
+INSERT INTO email_settings (user_id, email, verification_token, verification_sent_at, language, notifications, digest,
+    last_digest_at, unsubscribe_token, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE SET
+    -- A changed address has to be confirmed again
+    verified_at = CASE WHEN email_settings.email = excluded.email
+        THEN email_settings.verified_at ELSE NULL END,
+    verification_token = CASE WHEN email_settings.email = excluded.email
+        THEN email_settings.verification_token ELSE excluded.verification_token END,
+    verification_sent_at = CASE WHEN email_settings.email = excluded.email
+        THEN email_settings.verification_sent_at ELSE excluded.verification_sent_at END,
+    email = excluded.email,
+    language = excluded.language,
+    notifications = excluded.notifications,
+    digest = excluded.digest,
+    -- A digest that was just turned on or changed starts from now
+    last_digest_at = CASE WHEN email_settings.digest = excluded.digest
+        THEN email_settings.last_digest_at ELSE excluded.last_digest_at END,
+    updated_at = excluded.updated_at
+RETURNING user_id, email, verified_at, verification_token, verification_sent_at, language, notifications, digest, last_digest_at, unsubscribe_token, updated_at
+`
+
+type UpsertEmailSettingsParams struct {
+	UserID             string         `json:"user_id"`
+	Email              string         `json:"email"`
+	VerificationToken  sql.NullString `json:"verification_token"`
+	VerificationSentAt sql.NullTime   `json:"verification_sent_at"`
+	Language           string         `json:"language"`
+	Notifications      bool           `json:"notifications"`
+	Digest             string         `json:"digest"`
+	LastDigestAt       time.Time      `json:"last_digest_at"`
+	UnsubscribeToken   string         `json:"unsubscribe_token"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+}
+
+func (q *Queries) UpsertEmailSettings(ctx context.Context, arg UpsertEmailSettingsParams) (EmailSetting, error) {
+	row := q.db.QueryRowContext(ctx, upsertEmailSettings,
+		arg.UserID,
+		arg.Email,
+		arg.VerificationToken,
+		arg.VerificationSentAt,
+		arg.Language,
+		arg.Notifications,
+		arg.Digest,
+		arg.LastDigestAt,
+		arg.UnsubscribeToken,
+		arg.UpdatedAt,
+	)
+	var i EmailSetting
+	err := row.Scan(
+		&i.UserID,
+		&i.Email,
+		&i.VerifiedAt,
+		&i.VerificationToken,
+		&i.VerificationSentAt,
+		&i.Language,
+		&i.Notifications,
+		&i.Digest,
+		&i.LastDigestAt,
+		&i.UnsubscribeToken,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertThreadSubscription = `-- name: UpsertThreadSubscription :exec
+INSERT INTO thread_subscriptions (user_id, thread_id, subscribed, created_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (user_id, thread_id) DO UPDATE SET subscribed = excluded.subscribed
+`
+
+type UpsertThreadSubscriptionParams struct {
+	UserID     string    `json:"user_id"`
+	ThreadID   string    `json:"thread_id"`
+	Subscribed bool      `json:"subscribed"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (q *Queries) UpsertThreadSubscription(ctx context.Context, arg UpsertThreadSubscriptionParams) error {
+	_, err := q.db.ExecContext(ctx, upsertThreadSubscription,
+		arg.UserID,
+		arg.ThreadID,
+		arg.Subscribed,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const upsertThreadTranslation = `-- name: UpsertThreadTranslation :one
+INSERT INTO thread_translations (id, thread_id, language, title, content)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (thread_id, language) DO UPDATE SET title = excluded.title, content = excluded.content
+RETURNING id, thread_id, language, title, content, detected_language, detection_confidence
+`
+
+type UpsertThreadTranslationParams struct {
+	ID       string `json:"id"`
+	ThreadID string `json:"thread_id"`
+	Language string `json:"language"`
+	Title    string `json:"title"`
+	Content  string `json:"content"`
+}
+
+func (q *Queries) UpsertThreadTranslation(ctx context.Context, arg UpsertThreadTranslationParams) (ThreadTranslation, error) {
+	row := q.db.QueryRowContext(ctx, upsertThreadTranslation,
+		arg.ID,
+		arg.ThreadID,
+		arg.Language,
+		arg.Title,
+		arg.Content,
+	)
+	var i ThreadTranslation
+	err := row.Scan(
+		&i.ID,
+		&i.ThreadID,
+		&i.Language,
+		&i.Title,
+		&i.Content,
+		&i.DetectedLanguage,
+		&i.DetectionConfidence,
+	)
+	return i, err
+}
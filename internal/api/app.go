@@ -3,22 +3,148 @@ package api
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
 	sqlcdb "pkoforum/db/sqlc"
+	"pkoforum/internal/blob"
+	"pkoforum/internal/config"
+	"pkoforum/internal/langdetect"
+	"pkoforum/internal/mailer"
+	"pkoforum/internal/media"
+	"pkoforum/internal/translate"
 
 	"github.com/gorilla/mux"
-	"github.com/sashabaranov/go-openai"
+)
+
+// errNotFound is returned by helpers when the targeted row does not exist
+var errNotFound = errors.New("not found")
+
+// Errors returned when a comment cannot reply to the requested comment
+var (
+	errInvalidParent = errors.New("parent comment does not belong to this thread")
+	errReplyTooDeep  = errors.New("replies are nested too deeply")
 )
 
 // Querier defines the database operations interface
 type Querier interface {
+	AddCategorySubscription(ctx context.Context, arg sqlcdb.AddCategorySubscriptionParams) error
+	AddReaction(ctx context.Context, arg sqlcdb.AddReactionParams) error
+	ClaimTranslationJob(ctx context.Context, now time.Time) (sqlcdb.TranslationJob, error)
+	ClearAcceptedComment(ctx context.Context, acceptedCommentID sql.NullString) error
+	ClearEmailSettings(ctx context.Context, arg sqlcdb.ClearEmailSettingsParams) error
+	ConfirmEmail(ctx context.Context, arg sqlcdb.ConfirmEmailParams) error
+	CountUnreadNotifications(ctx context.Context, arg sqlcdb.CountUnreadNotificationsParams) (int64, error)
+	CreateCategory(ctx context.Context, arg sqlcdb.CreateCategoryParams) (sqlcdb.Category, error)
+	CreateCategoryTranslation(ctx context.Context, arg sqlcdb.CreateCategoryTranslationParams) (sqlcdb.CategoryTranslation, error)
 	CreateComment(ctx context.Context, arg sqlcdb.CreateCommentParams) (sqlcdb.Comment, error)
 	CreateCommentImage(ctx context.Context, arg sqlcdb.CreateCommentImageParams) (sqlcdb.CommentImage, error)
+	CreateCommentImageVariant(ctx context.Context, arg sqlcdb.CreateCommentImageVariantParams) (sqlcdb.CommentImageVariant, error)
 	CreateCommentTranslation(ctx context.Context, arg sqlcdb.CreateCommentTranslationParams) (sqlcdb.CommentTranslation, error)
+	CreateModerationLog(ctx context.Context, arg sqlcdb.CreateModerationLogParams) (sqlcdb.ModerationLog, error)
+	CreateNotification(ctx context.Context, arg sqlcdb.CreateNotificationParams) (int64, error)
+	CreateRevision(ctx context.Context, arg sqlcdb.CreateRevisionParams) (sqlcdb.Revision, error)
+	CreateSession(ctx context.Context, arg sqlcdb.CreateSessionParams) (sqlcdb.Session, error)
 	CreateThread(ctx context.Context, arg sqlcdb.CreateThreadParams) (sqlcdb.Thread, error)
-	GetThread(ctx context.Context, id string) (sqlcdb.Thread, error)
-	GetThreadComments(ctx context.Context, threadID string) ([]sqlcdb.GetThreadCommentsRow, error)
-	ListAllThreads(ctx context.Context) ([]sqlcdb.Thread, error)
-	ListThreads(ctx context.Context, category string) ([]sqlcdb.Thread, error)
+	CreateThreadImage(ctx context.Context, arg sqlcdb.CreateThreadImageParams) (sqlcdb.ThreadImage, error)
+	CreateThreadImageVariant(ctx context.Context, arg sqlcdb.CreateThreadImageVariantParams) (sqlcdb.ThreadImageVariant, error)
+	CreateThreadTranslation(ctx context.Context, arg sqlcdb.CreateThreadTranslationParams) (sqlcdb.ThreadTranslation, error)
+	CreateTranslationJob(ctx context.Context, arg sqlcdb.CreateTranslationJobParams) (sqlcdb.TranslationJob, error)
+	CreateUser(ctx context.Context, arg sqlcdb.CreateUserParams) (sqlcdb.User, error)
+	DecrementReplyDepths(ctx context.Context, commentID string) error
+	DeleteCategorySubscribers(ctx context.Context, categorySlug string) error
+	DeleteCategorySubscriptions(ctx context.Context, userID string) error
+	DeleteCategoryTranslations(ctx context.Context, categorySlug string) error
+	DeleteComment(ctx context.Context, id string) (int64, error)
+	DeleteCommentImageVariants(ctx context.Context, commentID string) error
+	DeleteCommentImages(ctx context.Context, commentID string) error
+	DeleteCommentNotifications(ctx context.Context, commentID sql.NullString) error
+	DeleteCommentTranslations(ctx context.Context, commentID string) error
+	DeleteExpiredSessions(ctx context.Context, expiresAt time.Time) error
+	DeleteReaction(ctx context.Context, arg sqlcdb.DeleteReactionParams) (int64, error)
+	DeleteReactions(ctx context.Context, arg sqlcdb.DeleteReactionsParams) error
+	DeleteRevisions(ctx context.Context, arg sqlcdb.DeleteRevisionsParams) error
+	DeleteSession(ctx context.Context, id string) error
+	DeleteThread(ctx context.Context, id string) (int64, error)
+	DeleteThreadCommentImageVariants(ctx context.Context, threadID string) error
+	DeleteThreadCommentImages(ctx context.Context, threadID string) error
+	DeleteThreadCommentReactions(ctx context.Context, threadID string) error
+	DeleteThreadCommentRevisions(ctx context.Context, threadID string) error
+	DeleteThreadCommentTranslationJobs(ctx context.Context, threadID string) error
+	DeleteThreadCommentTranslations(ctx context.Context, threadID string) error
+	DeleteThreadComments(ctx context.Context, threadID string) error
+	DeleteThreadImageVariants(ctx context.Context, threadID string) error
+	DeleteThreadImages(ctx context.Context, threadID string) error
+	DeleteThreadNotifications(ctx context.Context, threadID string) error
+	DeleteThreadSubscriptions(ctx context.Context, threadID string) error
+	DeleteThreadTranslations(ctx context.Context, threadID string) error
+	DeleteTranslationJob(ctx context.Context, id string) error
+	DeleteTranslationJobs(ctx context.Context, arg sqlcdb.DeleteTranslationJobsParams) error
+	DeleteUnusedCategory(ctx context.Context, slug string) (int64, error)
+	EnqueueMissingCommentTranslations(ctx context.Context, arg sqlcdb.EnqueueMissingCommentTranslationsParams) (int64, error)
+	EnqueueMissingThreadTranslations(ctx context.Context, arg sqlcdb.EnqueueMissingThreadTranslationsParams) (int64, error)
+	FailTranslationJob(ctx context.Context, arg sqlcdb.FailTranslationJobParams) error
+	GetCategory(ctx context.Context, slug string) (sqlcdb.Category, error)
+	GetComment(ctx context.Context, id string) (sqlcdb.Comment, error)
+	GetCommentTranslation(ctx context.Context, arg sqlcdb.GetCommentTranslationParams) (sqlcdb.CommentTranslation, error)
+	GetEmailSettings(ctx context.Context, userID string) (sqlcdb.EmailSetting, error)
+	GetEmailSettingsByToken(ctx context.Context, unsubscribeToken string) (sqlcdb.EmailSetting, error)
+	GetEmailSettingsByVerificationToken(ctx context.Context, verificationToken sql.NullString) (sqlcdb.EmailSetting, error)
+	GetReactions(ctx context.Context, arg sqlcdb.GetReactionsParams) (sqlcdb.GetReactionsRow, error)
+	GetSessionUser(ctx context.Context, arg sqlcdb.GetSessionUserParams) (sqlcdb.User, error)
+	GetThread(ctx context.Context, arg sqlcdb.GetThreadParams) (sqlcdb.GetThreadRow, error)
+	GetThreadComments(ctx context.Context, arg sqlcdb.GetThreadCommentsParams) ([]sqlcdb.GetThreadCommentsRow, error)
+	GetThreadSubscription(ctx context.Context, arg sqlcdb.GetThreadSubscriptionParams) (sqlcdb.ThreadSubscription, error)
+	GetThreadTranslation(ctx context.Context, arg sqlcdb.GetThreadTranslationParams) (sqlcdb.ThreadTranslation, error)
+	GetUser(ctx context.Context, id string) (sqlcdb.User, error)
+	GetUserByUsername(ctx context.Context, username string) (sqlcdb.User, error)
+	ListCategories(ctx context.Context) ([]sqlcdb.ListCategoriesRow, error)
+	ListCategorySubscriptions(ctx context.Context, userID string) ([]string, error)
+	ListCategoryTranslations(ctx context.Context) ([]sqlcdb.CategoryTranslation, error)
+	ListCommentImageVariants(ctx context.Context, commentID string) ([]sqlcdb.CommentImageVariant, error)
+	ListCommentImages(ctx context.Context, commentID string) ([]sqlcdb.CommentImage, error)
+	ListDigestThreads(ctx context.Context, arg sqlcdb.ListDigestThreadsParams) ([]sqlcdb.ListDigestThreadsRow, error)
+	ListDueDigests(ctx context.Context, arg sqlcdb.ListDueDigestsParams) ([]sqlcdb.EmailSetting, error)
+	ListModerationLog(ctx context.Context, limit int64) ([]sqlcdb.ListModerationLogRow, error)
+	ListNotifications(ctx context.Context, arg sqlcdb.ListNotificationsParams) ([]sqlcdb.ListNotificationsRow, error)
+	ListRevisions(ctx context.Context, arg sqlcdb.ListRevisionsParams) ([]sqlcdb.ListRevisionsRow, error)
+	ListThreadCommentImageVariants(ctx context.Context, threadID string) ([]sqlcdb.CommentImageVariant, error)
+	ListThreadCommentImages(ctx context.Context, threadID string) ([]sqlcdb.CommentImage, error)
+	ListThreadImageVariants(ctx context.Context, threadID string) ([]sqlcdb.ThreadImageVariant, error)
+	ListThreadImages(ctx context.Context, threadID string) ([]sqlcdb.ThreadImage, error)
+	ListThreadSubscribers(ctx context.Context, threadID string) ([]string, error)
+	ListThreads(ctx context.Context, arg sqlcdb.ListThreadsParams) ([]sqlcdb.ListThreadsRow, error)
+	ListTranslationJobs(ctx context.Context, arg sqlcdb.ListTranslationJobsParams) ([]sqlcdb.TranslationJob, error)
+	MarkAllNotificationsRead(ctx context.Context, arg sqlcdb.MarkAllNotificationsReadParams) (int64, error)
+	MarkDigestSent(ctx context.Context, arg sqlcdb.MarkDigestSentParams) error
+	ReparentCommentReplies(ctx context.Context, arg sqlcdb.ReparentCommentRepliesParams) error
+	RequeueFailedTranslationJobs(ctx context.Context, now time.Time) (int64, error)
+	RequeueTranslationJob(ctx context.Context, arg sqlcdb.RequeueTranslationJobParams) (int64, error)
+	ResetRunningTranslationJobs(ctx context.Context, updatedAt time.Time) error
+	SearchPosts(ctx context.Context, arg sqlcdb.SearchPostsParams) ([]sqlcdb.SearchPostsRow, error)
+	SetAcceptedComment(ctx context.Context, arg sqlcdb.SetAcceptedCommentParams) (int64, error)
+	SetCommentHidden(ctx context.Context, arg sqlcdb.SetCommentHiddenParams) (int64, error)
+	SetEmailDigest(ctx context.Context, arg sqlcdb.SetEmailDigestParams) error
+	SetEmailNotifications(ctx context.Context, arg sqlcdb.SetEmailNotificationsParams) error
+	SetEmailVerification(ctx context.Context, arg sqlcdb.SetEmailVerificationParams) error
+	SetNotificationRead(ctx context.Context, arg sqlcdb.SetNotificationReadParams) (int64, error)
+	SetThreadApproved(ctx context.Context, arg sqlcdb.SetThreadApprovedParams) (int64, error)
+	SetThreadHidden(ctx context.Context, arg sqlcdb.SetThreadHiddenParams) (int64, error)
+	SetThreadLocked(ctx context.Context, arg sqlcdb.SetThreadLockedParams) (int64, error)
+	SetThreadPinned(ctx context.Context, arg sqlcdb.SetThreadPinnedParams) (int64, error)
+	SetUserRole(ctx context.Context, arg sqlcdb.SetUserRoleParams) (int64, error)
+	TouchCommentActivity(ctx context.Context, arg sqlcdb.TouchCommentActivityParams) error
+	TouchThreadActivity(ctx context.Context, arg sqlcdb.TouchThreadActivityParams) error
+	UpdateCategory(ctx context.Context, arg sqlcdb.UpdateCategoryParams) (int64, error)
+	UpdateComment(ctx context.Context, arg sqlcdb.UpdateCommentParams) (int64, error)
+	UpdateCommentScore(ctx context.Context, id string) error
+	UpdateThread(ctx context.Context, arg sqlcdb.UpdateThreadParams) (int64, error)
+	UpdateThreadScore(ctx context.Context, id string) error
+	UpsertCommentTranslation(ctx context.Context, arg sqlcdb.UpsertCommentTranslationParams) (sqlcdb.CommentTranslation, error)
+	UpsertEmailSettings(ctx context.Context, arg sqlcdb.UpsertEmailSettingsParams) (sqlcdb.EmailSetting, error)
+	UpsertThreadSubscription(ctx context.Context, arg sqlcdb.UpsertThreadSubscriptionParams) error
+	UpsertThreadTranslation(ctx context.Context, arg sqlcdb.UpsertThreadTranslationParams) (sqlcdb.ThreadTranslation, error)
 	WithTx(tx *sql.Tx) *sqlcdb.Queries
 }
 
@@ -26,19 +152,70 @@ type Querier interface {
 type App struct {
 	db          *sql.DB
 	queries     Querier
-	openai      *openai.Client
+	translator  translate.Translator // nil when translation is disabled
+	blobs       blob.Store
 	router      *mux.Router
-	uploadsPath string
+	sessionTTL  time.Duration
+	languages   []string
+	defaultLang string
+	detector    *langdetect.Detector
+
+	imageLimits    media.Limits
+	maxAttachments int
+	maxReplyDepth  int64
+
+	// reactions is the emoji set users may react with besides votes
+	reactions []string
+
+	translations      *translationQueue
+	events            *eventBroker
+	notificationHooks notificationHooks
+
+	// mailer is nil when e-mail is disabled
+	mailer  mailer.Mailer
+	siteURL string
+	digests digestWorker
+
+	serverUI    bool
+	datastarURL string
 }
 
 // NewApp creates a new application instance
-func NewApp(db *sql.DB, queries Querier, openai *openai.Client, uploadsPath string) *App {
+func NewApp(db *sql.DB, queries Querier, translator translate.Translator, blobs blob.Store, mail mailer.Mailer, cfg *config.Config) *App {
 	app := &App{
 		db:          db,
 		queries:     queries,
-		openai:      openai,
+		translator:  translator,
+		blobs:       blobs,
 		router:      mux.NewRouter(),
-		uploadsPath: uploadsPath,
+		sessionTTL:  cfg.SessionTTL,
+		languages:   cfg.Languages,
+		defaultLang: cfg.DefaultLanguage,
+		detector:    langdetect.New(cfg.Languages),
+
+		imageLimits: media.Limits{
+			MaxBytes:  cfg.ImageMaxBytes,
+			MaxWidth:  cfg.ImageMaxWidth,
+			MaxHeight: cfg.ImageMaxHeight,
+			MaxFrames: cfg.ImageMaxFrames,
+			MaxPixels: cfg.ImageMaxPixels,
+		},
+		maxAttachments: cfg.MaxAttachments,
+		maxReplyDepth:  int64(cfg.MaxReplyDepth),
+
+		reactions: cfg.Reactions,
+
+		translations: newTranslationQueue(cfg.TranslationWorkers, cfg.TranslationMaxAttempts),
+		events:       newEventBroker(),
+
+		mailer:  mail,
+		siteURL: cfg.SiteURL,
+
+		serverUI:    cfg.ServerUI,
+		datastarURL: cfg.DatastarURL,
+	}
+	if mail != nil {
+		app.AddNotificationHook(mailNotifier{app: app})
 	}
 	app.setupRoutes()
 	return app
@@ -50,8 +227,66 @@ func (app *App) setupRoutes() {
 	app.router.HandleFunc("/api/threads", app.GetThreads).Methods("GET")
 	app.router.HandleFunc("/api/threads", app.CreateThread).Methods("POST")
 	app.router.HandleFunc("/api/threads/{id}", app.GetThread).Methods("GET")
+	app.router.HandleFunc("/api/threads/{id}", app.UpdateThread).Methods("PUT")
+	app.router.HandleFunc("/api/threads/{id}", app.DeleteThread).Methods("DELETE")
+	app.router.HandleFunc("/api/threads/{id}/revisions", app.GetThreadRevisions).Methods("GET")
+	app.router.HandleFunc("/api/threads/{id}/events", app.GetThreadEvents).Methods("GET")
+	app.router.HandleFunc("/api/threads/{id}/reactions/{reaction}", app.AddReaction).Methods("PUT")
+	app.router.HandleFunc("/api/threads/{id}/reactions/{reaction}", app.RemoveReaction).Methods("DELETE")
+	app.router.HandleFunc("/api/threads/{id}/solution", app.AcceptSolution).Methods("PUT")
+	app.router.HandleFunc("/api/threads/{id}/solution", app.UnacceptSolution).Methods("DELETE")
+	app.router.HandleFunc("/api/threads/{id}/subscription", app.GetThreadSubscription).Methods("GET")
+	app.router.HandleFunc("/api/threads/{id}/subscription", app.Subscribe).Methods("PUT")
+	app.router.HandleFunc("/api/threads/{id}/subscription", app.Unsubscribe).Methods("DELETE")
+	app.router.HandleFunc("/api/threads/{id}/comments", app.GetThreadComments).Methods("GET")
 	app.router.HandleFunc("/api/threads/{id}/comments", app.CreateComment).Methods("POST")
+	app.router.HandleFunc("/api/threads/{id}/comments/{commentID}", app.UpdateComment).Methods("PUT")
+	app.router.HandleFunc("/api/threads/{id}/comments/{commentID}", app.DeleteComment).Methods("DELETE")
+	app.router.HandleFunc("/api/threads/{id}/comments/{commentID}/revisions", app.GetCommentRevisions).Methods("GET")
+	app.router.HandleFunc("/api/threads/{id}/comments/{commentID}/reactions/{reaction}", app.AddReaction).Methods("PUT")
+	app.router.HandleFunc("/api/threads/{id}/comments/{commentID}/reactions/{reaction}", app.RemoveReaction).Methods("DELETE")
 	app.router.HandleFunc("/api/categories", app.GetCategories).Methods("GET")
+	app.router.HandleFunc("/api/reactions", app.GetReactionTypes).Methods("GET")
+	app.router.HandleFunc("/api/search", app.Search).Methods("GET")
+	app.router.HandleFunc("/api/notifications", app.GetNotifications).Methods("GET")
+	app.router.HandleFunc("/api/notifications", app.MarkNotificationsRead).Methods("PATCH")
+	app.router.HandleFunc("/api/notifications/{id}", app.UpdateNotification).Methods("PATCH")
+	app.router.HandleFunc("/api/settings/email", app.GetEmailSettings).Methods("GET")
+	app.router.HandleFunc("/api/settings/email", app.UpdateEmailSettings).Methods("PUT")
+	app.router.HandleFunc("/api/settings/email", app.DeleteEmailSettings).Methods("DELETE")
+	app.router.HandleFunc("/api/settings/email/confirmation", app.ResendEmailConfirmation).Methods("POST")
+	app.router.HandleFunc("/api/settings/email/confirm", app.ConfirmEmailAddress).Methods("GET", "POST")
+	app.router.HandleFunc("/api/unsubscribe", app.UnsubscribeEmail).Methods("GET", "POST")
+	app.router.HandleFunc("/api/events", app.GetEvents).Methods("GET")
+	app.router.HandleFunc(blob.ProxyPath+"{key}", app.ServeUpload).Methods("GET")
+
+	// Auth Routes
+	app.router.HandleFunc("/api/auth/register", app.Register).Methods("POST")
+	app.router.HandleFunc("/api/auth/login", app.Login).Methods("POST")
+	app.router.HandleFunc("/api/auth/logout", app.Logout).Methods("POST")
+	app.router.HandleFunc("/api/auth/me", app.Me).Methods("GET")
+
+	// Moderation Routes
+	mod := app.router.PathPrefix("/api/mod").Subrouter()
+	mod.Use(RequireRole(RoleModerator))
+	mod.HandleFunc("/threads/{id}/{action:pin|unpin|lock|unlock|hide|unhide|approve|unapprove}", app.ModerateThread).Methods("POST")
+	mod.HandleFunc("/threads/{id}", app.ModDeleteThread).Methods("DELETE")
+	mod.HandleFunc("/comments/{id}/{action:hide|unhide}", app.ModerateComment).Methods("POST")
+	mod.HandleFunc("/comments/{id}", app.ModDeleteComment).Methods("DELETE")
+	mod.HandleFunc("/log", app.GetModerationLog).Methods("GET")
+	mod.Handle("/users/{id}/role", RequireRole(RoleAdmin)(http.HandlerFunc(app.SetUserRole))).Methods("PUT")
+	mod.Handle("/categories", RequireRole(RoleAdmin)(http.HandlerFunc(app.ListAdminCategories))).Methods("GET")
+	mod.Handle("/categories", RequireRole(RoleAdmin)(http.HandlerFunc(app.CreateCategory))).Methods("POST")
+	mod.Handle("/categories/{slug}", RequireRole(RoleAdmin)(http.HandlerFunc(app.UpdateCategory))).Methods("PUT")
+	mod.Handle("/categories/{slug}", RequireRole(RoleAdmin)(http.HandlerFunc(app.DeleteCategory))).Methods("DELETE")
+	mod.Handle("/translation-jobs", RequireRole(RoleAdmin)(http.HandlerFunc(app.ListTranslationJobs))).Methods("GET")
+	mod.Handle("/translation-jobs/retry", RequireRole(RoleAdmin)(http.HandlerFunc(app.RetryFailedTranslationJobs))).Methods("POST")
+	mod.Handle("/translation-jobs/{id}/retry", RequireRole(RoleAdmin)(http.HandlerFunc(app.RetryTranslationJob))).Methods("POST")
+
+	// Server-rendered UI Routes
+	if app.serverUI {
+		app.setupUIRoutes()
+	}
 }
 
 // Router returns the configured router
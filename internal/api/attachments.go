@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sqlcdb "pkoforum/db/sqlc"
+	"pkoforum/internal/media"
+)
+
+// Attachment is an image attached to a thread or comment
+type Attachment struct {
+	ID       string `json:"id"`
+	URL      string `json:"url"`
+	Caption  string `json:"caption,omitempty"`
+	Position int64  `json:"position"`
+	Width    int64  `json:"width,omitempty"`
+	Height   int64  `json:"height,omitempty"`
+	// Variants lists the available sizes smallest first, for building a srcset
+	Variants []ImageVariant `json:"variants,omitempty"`
+}
+
+// ImageVariant is one size of an attached image
+type ImageVariant struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Width  int64  `json:"width"`
+	Height int64  `json:"height"`
+}
+
+// imageVariants lists the resized variants of an image followed by the original.
+// Images stored before dimensions were recorded have no variants to list.
+func imageVariants(resized []ImageVariant, originalPath string, width, height int64) []ImageVariant {
+	if width == 0 || height == 0 {
+		return nil
+	}
+	return append(resized, ImageVariant{Name: media.VariantOriginal, URL: originalPath, Width: width, Height: height})
+}
+
+// attachment describes a freshly stored image once its row exists
+func (app *App) attachment(ctx context.Context, id string, img *storedImage) Attachment {
+	resized := make([]ImageVariant, 0, len(img.Variants))
+	for _, v := range img.Variants {
+		resized = append(resized, ImageVariant{Name: v.Name, URL: app.blobURL(ctx, v.Filename), Width: int64(v.Width), Height: int64(v.Height)})
+	}
+	url := app.blobURL(ctx, img.Filename)
+	return Attachment{
+		ID:       id,
+		URL:      url,
+		Caption:  img.Caption,
+		Position: int64(img.Position),
+		Width:    int64(img.Width),
+		Height:   int64(img.Height),
+		Variants: imageVariants(resized, url, int64(img.Width), int64(img.Height)),
+	}
+}
+
+// createCommentAttachments records stored images and their variants for a comment
+func (app *App) createCommentAttachments(ctx context.Context, qtx *sqlcdb.Queries, commentID string, images storedImages) ([]Attachment, error) {
+	attachments := make([]Attachment, 0, len(images))
+	for _, img := range images {
+		row, err := qtx.CreateCommentImage(ctx, sqlcdb.CreateCommentImageParams{
+			ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+			CommentID: commentID,
+			Filename:  img.Filename,
+			Filepath:  img.WebPath,
+			CreatedAt: time.Now(),
+			Width:     int64(img.Width),
+			Height:    int64(img.Height),
+			Position:  int64(img.Position),
+			Caption:   img.Caption,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("creating comment image %s: %w", img.Filename, err)
+		}
+
+		for _, variant := range img.Variants {
+			_, err := qtx.CreateCommentImageVariant(ctx, sqlcdb.CreateCommentImageVariantParams{
+				ID:       fmt.Sprintf("%d", time.Now().UnixNano()),
+				ImageID:  row.ID,
+				Variant:  variant.Name,
+				Filename: variant.Filename,
+				Filepath: variant.WebPath,
+				Width:    int64(variant.Width),
+				Height:   int64(variant.Height),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("creating %s variant of %s: %w", variant.Name, img.Filename, err)
+			}
+		}
+
+		attachments = append(attachments, app.attachment(ctx, row.ID, img))
+	}
+	return attachments, nil
+}
+
+// createThreadAttachments records stored images and their variants for a thread
+func (app *App) createThreadAttachments(ctx context.Context, qtx *sqlcdb.Queries, threadID string, images storedImages) ([]Attachment, error) {
+	attachments := make([]Attachment, 0, len(images))
+	for _, img := range images {
+		row, err := qtx.CreateThreadImage(ctx, sqlcdb.CreateThreadImageParams{
+			ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+			ThreadID:  threadID,
+			Filename:  img.Filename,
+			Filepath:  img.WebPath,
+			CreatedAt: time.Now(),
+			Width:     int64(img.Width),
+			Height:    int64(img.Height),
+			Position:  int64(img.Position),
+			Caption:   img.Caption,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("creating thread image %s: %w", img.Filename, err)
+		}
+
+		for _, variant := range img.Variants {
+			_, err := qtx.CreateThreadImageVariant(ctx, sqlcdb.CreateThreadImageVariantParams{
+				ID:       fmt.Sprintf("%d", time.Now().UnixNano()),
+				ImageID:  row.ID,
+				Variant:  variant.Name,
+				Filename: variant.Filename,
+				Filepath: variant.WebPath,
+				Width:    int64(variant.Width),
+				Height:   int64(variant.Height),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("creating %s variant of %s: %w", variant.Name, img.Filename, err)
+			}
+		}
+
+		attachments = append(attachments, app.attachment(ctx, row.ID, img))
+	}
+	return attachments, nil
+}
+
+// loadThreadAttachments returns the images attached to a thread in order
+func (app *App) loadThreadAttachments(ctx context.Context, threadID string) ([]Attachment, error) {
+	images, err := app.queries.ListThreadImages(ctx, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("listing thread images: %w", err)
+	}
+	if len(images) == 0 {
+		return nil, nil
+	}
+
+	variants, err := app.queries.ListThreadImageVariants(ctx, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("listing thread image variants: %w", err)
+	}
+	byImage := make(map[string][]ImageVariant)
+	for _, v := range variants {
+		byImage[v.ImageID] = append(byImage[v.ImageID], ImageVariant{Name: v.Variant, URL: app.blobURL(ctx, v.Filename), Width: v.Width, Height: v.Height})
+	}
+
+	attachments := make([]Attachment, 0, len(images))
+	for _, img := range images {
+		url := app.blobURL(ctx, img.Filename)
+		attachments = append(attachments, Attachment{
+			ID:       img.ID,
+			URL:      url,
+			Caption:  img.Caption,
+			Position: img.Position,
+			Width:    img.Width,
+			Height:   img.Height,
+			Variants: imageVariants(byImage[img.ID], url, img.Width, img.Height),
+		})
+	}
+	return attachments, nil
+}
+
+// loadCommentAttachments returns the images attached to a comment in order
+func (app *App) loadCommentAttachments(ctx context.Context, commentID string) ([]Attachment, error) {
+	images, err := app.queries.ListCommentImages(ctx, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("listing comment images: %w", err)
+	}
+	if len(images) == 0 {
+		return nil, nil
+	}
+
+	variants, err := app.queries.ListCommentImageVariants(ctx, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("listing comment image variants: %w", err)
+	}
+	byImage := make(map[string][]ImageVariant)
+	for _, v := range variants {
+		byImage[v.ImageID] = append(byImage[v.ImageID], ImageVariant{Name: v.Variant, URL: app.blobURL(ctx, v.Filename), Width: v.Width, Height: v.Height})
+	}
+
+	attachments := make([]Attachment, 0, len(images))
+	for _, img := range images {
+		url := app.blobURL(ctx, img.Filename)
+		attachments = append(attachments, Attachment{
+			ID:       img.ID,
+			URL:      url,
+			Caption:  img.Caption,
+			Position: img.Position,
+			Width:    img.Width,
+			Height:   img.Height,
+			Variants: imageVariants(byImage[img.ID], url, img.Width, img.Height),
+		})
+	}
+	return attachments, nil
+}
@@ -0,0 +1,298 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	sqlcdb "pkoforum/db/sqlc"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type userContextKey string
+
+const (
+	UserContextKey    userContextKey = "user"
+	SessionCookieName string         = "pkoforum_session"
+	minPasswordLength int            = 8
+	maxPasswordLength int            = 72 // bcrypt ignores anything longer
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,32}$`)
+
+type User struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type credentialsRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// AuthMiddleware resolves the session cookie and stores the authenticated user in the request context
+func (app *App) AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(SessionCookieName)
+		if err != nil || cookie.Value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := app.queries.GetSessionUser(r.Context(), sqlcdb.GetSessionUserParams{
+			ID:        hashSessionToken(cookie.Value),
+			ExpiresAt: time.Now(),
+		})
+		if err != nil {
+			if err != sql.ErrNoRows {
+				log.Error().Err(err).Msg("Error resolving session")
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), UserContextKey, user)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetCurrentUser gets the authenticated user from context
+func GetCurrentUser(ctx context.Context) (sqlcdb.User, bool) {
+	user, ok := ctx.Value(UserContextKey).(sqlcdb.User)
+	return user, ok
+}
+
+// currentUserID returns the authenticated user ID as a nullable column value
+func currentUserID(ctx context.Context) sql.NullString {
+	if user, ok := GetCurrentUser(ctx); ok {
+		return sql.NullString{String: user.ID, Valid: true}
+	}
+	return sql.NullString{}
+}
+
+// Register handles the POST /api/auth/register endpoint
+func (app *App) Register(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req credentialsRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error().Err(err).Msg("Error decoding request body")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := app.registerUser(ctx, req.Username, req.Password)
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	if err := app.startSession(w, r, user.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Info().Str("user_id", user.ID).Str("username", user.Username).Msg("User registered")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toUser(user))
+}
+
+// Login handles the POST /api/auth/login endpoint
+func (app *App) Login(w http.ResponseWriter, r *http.Request) {
+	var req credentialsRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error().Err(err).Msg("Error decoding request body")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := app.authenticate(r.Context(), req.Username, req.Password)
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	if err := app.startSession(w, r, user.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Info().Str("user_id", user.ID).Msg("User logged in")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toUser(user))
+}
+
+// Logout handles the POST /api/auth/logout endpoint
+func (app *App) Logout(w http.ResponseWriter, r *http.Request) {
+	if err := app.endSession(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// registerUser validates new credentials and creates the account
+func (app *App) registerUser(ctx context.Context, username, password string) (sqlcdb.User, error) {
+	username = strings.TrimSpace(username)
+	if !usernamePattern.MatchString(username) {
+		return sqlcdb.User{}, &requestError{status: http.StatusBadRequest, message: "Username must be 3-32 characters of letters, digits, '_' or '-'"}
+	}
+
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return sqlcdb.User{}, &requestError{status: http.StatusBadRequest, message: fmt.Sprintf("Password must be %d-%d characters", minPasswordLength, maxPasswordLength)}
+	}
+
+	if _, err := app.queries.GetUserByUsername(ctx, username); err == nil {
+		return sqlcdb.User{}, &requestError{status: http.StatusConflict, message: "Username is already taken"}
+	} else if err != sql.ErrNoRows {
+		log.Error().Err(err).Str("username", username).Msg("Error looking up user")
+		return sqlcdb.User{}, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Error().Err(err).Msg("Error hashing password")
+		return sqlcdb.User{}, err
+	}
+
+	user, err := app.queries.CreateUser(ctx, sqlcdb.CreateUserParams{
+		ID:           fmt.Sprintf("%d", time.Now().UnixNano()),
+		Username:     username,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("username", username).Msg("Error creating user")
+		return sqlcdb.User{}, err
+	}
+	return user, nil
+}
+
+// authenticate checks a username and password
+func (app *App) authenticate(ctx context.Context, username, password string) (sqlcdb.User, error) {
+	invalid := &requestError{status: http.StatusUnauthorized, message: "Invalid username or password"}
+
+	user, err := app.queries.GetUserByUsername(ctx, strings.TrimSpace(username))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Error().Err(err).Str("username", username).Msg("Error looking up user")
+			return sqlcdb.User{}, err
+		}
+		return sqlcdb.User{}, invalid
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		log.Debug().Str("username", user.Username).Msg("Invalid password")
+		return sqlcdb.User{}, invalid
+	}
+	return user, nil
+}
+
+// endSession deletes the current session and clears the session cookie
+func (app *App) endSession(w http.ResponseWriter, r *http.Request) error {
+	if cookie, err := r.Cookie(SessionCookieName); err == nil && cookie.Value != "" {
+		if err := app.queries.DeleteSession(r.Context(), hashSessionToken(cookie.Value)); err != nil {
+			log.Error().Err(err).Msg("Error deleting session")
+			return err
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// Me handles the GET /api/auth/me endpoint
+func (app *App) Me(w http.ResponseWriter, r *http.Request) {
+	user, ok := GetCurrentUser(r.Context())
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toUser(user))
+}
+
+// startSession creates a session for the user and sets the session cookie
+func (app *App) startSession(w http.ResponseWriter, r *http.Request, userID string) error {
+	ctx := r.Context()
+	now := time.Now()
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		log.Error().Err(err).Msg("Error generating session token")
+		return err
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	session, err := app.queries.CreateSession(ctx, sqlcdb.CreateSessionParams{
+		ID:        hashSessionToken(token),
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(app.sessionTTL),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Error creating session")
+		return err
+	}
+
+	// Opportunistically clean up sessions that can no longer be used
+	if err := app.queries.DeleteExpiredSessions(ctx, now); err != nil {
+		log.Warn().Err(err).Msg("Error deleting expired sessions")
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// hashSessionToken derives the stored session ID so raw tokens never touch the database
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// isSecureRequest reports whether the request reached us over HTTPS
+func isSecureRequest(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+func toUser(u sqlcdb.User) User {
+	return User{
+		ID:        u.ID,
+		Username:  u.Username,
+		Role:      u.Role,
+		CreatedAt: u.CreatedAt,
+	}
+}
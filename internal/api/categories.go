@@ -0,0 +1,527 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	sqlcdb "pkoforum/db/sqlc"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// PostingRoleAnyone lets guests as well as every user post in a category
+	PostingRoleAnyone string = "anyone"
+
+	maxCategoryIconLength  = 64
+	maxCategoryLabelLength = 100
+)
+
+var categorySlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// Category is a category with all of its translations, as managed by admins
+type Category struct {
+	Slug         string            `json:"slug"`
+	Labels       map[string]string `json:"labels"`
+	Descriptions map[string]string `json:"descriptions,omitempty"`
+	SortOrder    int64             `json:"sort_order"`
+	Icon         string            `json:"icon,omitempty"`
+	CategoryPermissions
+}
+
+// CategoryPermissions are the posting rules of a category. Roles are the
+// minimum role needed, or PostingRoleAnyone. SolutionsEnabled lets thread
+// authors mark a comment as the accepted answer. ReadOnly is derived from
+// ThreadRole and reports that only moderators may start threads; in requests
+// without a ThreadRole it stands for a moderator ThreadRole.
+type CategoryPermissions struct {
+	ThreadRole       string `json:"thread_role"`
+	CommentRole      string `json:"comment_role"`
+	RequiresApproval bool   `json:"requires_approval"`
+	SolutionsEnabled bool   `json:"solutions_enabled"`
+	ReadOnly         bool   `json:"read_only"`
+}
+
+// categoryPermissions builds the posting rules of a category
+func categoryPermissions(threadRole, commentRole string, requiresApproval, solutionsEnabled bool) CategoryPermissions {
+	return CategoryPermissions{
+		ThreadRole:       threadRole,
+		CommentRole:      commentRole,
+		RequiresApproval: requiresApproval,
+		SolutionsEnabled: solutionsEnabled,
+		ReadOnly:         readOnlyRole(threadRole),
+	}
+}
+
+// readOnlyRole reports whether a thread role keeps everyone but moderators
+// from starting threads
+func readOnlyRole(threadRole string) bool {
+	return roleRank[threadRole] >= roleRank[RoleModerator]
+}
+
+// LocalizedCategory is a category in the request language with its activity
+type LocalizedCategory struct {
+	Value          string     `json:"value"`
+	Label          string     `json:"label"`
+	Description    string     `json:"description,omitempty"`
+	Icon           string     `json:"icon,omitempty"`
+	ThreadCount    int64      `json:"thread_count"`
+	LastActivityAt *time.Time `json:"last_activity_at,omitempty"`
+	CategoryPermissions
+	// CanCreateThread and CanComment apply the rules to the current user
+	CanCreateThread bool `json:"can_create_thread"`
+	CanComment      bool `json:"can_comment"`
+}
+
+type categoryRequest struct {
+	Slug         string            `json:"slug"`
+	Labels       map[string]string `json:"labels"`
+	Descriptions map[string]string `json:"descriptions"`
+	SortOrder    int64             `json:"sort_order"`
+	Icon         string            `json:"icon"`
+	CategoryPermissions
+}
+
+// categoryTranslations groups category labels and descriptions by slug and language
+type categoryTranslations struct {
+	labels       map[string]map[string]string
+	descriptions map[string]map[string]string
+}
+
+// ValidatePostingRole checks if a role can be required for posting in a category
+func ValidatePostingRole(role string) bool {
+	return role == PostingRoleAnyone || ValidateRole(role)
+}
+
+// CanPost reports whether the current user meets a category's posting role
+func CanPost(ctx context.Context, role string) bool {
+	return role == PostingRoleAnyone || HasRole(ctx, role)
+}
+
+// postingDenied rejects a post the current user is not allowed to make,
+// asking guests to log in first
+func postingDenied(ctx context.Context, message string) error {
+	if _, ok := GetCurrentUser(ctx); !ok {
+		return &requestError{status: http.StatusUnauthorized, message: "Not authenticated"}
+	}
+	return &requestError{status: http.StatusForbidden, message: message}
+}
+
+// getCategory loads a category by slug, returning errNotFound for unknown slugs
+func (app *App) getCategory(ctx context.Context, slug string) (sqlcdb.Category, error) {
+	category, err := app.queries.GetCategory(ctx, slug)
+	if err == sql.ErrNoRows {
+		return category, errNotFound
+	}
+	return category, err
+}
+
+// loadCategoryTranslations returns the labels and descriptions of every category
+func (app *App) loadCategoryTranslations(ctx context.Context) (categoryTranslations, error) {
+	rows, err := app.queries.ListCategoryTranslations(ctx)
+	if err != nil {
+		return categoryTranslations{}, fmt.Errorf("listing category translations: %w", err)
+	}
+
+	t := categoryTranslations{
+		labels:       make(map[string]map[string]string),
+		descriptions: make(map[string]map[string]string),
+	}
+	for _, row := range rows {
+		if t.labels[row.CategorySlug] == nil {
+			t.labels[row.CategorySlug] = make(map[string]string)
+		}
+		t.labels[row.CategorySlug][row.Language] = row.Label
+		if row.Description != "" {
+			if t.descriptions[row.CategorySlug] == nil {
+				t.descriptions[row.CategorySlug] = make(map[string]string)
+			}
+			t.descriptions[row.CategorySlug][row.Language] = row.Description
+		}
+	}
+	return t, nil
+}
+
+// GetCategories handles the GET /api/categories endpoint
+func (app *App) GetCategories(w http.ResponseWriter, r *http.Request) {
+	localizedCategories, err := app.listCategories(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Error listing categories")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(localizedCategories)
+}
+
+// listCategories returns every category localized for the request language,
+// with whether the current user may post in it
+func (app *App) listCategories(ctx context.Context) ([]LocalizedCategory, error) {
+	lang := GetLanguage(ctx)
+
+	rows, err := app.queries.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	translations, err := app.loadCategoryTranslations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading category translations: %w", err)
+	}
+
+	localizedCategories := make([]LocalizedCategory, 0, len(rows))
+	for _, row := range rows {
+		label := GetLocalizedContent(translations.labels[row.Slug], lang, app.defaultLang, DefaultLang)
+		if label == "" {
+			label = row.Slug
+		}
+
+		localizedCategories = append(localizedCategories, LocalizedCategory{
+			Value:               row.Slug,
+			Label:               label,
+			Description:         GetLocalizedContent(translations.descriptions[row.Slug], lang, app.defaultLang, DefaultLang),
+			Icon:                row.Icon,
+			ThreadCount:         row.ThreadCount,
+			LastActivityAt:      nullTime(row.LastActivityAt),
+			CategoryPermissions: categoryPermissions(row.ThreadRole, row.CommentRole, row.RequiresApproval, row.SolutionsEnabled),
+			CanCreateThread:     CanPost(ctx, row.ThreadRole),
+			CanComment:          CanPost(ctx, row.CommentRole),
+		})
+	}
+	return localizedCategories, nil
+}
+
+// ListAdminCategories handles the GET /api/mod/categories endpoint, returning
+// every category with all of its translations
+func (app *App) ListAdminCategories(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rows, err := app.queries.ListCategories(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Error listing categories")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	translations, err := app.loadCategoryTranslations(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Error loading category translations")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	categories := make([]Category, 0, len(rows))
+	for _, row := range rows {
+		categories = append(categories, Category{
+			Slug:                row.Slug,
+			Labels:              translations.labels[row.Slug],
+			Descriptions:        translations.descriptions[row.Slug],
+			SortOrder:           row.SortOrder,
+			Icon:                row.Icon,
+			CategoryPermissions: categoryPermissions(row.ThreadRole, row.CommentRole, row.RequiresApproval, row.SolutionsEnabled),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(categories)
+}
+
+// validate checks a category request; the slug is only checked when creating
+func (req *categoryRequest) validate(defaultLang string, checkSlug bool) error {
+	if checkSlug && !categorySlugPattern.MatchString(req.Slug) {
+		return errors.New("Slug must be lowercase letters, digits and dashes, at most 50 characters")
+	}
+	if strings.TrimSpace(req.Labels[defaultLang]) == "" {
+		return fmt.Errorf("A label in the default language %q is required", defaultLang)
+	}
+	for lang, label := range req.Labels {
+		if lang == "" || len(lang) > 10 || strings.ToLower(lang) != lang {
+			return fmt.Errorf("Invalid label language %q", lang)
+		}
+		if strings.TrimSpace(label) == "" || utf8.RuneCountInString(label) > maxCategoryLabelLength {
+			return fmt.Errorf("Labels must be 1 to %d characters", maxCategoryLabelLength)
+		}
+	}
+	for lang := range req.Descriptions {
+		if _, ok := req.Labels[lang]; !ok {
+			return fmt.Errorf("Description in %q has no matching label", lang)
+		}
+	}
+	if utf8.RuneCountInString(req.Icon) > maxCategoryIconLength {
+		return fmt.Errorf("Icon must be at most %d characters", maxCategoryIconLength)
+	}
+	if req.ThreadRole == "" {
+		req.ThreadRole = PostingRoleAnyone
+		if req.ReadOnly {
+			req.ThreadRole = RoleModerator
+		}
+	}
+	if req.CommentRole == "" {
+		req.CommentRole = PostingRoleAnyone
+	}
+	if !ValidatePostingRole(req.ThreadRole) || !ValidatePostingRole(req.CommentRole) {
+		return fmt.Errorf("Posting roles must be %q or a user role", PostingRoleAnyone)
+	}
+	if req.ReadOnly && !readOnlyRole(req.ThreadRole) {
+		return fmt.Errorf("Read-only categories need a %q or %q thread role", RoleModerator, RoleAdmin)
+	}
+	req.ReadOnly = readOnlyRole(req.ThreadRole)
+	return nil
+}
+
+// writeCategoryTranslations replaces the labels and descriptions of a category
+func writeCategoryTranslations(ctx context.Context, qtx *sqlcdb.Queries, slug string, req categoryRequest) error {
+	if err := qtx.DeleteCategoryTranslations(ctx, slug); err != nil {
+		return fmt.Errorf("deleting category translations: %w", err)
+	}
+	for _, lang := range slices.Sorted(maps.Keys(req.Labels)) {
+		if _, err := qtx.CreateCategoryTranslation(ctx, sqlcdb.CreateCategoryTranslationParams{
+			CategorySlug: slug,
+			Language:     lang,
+			Label:        strings.TrimSpace(req.Labels[lang]),
+			Description:  strings.TrimSpace(req.Descriptions[lang]),
+		}); err != nil {
+			return fmt.Errorf("creating %s category translation: %w", lang, err)
+		}
+	}
+	return nil
+}
+
+// CreateCategory handles the POST /api/mod/categories endpoint
+func (app *App) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req categoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error().Err(err).Msg("Error decoding request body")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(app.defaultLang, true); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	if _, err := qtx.GetCategory(ctx, req.Slug); err == nil {
+		http.Error(w, "Category already exists", http.StatusConflict)
+		return
+	} else if err != sql.ErrNoRows {
+		log.Error().Err(err).Str("slug", req.Slug).Msg("Error getting category")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	category, err := qtx.CreateCategory(ctx, sqlcdb.CreateCategoryParams{
+		Slug:      req.Slug,
+		SortOrder: req.SortOrder,
+		Icon:      req.Icon,
+
+		ThreadRole:       req.ThreadRole,
+		CommentRole:      req.CommentRole,
+		RequiresApproval: req.RequiresApproval,
+		SolutionsEnabled: req.SolutionsEnabled,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("slug", req.Slug).Msg("Error creating category")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeCategoryTranslations(ctx, qtx, category.Slug, req); err != nil {
+		log.Error().Err(err).Str("slug", category.Slug).Msg("Error writing category translations")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := logModeration(ctx, qtx, "create_category", "category", category.Slug, ""); err != nil {
+		log.Error().Err(err).Str("slug", category.Slug).Msg("Error writing moderation log")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("slug", category.Slug).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Info().Str("slug", category.Slug).Msg("Category created")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(Category{
+		Slug:                category.Slug,
+		Labels:              req.Labels,
+		Descriptions:        req.Descriptions,
+		SortOrder:           category.SortOrder,
+		Icon:                category.Icon,
+		CategoryPermissions: categoryPermissions(category.ThreadRole, category.CommentRole, category.RequiresApproval, category.SolutionsEnabled),
+	})
+}
+
+// UpdateCategory handles the PUT /api/mod/categories/{slug} endpoint. The
+// request replaces every translation of the category.
+func (app *App) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	slug := mux.Vars(r)["slug"]
+
+	var req categoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error().Err(err).Msg("Error decoding request body")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(app.defaultLang, false); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	affected, err := qtx.UpdateCategory(ctx, sqlcdb.UpdateCategoryParams{
+		SortOrder: req.SortOrder,
+		Icon:      req.Icon,
+
+		ThreadRole:       req.ThreadRole,
+		CommentRole:      req.CommentRole,
+		RequiresApproval: req.RequiresApproval,
+		SolutionsEnabled: req.SolutionsEnabled,
+
+		Slug: slug,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("slug", slug).Msg("Error updating category")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if affected == 0 {
+		http.Error(w, "Category not found", http.StatusNotFound)
+		return
+	}
+
+	if err := writeCategoryTranslations(ctx, qtx, slug, req); err != nil {
+		log.Error().Err(err).Str("slug", slug).Msg("Error writing category translations")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := logModeration(ctx, qtx, "update_category", "category", slug, ""); err != nil {
+		log.Error().Err(err).Str("slug", slug).Msg("Error writing moderation log")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("slug", slug).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Info().Str("slug", slug).Msg("Category updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Category{
+		Slug:         slug,
+		Labels:       req.Labels,
+		Descriptions: req.Descriptions,
+		SortOrder:    req.SortOrder,
+		Icon:         req.Icon,
+
+		CategoryPermissions: req.CategoryPermissions,
+	})
+}
+
+// DeleteCategory handles the DELETE /api/mod/categories/{slug} endpoint.
+// Categories that still hold threads cannot be deleted.
+func (app *App) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	slug := mux.Vars(r)["slug"]
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	if _, err := qtx.GetCategory(ctx, slug); err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Category not found", http.StatusNotFound)
+			return
+		}
+		log.Error().Err(err).Str("slug", slug).Msg("Error getting category")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := qtx.DeleteCategoryTranslations(ctx, slug); err != nil {
+		log.Error().Err(err).Str("slug", slug).Msg("Error deleting category translations")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := qtx.DeleteCategorySubscribers(ctx, slug); err != nil {
+		log.Error().Err(err).Str("slug", slug).Msg("Error deleting category subscriptions")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	affected, err := qtx.DeleteUnusedCategory(ctx, slug)
+	if err != nil {
+		log.Error().Err(err).Str("slug", slug).Msg("Error deleting category")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if affected == 0 {
+		http.Error(w, "Category still has threads", http.StatusConflict)
+		return
+	}
+
+	if err := logModeration(ctx, qtx, "delete_category", "category", slug, ""); err != nil {
+		log.Error().Err(err).Str("slug", slug).Msg("Error writing moderation log")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("slug", slug).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Info().Str("slug", slug).Msg("Category deleted")
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// datastarRequestHeader is sent by Datastar with every request it makes
+const datastarRequestHeader = "Datastar-Request"
+
+// isDatastarRequest reports whether r was made by Datastar. Browsers only send
+// the header from page scripts after a CORS preflight, which keeps other sites
+// from posting forms to the UI endpoints.
+func isDatastarRequest(r *http.Request) bool {
+	return r.Header.Get(datastarRequestHeader) == "true"
+}
+
+// datastarSSE writes Datastar events to a server-sent events response
+type datastarSSE struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// newDatastarSSE starts an event stream on w. It reports false, after writing
+// an error response, when w cannot stream.
+func newDatastarSSE(w http.ResponseWriter) (*datastarSSE, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return nil, false
+	}
+	startEventStream(w)
+	flusher.Flush()
+	return &datastarSSE{w: w, flusher: flusher}, true
+}
+
+// send writes one event whose data lines are prefixed with their field name.
+// Fields span one data line per line of their value.
+func (sse *datastarSSE) send(event string, fields ...[2]string) {
+	var b strings.Builder
+	b.WriteString("event: " + event + "\n")
+	for _, field := range fields {
+		// A lone carriage return also ends a line in the event stream format
+		value := strings.ReplaceAll(field[1], "\r\n", "\n")
+		value = strings.ReplaceAll(value, "\r", "\n")
+		for _, line := range strings.Split(value, "\n") {
+			b.WriteString("data: " + field[0] + " " + line + "\n")
+		}
+	}
+	b.WriteString("\n")
+
+	fmt.Fprint(sse.w, b.String())
+	sse.flusher.Flush()
+}
+
+// mergeFragments morphs the given HTML into the page, matching elements by ID
+func (sse *datastarSSE) mergeFragments(html string) {
+	sse.send("datastar-merge-fragments", [2]string{"fragments", html})
+}
+
+// executeScript runs script in the page
+func (sse *datastarSSE) executeScript(script string) {
+	sse.send("datastar-execute-script", [2]string{"script", script})
+}
+
+// redirect sends the browser to url
+func (sse *datastarSSE) redirect(url string) {
+	target, _ := json.Marshal(url)
+	sse.executeScript("window.location.assign(" + string(target) + ")")
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	sqlcdb "pkoforum/db/sqlc"
+	"pkoforum/internal/mailer"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// digestCheckInterval is how often the digest worker looks for users due one
+	digestCheckInterval = 15 * time.Minute
+
+	// digestMaxThreads caps the threads listed in one digest
+	digestMaxThreads int64 = 50
+)
+
+// digestPeriods maps digest frequencies to the time between two digests
+var digestPeriods = map[string]time.Duration{
+	DigestDaily:  24 * time.Hour,
+	DigestWeekly: 7 * 24 * time.Hour,
+}
+
+// digestWorker tracks the background loop that sends digests
+type digestWorker struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// StartDigests launches the worker sending daily and weekly digests. It does
+// nothing when e-mail is disabled.
+func (app *App) StartDigests() {
+	if app.mailer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app.digests.cancel = cancel
+	app.digests.wg.Add(1)
+
+	go func() {
+		defer app.digests.wg.Done()
+
+		ticker := time.NewTicker(digestCheckInterval)
+		defer ticker.Stop()
+		for {
+			app.sendDueDigests(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Info().Msg("Digest worker started")
+}
+
+// StopDigests stops the digest worker and waits for the digest being sent, if any
+func (app *App) StopDigests() {
+	if app.digests.cancel == nil {
+		return
+	}
+	app.digests.cancel()
+	app.digests.wg.Wait()
+}
+
+// sendDueDigests sends a digest to every user whose last one is a period old
+func (app *App) sendDueDigests(ctx context.Context) {
+	now := time.Now()
+	due, err := app.queries.ListDueDigests(ctx, sqlcdb.ListDueDigestsParams{
+		DailyBefore:  now.Add(-digestPeriods[DigestDaily]),
+		WeeklyBefore: now.Add(-digestPeriods[DigestWeekly]),
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Msg("Error listing due digests")
+		}
+		return
+	}
+
+	for _, settings := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		// A failed digest is retried on the next check
+		if err := app.sendDigest(ctx, settings, now); err != nil {
+			log.Error().Err(err).Str("user_id", settings.UserID).Msg("Error sending digest")
+		}
+	}
+}
+
+// sendDigest e-mails a user the threads created in their followed categories
+// since their last digest. Nothing is sent when there are none.
+func (app *App) sendDigest(ctx context.Context, settings sqlcdb.EmailSetting, now time.Time) error {
+	threads, err := app.queries.ListDigestThreads(ctx, sqlcdb.ListDigestThreadsParams{
+		UserID:   settings.UserID,
+		Language: settings.Language,
+		Since:    settings.LastDigestAt,
+		Limit:    digestMaxThreads,
+	})
+	if err != nil {
+		return fmt.Errorf("listing digest threads: %w", err)
+	}
+
+	if len(threads) > 0 {
+		data := mailer.DigestData{
+			Frequency:      settings.Digest,
+			UnsubscribeURL: app.unsubscribeURL(settings.UnsubscribeToken, MailListDigest),
+		}
+		// Threads come grouped by category
+		var category string
+		for _, thread := range threads {
+			if len(data.Categories) == 0 || thread.Category != category {
+				category = thread.Category
+				data.Categories = append(data.Categories, mailer.DigestCategory{Label: thread.CategoryLabel})
+			}
+			title := thread.Title
+			if thread.TranslatedTitle.Valid {
+				title = thread.TranslatedTitle.String
+			}
+			group := &data.Categories[len(data.Categories)-1]
+			group.Threads = append(group.Threads, mailer.DigestThread{
+				Title:      title,
+				AuthorName: thread.AuthorName.String,
+				URL:        app.threadURL(thread.ID),
+				CreatedAt:  thread.CreatedAt,
+			})
+		}
+
+		msg, err := mailer.Render(mailer.TemplateDigest, settings.Language, data)
+		if err != nil {
+			return err
+		}
+		if err := app.sendMail(ctx, settings, MailListDigest, msg); err != nil {
+			return err
+		}
+		log.Info().Str("user_id", settings.UserID).Int("threads", len(threads)).Msg("Digest sent")
+	}
+
+	if err := app.queries.MarkDigestSent(ctx, sqlcdb.MarkDigestSentParams{
+		LastDigestAt: now,
+		UserID:       settings.UserID,
+	}); err != nil {
+		return fmt.Errorf("marking digest sent: %w", err)
+	}
+	return nil
+}
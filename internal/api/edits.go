@@ -0,0 +1,667 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	sqlcdb "pkoforum/db/sqlc"
+	"pkoforum/internal/langdetect"
+	"pkoforum/internal/markdown"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	RevisionTargetThread  string = "thread"
+	RevisionTargetComment string = "comment"
+)
+
+// Errors returned when a post may not be changed by the current user
+var (
+	errNotAuthor    = errors.New("only the author or a moderator may change this post")
+	errThreadLocked = errors.New("thread is locked")
+)
+
+// Revision is a prior version of an edited thread or comment
+type Revision struct {
+	ID         string    `json:"id"`
+	Title      string    `json:"title,omitempty"`
+	Content    string    `json:"content"`
+	Language   string    `json:"language"`
+	EditedBy   string    `json:"edited_by,omitempty"`
+	EditorName string    `json:"editor_name,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type editRequest struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Lang    string `json:"lang"`
+}
+
+// isAuthor reports whether the authenticated user wrote the post
+func isAuthor(ctx context.Context, authorID sql.NullString) bool {
+	user, ok := GetCurrentUser(ctx)
+	return ok && authorID.Valid && authorID.String == user.ID
+}
+
+// nullTime converts a nullable timestamp into an optional JSON field
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// writableThread loads a thread the current user may post changes to. Hidden
+// threads, and pending threads of other users, are missing and locked threads
+// read-only for non-moderators.
+func writableThread(ctx context.Context, qtx *sqlcdb.Queries, threadID string) (sqlcdb.GetThreadRow, error) {
+	thread, err := qtx.GetThread(ctx, sqlcdb.GetThreadParams{
+		Language: GetLanguage(ctx),
+		ID:       threadID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return thread, errNotFound
+		}
+		return thread, err
+	}
+	if IsModerator(ctx) {
+		return thread, nil
+	}
+	if thread.Hidden || (!thread.Approved && !isAuthor(ctx, thread.AuthorID)) {
+		return thread, errNotFound
+	}
+	if thread.Locked {
+		return thread, errThreadLocked
+	}
+	return thread, nil
+}
+
+// editableThread loads a thread the current user may edit or delete
+func editableThread(ctx context.Context, qtx *sqlcdb.Queries, threadID string) (sqlcdb.GetThreadRow, error) {
+	thread, err := writableThread(ctx, qtx, threadID)
+	if err != nil {
+		return thread, err
+	}
+	if !IsModerator(ctx) && !isAuthor(ctx, thread.AuthorID) {
+		return thread, errNotAuthor
+	}
+	return thread, nil
+}
+
+// editableComment loads a comment of the given thread the current user may edit or delete
+func editableComment(ctx context.Context, qtx *sqlcdb.Queries, threadID, commentID string) (sqlcdb.Comment, error) {
+	if _, err := writableThread(ctx, qtx, threadID); err != nil {
+		return sqlcdb.Comment{}, err
+	}
+	comment, err := qtx.GetComment(ctx, commentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return comment, errNotFound
+		}
+		return comment, err
+	}
+	if comment.ThreadID != threadID || (comment.Hidden && !IsModerator(ctx)) {
+		return comment, errNotFound
+	}
+	if !IsModerator(ctx) && !isAuthor(ctx, comment.AuthorID) {
+		return comment, errNotAuthor
+	}
+	return comment, nil
+}
+
+// writeEditError maps the errors of the editable* helpers onto responses
+func writeEditError(w http.ResponseWriter, err error, notFound string) {
+	switch {
+	case errors.Is(err, errNotFound):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, errThreadLocked):
+		http.Error(w, "Thread is locked", http.StatusForbidden)
+	case errors.Is(err, errNotAuthor):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		log.Error().Err(err).Msg("Error loading post")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// decodeEditRequest reads and validates the body of an edit
+func decodeEditRequest(r *http.Request, requireTitle bool) (editRequest, error) {
+	var req editRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, err
+	}
+	if requireTitle && strings.TrimSpace(req.Title) == "" {
+		return req, errors.New("Title is required")
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		return req, errors.New("Content is required")
+	}
+	return req, nil
+}
+
+// retranslateThread replaces a thread's translations with its new original text
+// and queues translations into the other languages
+func (app *App) retranslateThread(ctx context.Context, qtx *sqlcdb.Queries, threadID, title, content, lang string, detection langdetect.Result) error {
+	if err := qtx.DeleteTranslationJobs(ctx, sqlcdb.DeleteTranslationJobsParams{
+		TargetType: JobTargetThread,
+		TargetID:   threadID,
+	}); err != nil {
+		return fmt.Errorf("deleting thread translation jobs: %w", err)
+	}
+	if err := qtx.DeleteThreadTranslations(ctx, threadID); err != nil {
+		return fmt.Errorf("deleting thread translations: %w", err)
+	}
+	if _, err := qtx.CreateThreadTranslation(ctx, sqlcdb.CreateThreadTranslationParams{
+		ID:       fmt.Sprintf("%d", time.Now().UnixNano()),
+		ThreadID: threadID,
+		Language: lang,
+		Title:    title,
+		Content:  content,
+
+		DetectedLanguage:    detection.Language,
+		DetectionConfidence: detection.Confidence,
+	}); err != nil {
+		return fmt.Errorf("creating thread translation: %w", err)
+	}
+	for _, targetLang := range app.targetLanguages(lang) {
+		if err := enqueueTranslation(ctx, qtx, JobTargetThread, threadID, lang, targetLang); err != nil {
+			return fmt.Errorf("enqueueing thread translation: %w", err)
+		}
+	}
+	return nil
+}
+
+// retranslateComment replaces a comment's translations with its new original
+// text and queues translations into the other languages
+func (app *App) retranslateComment(ctx context.Context, qtx *sqlcdb.Queries, commentID, content, lang string, detection langdetect.Result) error {
+	if err := qtx.DeleteTranslationJobs(ctx, sqlcdb.DeleteTranslationJobsParams{
+		TargetType: JobTargetComment,
+		TargetID:   commentID,
+	}); err != nil {
+		return fmt.Errorf("deleting comment translation jobs: %w", err)
+	}
+	if err := qtx.DeleteCommentTranslations(ctx, commentID); err != nil {
+		return fmt.Errorf("deleting comment translations: %w", err)
+	}
+	if _, err := qtx.CreateCommentTranslation(ctx, sqlcdb.CreateCommentTranslationParams{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		CommentID: commentID,
+		Language:  lang,
+		Content:   content,
+
+		DetectedLanguage:    detection.Language,
+		DetectionConfidence: detection.Confidence,
+	}); err != nil {
+		return fmt.Errorf("creating comment translation: %w", err)
+	}
+	for _, targetLang := range app.targetLanguages(lang) {
+		if err := enqueueTranslation(ctx, qtx, JobTargetComment, commentID, lang, targetLang); err != nil {
+			return fmt.Errorf("enqueueing comment translation: %w", err)
+		}
+	}
+	return nil
+}
+
+// UpdateThread handles the PUT /api/threads/{id} endpoint. The previous version
+// is kept as a revision and the new text is translated again.
+func (app *App) UpdateThread(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	threadID := mux.Vars(r)["id"]
+
+	if _, ok := GetCurrentUser(ctx); !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	req, err := decodeEditRequest(r, true)
+	if err != nil {
+		log.Debug().Err(err).Msg("Invalid thread edit")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	thread, err := editableThread(ctx, qtx, threadID)
+	if err != nil {
+		writeEditError(w, err, "Thread not found")
+		return
+	}
+
+	originalLang, detection := app.detectSourceLanguage(ctx, req.Lang, req.Title+"\n"+req.Content)
+	now := time.Now()
+
+	if _, err := qtx.CreateRevision(ctx, sqlcdb.CreateRevisionParams{
+		ID:         fmt.Sprintf("%d", now.UnixNano()),
+		TargetType: RevisionTargetThread,
+		TargetID:   threadID,
+		Title:      thread.Title,
+		Content:    thread.Content,
+		Language:   thread.Language,
+		EditedBy:   currentUserID(ctx),
+		CreatedAt:  now,
+	}); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error saving thread revision")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := qtx.UpdateThread(ctx, sqlcdb.UpdateThreadParams{
+		Title:    req.Title,
+		Content:  req.Content,
+		Language: originalLang,
+		EditedAt: sql.NullTime{Time: now, Valid: true},
+		ID:       threadID,
+	}); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error updating thread")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := app.retranslateThread(ctx, qtx, threadID, req.Title, req.Content, originalLang, detection); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error retranslating thread")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !isAuthor(ctx, thread.AuthorID) {
+		if err := logModeration(ctx, qtx, "edit", "thread", threadID, ""); err != nil {
+			log.Error().Err(err).Str("thread_id", threadID).Msg("Error writing moderation log")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.notifyTranslationWorkers()
+	app.publishThreadUpdate(threadID, "", "edit")
+
+	log.Info().
+		Str("thread_id", threadID).
+		Str("language", originalLang).
+		Float64("detection_confidence", detection.Confidence).
+		Msg("Thread edited")
+
+	updated, err := app.getVisibleThread(ctx, threadID)
+	if err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error getting thread")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	attachments, err := app.loadThreadAttachments(ctx, threadID)
+	if err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error getting thread attachments")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	content := localizedText(updated.Content, updated.TranslatedContent)
+	json.NewEncoder(w).Encode(LocalizedThread{
+		ID:          updated.ID,
+		Title:       localizedText(updated.Title, updated.TranslatedTitle),
+		Content:     content,
+		ContentHTML: markdown.Render(content),
+		Category:    updated.Category,
+		AuthorID:    updated.AuthorID.String,
+		AuthorName:  updated.AuthorName.String,
+		Pinned:      updated.Pinned,
+		Locked:      updated.Locked,
+		Hidden:      updated.Hidden,
+		Pending:     !updated.Approved,
+		CreatedAt:   updated.CreatedAt,
+		EditedAt:    nullTime(updated.EditedAt),
+		Attachments: attachments,
+		Language:    GetLanguage(ctx),
+	})
+}
+
+// DeleteThread handles the DELETE /api/threads/{id} endpoint
+func (app *App) DeleteThread(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	threadID := mux.Vars(r)["id"]
+
+	if _, ok := GetCurrentUser(ctx); !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	thread, err := editableThread(ctx, qtx, threadID)
+	if err != nil {
+		writeEditError(w, err, "Thread not found")
+		return
+	}
+
+	files, err := deleteThreadTx(ctx, qtx, threadID)
+	if err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error deleting thread")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !isAuthor(ctx, thread.AuthorID) {
+		if err := logModeration(ctx, qtx, "delete", "thread", threadID, ""); err != nil {
+			log.Error().Err(err).Str("thread_id", threadID).Msg("Error writing moderation log")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.removeImageFiles(context.WithoutCancel(ctx), files)
+	app.publishThreadUpdate(threadID, "", "delete")
+
+	log.Info().Str("thread_id", threadID).Int("files", len(files)).Msg("Thread deleted")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateComment handles the PUT /api/threads/{id}/comments/{commentID} endpoint.
+// The previous version is kept as a revision and the new text is translated again.
+func (app *App) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	threadID := vars["id"]
+	commentID := vars["commentID"]
+
+	if _, ok := GetCurrentUser(ctx); !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	req, err := decodeEditRequest(r, false)
+	if err != nil {
+		log.Debug().Err(err).Msg("Invalid comment edit")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	comment, err := editableComment(ctx, qtx, threadID, commentID)
+	if err != nil {
+		writeEditError(w, err, "Comment not found")
+		return
+	}
+
+	previous, err := qtx.GetCommentTranslation(ctx, sqlcdb.GetCommentTranslationParams{
+		CommentID: commentID,
+		Language:  comment.Language,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error getting comment text")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	originalLang, detection := app.detectSourceLanguage(ctx, req.Lang, req.Content)
+	now := time.Now()
+
+	if _, err := qtx.CreateRevision(ctx, sqlcdb.CreateRevisionParams{
+		ID:         fmt.Sprintf("%d", now.UnixNano()),
+		TargetType: RevisionTargetComment,
+		TargetID:   commentID,
+		Content:    previous.Content,
+		Language:   comment.Language,
+		EditedBy:   currentUserID(ctx),
+		CreatedAt:  now,
+	}); err != nil {
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error saving comment revision")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	editedAt := sql.NullTime{Time: now, Valid: true}
+	if _, err := qtx.UpdateComment(ctx, sqlcdb.UpdateCommentParams{
+		Language: originalLang,
+		EditedAt: editedAt,
+		ID:       commentID,
+	}); err != nil {
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error updating comment")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := app.retranslateComment(ctx, qtx, commentID, req.Content, originalLang, detection); err != nil {
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error retranslating comment")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !isAuthor(ctx, comment.AuthorID) {
+		if err := logModeration(ctx, qtx, "edit", "comment", commentID, ""); err != nil {
+			log.Error().Err(err).Str("comment_id", commentID).Msg("Error writing moderation log")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.notifyTranslationWorkers()
+	app.publishThreadUpdate(threadID, commentID, "edit")
+
+	log.Info().
+		Str("comment_id", commentID).
+		Str("thread_id", threadID).
+		Str("language", originalLang).
+		Float64("detection_confidence", detection.Confidence).
+		Msg("Comment edited")
+
+	attachments, err := app.loadCommentAttachments(ctx, commentID)
+	if err != nil {
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error getting comment attachments")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := LocalizedComment{
+		ID:              comment.ID,
+		ThreadID:        comment.ThreadID,
+		ParentCommentID: comment.ParentCommentID.String,
+		Depth:           comment.Depth,
+		Content:         req.Content,
+		ContentHTML:     markdown.Render(req.Content),
+		Attachments:     attachments,
+		AuthorID:        comment.AuthorID.String,
+		Hidden:          comment.Hidden,
+		CreatedAt:       comment.CreatedAt,
+		EditedAt:        nullTime(editedAt),
+		Language:        GetLanguage(ctx),
+	}
+	if len(attachments) > 0 {
+		response.ImagePath = attachments[0].URL
+	}
+	if comment.AuthorID.Valid {
+		if author, err := app.queries.GetUser(ctx, comment.AuthorID.String); err == nil {
+			response.AuthorName = author.Username
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteComment handles the DELETE /api/threads/{id}/comments/{commentID} endpoint
+func (app *App) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	threadID := vars["id"]
+	commentID := vars["commentID"]
+
+	if _, ok := GetCurrentUser(ctx); !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	comment, err := editableComment(ctx, qtx, threadID, commentID)
+	if err != nil {
+		writeEditError(w, err, "Comment not found")
+		return
+	}
+
+	files, err := deleteCommentTx(ctx, qtx, commentID)
+	if err != nil {
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error deleting comment")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !isAuthor(ctx, comment.AuthorID) {
+		if err := logModeration(ctx, qtx, "delete", "comment", commentID, ""); err != nil {
+			log.Error().Err(err).Str("comment_id", commentID).Msg("Error writing moderation log")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.removeImageFiles(context.WithoutCancel(ctx), files)
+	app.publishThreadUpdate(threadID, commentID, "delete")
+
+	log.Info().Str("comment_id", commentID).Int("files", len(files)).Msg("Comment deleted")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetThreadRevisions handles the GET /api/threads/{id}/revisions endpoint
+func (app *App) GetThreadRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	threadID := mux.Vars(r)["id"]
+
+	if _, err := app.getVisibleThread(ctx, threadID); err != nil {
+		if errors.Is(err, errNotFound) {
+			http.Error(w, "Thread not found", http.StatusNotFound)
+			return
+		}
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error getting thread")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.writeRevisions(w, r, RevisionTargetThread, threadID)
+}
+
+// GetCommentRevisions handles the GET /api/threads/{id}/comments/{commentID}/revisions endpoint
+func (app *App) GetCommentRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	threadID := vars["id"]
+	commentID := vars["commentID"]
+
+	if _, err := app.getVisibleThread(ctx, threadID); err != nil {
+		if errors.Is(err, errNotFound) {
+			http.Error(w, "Thread not found", http.StatusNotFound)
+			return
+		}
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error getting thread")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	comment, err := app.queries.GetComment(ctx, commentID)
+	if err != nil && err != sql.ErrNoRows {
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error getting comment")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err == sql.ErrNoRows || comment.ThreadID != threadID || (comment.Hidden && !IsModerator(ctx)) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+
+	app.writeRevisions(w, r, RevisionTargetComment, commentID)
+}
+
+// writeRevisions responds with the prior versions of a post, newest first
+func (app *App) writeRevisions(w http.ResponseWriter, r *http.Request, targetType, targetID string) {
+	rows, err := app.queries.ListRevisions(r.Context(), sqlcdb.ListRevisionsParams{
+		TargetType: targetType,
+		TargetID:   targetID,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("target_type", targetType).Str("target_id", targetID).Msg("Error listing revisions")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	revisions := make([]Revision, 0, len(rows))
+	for _, row := range rows {
+		revisions = append(revisions, Revision{
+			ID:         row.ID,
+			Title:      row.Title,
+			Content:    row.Content,
+			Language:   row.Language,
+			EditedBy:   row.EditedBy.String,
+			EditorName: row.EditorName.String,
+			CreatedAt:  row.CreatedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
@@ -0,0 +1,698 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"slices"
+	"time"
+
+	sqlcdb "pkoforum/db/sqlc"
+	"pkoforum/internal/mailer"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Digest frequencies
+const (
+	DigestOff    string = "off"
+	DigestDaily  string = "daily"
+	DigestWeekly string = "weekly"
+)
+
+const (
+	// emailConfirmationTTL is how long a confirmation link stays valid
+	emailConfirmationTTL = 24 * time.Hour
+
+	// emailConfirmationCooldown is the least time between two confirmation
+	// e-mails for one user, so that the forum cannot be used to flood an inbox
+	emailConfirmationCooldown = 5 * time.Minute
+)
+
+// Mailing lists users can unsubscribe from with the token in their e-mails
+const (
+	MailListNotifications string = "notifications"
+	MailListDigest        string = "digest"
+)
+
+// EmailSettings are the e-mail preferences of the current user
+type EmailSettings struct {
+	Email string `json:"email"`
+	// Verified is set once the address is confirmed; until then nothing is sent to it
+	Verified bool   `json:"verified"`
+	Language string `json:"language"`
+	// Notifications sends every new notification by e-mail
+	Notifications bool   `json:"notifications"`
+	Digest        string `json:"digest"`
+	// Categories are the categories whose new threads the digest lists
+	Categories []string `json:"categories"`
+}
+
+type emailSettingsRequest struct {
+	Email         string    `json:"email"`
+	Language      string    `json:"language"`
+	Notifications *bool     `json:"notifications"`
+	Digest        string    `json:"digest"`
+	Categories    *[]string `json:"categories"`
+}
+
+// threadURL links to a thread on the public site
+func (app *App) threadURL(threadID string) string {
+	return app.siteURL + "/t/" + url.PathEscape(threadID)
+}
+
+// unsubscribeURL is the one-click link that takes a user off list
+func (app *App) unsubscribeURL(token, list string) string {
+	return app.siteURL + "/api/unsubscribe?" + url.Values{"list": {list}, "token": {token}}.Encode()
+}
+
+// confirmationURL is the link that confirms an address
+func (app *App) confirmationURL(token string) string {
+	return app.siteURL + "/api/settings/email/confirm?" + url.Values{"token": {token}}.Encode()
+}
+
+// sendMail addresses msg to the owner of settings and adds the headers mail
+// clients use to offer unsubscribing from list. Nothing is sent to addresses
+// that have not been confirmed.
+func (app *App) sendMail(ctx context.Context, settings sqlcdb.EmailSetting, list string, msg mailer.Message) error {
+	if !settings.VerifiedAt.Valid {
+		log.Debug().Str("user_id", settings.UserID).Str("list", list).Msg("Skipping e-mail to unconfirmed address")
+		return nil
+	}
+	msg.To = settings.Email
+	msg.Headers = map[string]string{
+		"List-Unsubscribe":      "<" + app.unsubscribeURL(settings.UnsubscribeToken, list) + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+	return app.mailer.Send(ctx, msg)
+}
+
+// mailNotifier is the notification hook sending notifications by e-mail to
+// the users who turned them on
+type mailNotifier struct {
+	app *App
+}
+
+// Deliver e-mails notification in the recipient's language
+func (n mailNotifier) Deliver(ctx context.Context, notification Notification) error {
+	app := n.app
+
+	settings, err := app.queries.GetEmailSettings(ctx, notification.UserID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("getting e-mail settings: %w", err)
+	}
+	if !settings.Notifications || !settings.VerifiedAt.Valid {
+		return nil
+	}
+
+	title := notification.ThreadTitle
+	translation, err := app.queries.GetThreadTranslation(ctx, sqlcdb.GetThreadTranslationParams{
+		ThreadID: notification.ThreadID,
+		Language: settings.Language,
+	})
+	if err == nil {
+		title = translation.Title
+	} else if err != sql.ErrNoRows {
+		return fmt.Errorf("getting thread translation: %w", err)
+	}
+
+	link := app.threadURL(notification.ThreadID)
+	if notification.CommentID != "" {
+		link += "#comment-" + notification.CommentID
+	}
+
+	msg, err := mailer.Render(mailer.TemplateNotification, settings.Language, mailer.NotificationData{
+		Kind:           notification.Kind,
+		ActorName:      notification.ActorName,
+		ThreadTitle:    title,
+		URL:            link,
+		UnsubscribeURL: app.unsubscribeURL(settings.UnsubscribeToken, MailListNotifications),
+	})
+	if err != nil {
+		return err
+	}
+	if err := app.sendMail(ctx, settings, MailListNotifications, msg); err != nil {
+		return err
+	}
+
+	log.Debug().Str("notification_id", notification.ID).Str("user_id", notification.UserID).Msg("Notification e-mailed")
+	return nil
+}
+
+// GetEmailSettings handles the GET /api/settings/email endpoint. Users without
+// an address get the defaults they would start with.
+func (app *App) GetEmailSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := GetCurrentUser(ctx)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	settings, err := app.loadEmailSettings(ctx, user.ID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Error getting e-mail settings")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// loadEmailSettings returns a user's e-mail settings and followed categories
+func (app *App) loadEmailSettings(ctx context.Context, userID string) (EmailSettings, error) {
+	settings := EmailSettings{
+		Language:      GetLanguage(ctx),
+		Notifications: true,
+		Digest:        DigestOff,
+	}
+
+	row, err := app.queries.GetEmailSettings(ctx, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return settings, err
+	}
+	if err == nil {
+		settings.Email = row.Email
+		settings.Verified = row.VerifiedAt.Valid
+		settings.Language = row.Language
+		settings.Notifications = row.Notifications
+		settings.Digest = row.Digest
+	}
+
+	settings.Categories, err = app.queries.ListCategorySubscriptions(ctx, userID)
+	if err != nil {
+		return settings, fmt.Errorf("listing followed categories: %w", err)
+	}
+	return settings, nil
+}
+
+// UpdateEmailSettings handles the PUT /api/settings/email endpoint. Omitted
+// fields keep their current value; categories replaces the followed categories.
+// A new address is only used once confirmed through the link e-mailed to it.
+func (app *App) UpdateEmailSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := GetCurrentUser(ctx)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	var req emailSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error().Err(err).Msg("Error decoding request body")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	settings, err := app.loadEmailSettings(ctx, user.ID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Error getting e-mail settings")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	previousEmail := settings.Email
+	if req.Email != "" {
+		address, err := mail.ParseAddress(req.Email)
+		if err != nil {
+			http.Error(w, "Invalid email", http.StatusBadRequest)
+			return
+		}
+		settings.Email = address.Address
+	}
+	if settings.Email == "" {
+		http.Error(w, "email is required", http.StatusBadRequest)
+		return
+	}
+	if req.Language != "" {
+		if !slices.Contains(app.languages, req.Language) {
+			http.Error(w, "Unsupported language", http.StatusBadRequest)
+			return
+		}
+		settings.Language = req.Language
+	}
+	if req.Notifications != nil {
+		settings.Notifications = *req.Notifications
+	}
+	if req.Digest != "" {
+		if !slices.Contains([]string{DigestOff, DigestDaily, DigestWeekly}, req.Digest) {
+			http.Error(w, fmt.Sprintf("digest must be %s, %s or %s", DigestOff, DigestDaily, DigestWeekly), http.StatusBadRequest)
+			return
+		}
+		settings.Digest = req.Digest
+	}
+	if req.Categories != nil {
+		settings.Categories = []string{}
+		for _, slug := range *req.Categories {
+			if _, err := app.getCategory(ctx, slug); err != nil {
+				if errors.Is(err, errNotFound) {
+					http.Error(w, fmt.Sprintf("Invalid category %q", slug), http.StatusBadRequest)
+					return
+				}
+				log.Error().Err(err).Str("category", slug).Msg("Error getting category")
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !slices.Contains(settings.Categories, slug) {
+				settings.Categories = append(settings.Categories, slug)
+			}
+		}
+	}
+
+	// The tokens are kept when the settings already exist for the same address
+	token, err := newEmailToken()
+	if err != nil {
+		log.Error().Err(err).Msg("Error generating unsubscribe token")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	verificationToken, err := newEmailToken()
+	if err != nil {
+		log.Error().Err(err).Msg("Error generating verification token")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	now := time.Now()
+	// A new address gets a confirmation e-mail, which is throttled even when
+	// the previous address was removed
+	if settings.Email != previousEmail {
+		current, err := qtx.GetEmailSettings(ctx, user.ID)
+		if err != nil && err != sql.ErrNoRows {
+			log.Error().Err(err).Str("user_id", user.ID).Msg("Error getting e-mail settings")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err == nil && confirmationThrottled(current, now) {
+			http.Error(w, "A confirmation e-mail was sent recently, try again later", http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	row, err := qtx.UpsertEmailSettings(ctx, sqlcdb.UpsertEmailSettingsParams{
+		UserID:             user.ID,
+		Email:              settings.Email,
+		VerificationToken:  sql.NullString{String: verificationToken, Valid: true},
+		VerificationSentAt: sql.NullTime{Time: now, Valid: true},
+		Language:           settings.Language,
+		Notifications:      settings.Notifications,
+		Digest:             settings.Digest,
+		LastDigestAt:       now,
+		UnsubscribeToken:   token,
+		UpdatedAt:          now,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Error saving e-mail settings")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if req.Categories != nil {
+		if err := qtx.DeleteCategorySubscriptions(ctx, user.ID); err != nil {
+			log.Error().Err(err).Str("user_id", user.ID).Msg("Error removing followed categories")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, slug := range settings.Categories {
+			if err := qtx.AddCategorySubscription(ctx, sqlcdb.AddCategorySubscriptionParams{
+				UserID:       user.ID,
+				CategorySlug: slug,
+				CreatedAt:    now,
+			}); err != nil {
+				log.Error().Err(err).Str("user_id", user.ID).Str("category", slug).Msg("Error following category")
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Info().Str("user_id", user.ID).Str("digest", settings.Digest).Bool("notifications", settings.Notifications).Msg("E-mail settings updated")
+
+	// The fresh token was stored only if the address is new
+	settings.Verified = row.VerifiedAt.Valid
+	if row.VerificationToken.String == verificationToken {
+		if err := app.sendConfirmation(ctx, row); err != nil {
+			log.Error().Err(err).Str("user_id", user.ID).Msg("Error sending confirmation e-mail")
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// DeleteEmailSettings handles the DELETE /api/settings/email endpoint,
+// forgetting the user's address and followed categories so that no more
+// e-mail is sent. When the last confirmation e-mail went out is kept.
+func (app *App) DeleteEmailSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := GetCurrentUser(ctx)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	if err := qtx.ClearEmailSettings(ctx, sqlcdb.ClearEmailSettingsParams{
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+	}); err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Error deleting e-mail settings")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := qtx.DeleteCategorySubscriptions(ctx, user.ID); err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Error removing followed categories")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Info().Str("user_id", user.ID).Msg("E-mail settings deleted")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// confirmationThrottled reports whether a confirmation e-mail was sent to the
+// owner of settings too recently to send another
+func confirmationThrottled(settings sqlcdb.EmailSetting, now time.Time) bool {
+	return settings.VerificationSentAt.Valid && now.Sub(settings.VerificationSentAt.Time) < emailConfirmationCooldown
+}
+
+// sendConfirmation e-mails the link confirming the address in settings. It
+// bypasses sendMail, which refuses unconfirmed addresses.
+func (app *App) sendConfirmation(ctx context.Context, settings sqlcdb.EmailSetting) error {
+	if app.mailer == nil {
+		return nil
+	}
+	msg, err := mailer.Render(mailer.TemplateConfirmation, settings.Language, mailer.ConfirmationData{
+		Email: settings.Email,
+		URL:   app.confirmationURL(settings.VerificationToken.String),
+		Hours: int(emailConfirmationTTL / time.Hour),
+	})
+	if err != nil {
+		return err
+	}
+	msg.To = settings.Email
+	if err := app.mailer.Send(ctx, msg); err != nil {
+		return err
+	}
+	log.Info().Str("user_id", settings.UserID).Msg("Confirmation e-mail sent")
+	return nil
+}
+
+// ResendEmailConfirmation handles the POST /api/settings/email/confirmation
+// endpoint, sending a new confirmation link for an unconfirmed address
+func (app *App) ResendEmailConfirmation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := GetCurrentUser(ctx)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	settings, err := app.queries.GetEmailSettings(ctx, user.ID)
+	if err != nil && err != sql.ErrNoRows {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Error getting e-mail settings")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err == sql.ErrNoRows || settings.Email == "" {
+		http.Error(w, "No e-mail address set", http.StatusNotFound)
+		return
+	}
+	if settings.VerifiedAt.Valid {
+		http.Error(w, "E-mail address is already confirmed", http.StatusConflict)
+		return
+	}
+	now := time.Now()
+	if confirmationThrottled(settings, now) {
+		http.Error(w, "A confirmation e-mail was sent recently, try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	token, err := newEmailToken()
+	if err != nil {
+		log.Error().Err(err).Msg("Error generating verification token")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	settings.VerificationToken = sql.NullString{String: token, Valid: true}
+	settings.VerificationSentAt = sql.NullTime{Time: now, Valid: true}
+	if err := app.queries.SetEmailVerification(ctx, sqlcdb.SetEmailVerificationParams{
+		VerificationToken:  settings.VerificationToken,
+		VerificationSentAt: settings.VerificationSentAt,
+		UpdatedAt:          now,
+		UserID:             user.ID,
+	}); err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Error saving verification token")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := app.sendConfirmation(ctx, settings); err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Error sending confirmation e-mail")
+		http.Error(w, "Error sending confirmation e-mail", http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// newEmailToken returns a random token for unsubscribe and confirmation links
+func newEmailToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(tokenBytes), nil
+}
+
+// unsubscribeTexts holds the wording of the unsubscribe page by language
+var unsubscribeTexts = map[string]map[string]string{
+	"en": {
+		MailListNotifications:          "Stop e-mail notifications about replies, mentions and followed threads?",
+		MailListDigest:                 "Stop the e-mail digest of new threads?",
+		"button":                       "Unsubscribe",
+		"done":                         "You have been unsubscribed.",
+		"title":                        "Unsubscribe",
+		"invalid":                      "This unsubscribe link is invalid or has expired.",
+		MailListNotifications + ".off": "You will no longer receive e-mail notifications.",
+		MailListDigest + ".off":        "You will no longer receive the digest.",
+	},
+	"ru": {
+		MailListNotifications:          "Отключить уведомления по почте об ответах, упоминаниях и отслеживаемых обсуждениях?",
+		MailListDigest:                 "Отключить сводку новых обсуждений по почте?",
+		"button":                       "Отписаться",
+		"done":                         "Вы отписались.",
+		"title":                        "Отписка",
+		"invalid":                      "Ссылка для отписки недействительна.",
+		MailListNotifications + ".off": "Вы больше не будете получать уведомления по почте.",
+		MailListDigest + ".off":        "Вы больше не будете получать сводку.",
+	},
+}
+
+// confirmationTexts holds the wording of the address confirmation page by language
+var confirmationTexts = map[string]map[string]string{
+	"en": {
+		"title":   "Confirm e-mail address",
+		"prompt":  "Confirm that forum e-mail may be sent to %s?",
+		"button":  "Confirm",
+		"done":    "Address confirmed",
+		"message": "E-mail will now be sent to %s according to your settings.",
+		"invalid": "This confirmation link is invalid or has expired.",
+	},
+	"ru": {
+		"title":   "Подтверждение адреса",
+		"prompt":  "Подтвердить, что форум может присылать письма на %s?",
+		"button":  "Подтвердить",
+		"done":    "Адрес подтверждён",
+		"message": "Теперь письма будут приходить на %s в соответствии с вашими настройками.",
+		"invalid": "Ссылка для подтверждения недействительна или устарела.",
+	},
+}
+
+// pageTexts picks the wording for lang, falling back to English
+func pageTexts(texts map[string]map[string]string, lang string) map[string]string {
+	if t, ok := texts[lang]; ok {
+		return t
+	}
+	return texts["en"]
+}
+
+// emailPageTemplate renders the pages opened from links in e-mails
+var emailPageTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{- if .Button}}
+<form method="post"><button type="submit">{{.Button}}</button></form>
+{{- end}}
+</body>
+</html>
+`))
+
+type emailPage struct {
+	Lang    string
+	Title   string
+	Message string
+	Button  string
+}
+
+// UnsubscribeEmail handles the GET and POST /api/unsubscribe endpoints. GET asks
+// for confirmation, so that link scanners do not unsubscribe anyone; POST is
+// the one-click unsubscribe mail clients send for the List-Unsubscribe header.
+func (app *App) UnsubscribeEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	list := query.Get("list")
+
+	lang := GetLanguage(ctx)
+	settings, err := app.queries.GetEmailSettingsByToken(ctx, query.Get("token"))
+	if err != nil && err != sql.ErrNoRows {
+		log.Error().Err(err).Msg("Error getting e-mail settings")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err == nil {
+		lang = settings.Language
+	}
+	texts := pageTexts(unsubscribeTexts, lang)
+
+	page := emailPage{Lang: lang, Title: texts["title"]}
+	status := http.StatusOK
+	switch {
+	case err == sql.ErrNoRows || query.Get("token") == "" || (list != MailListNotifications && list != MailListDigest):
+		page.Message = texts["invalid"]
+		status = http.StatusNotFound
+	case r.Method == http.MethodGet:
+		page.Message = texts[list]
+		page.Button = texts["button"]
+	default:
+		now := time.Now()
+		if list == MailListNotifications {
+			err = app.queries.SetEmailNotifications(ctx, sqlcdb.SetEmailNotificationsParams{
+				Notifications: false,
+				UpdatedAt:     now,
+				UserID:        settings.UserID,
+			})
+		} else {
+			err = app.queries.SetEmailDigest(ctx, sqlcdb.SetEmailDigestParams{
+				Digest:    DigestOff,
+				UpdatedAt: now,
+				UserID:    settings.UserID,
+			})
+		}
+		if err != nil {
+			log.Error().Err(err).Str("user_id", settings.UserID).Str("list", list).Msg("Error unsubscribing")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info().Str("user_id", settings.UserID).Str("list", list).Msg("Unsubscribed from e-mail")
+		page.Title = texts["done"]
+		page.Message = texts[list+".off"]
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := emailPageTemplate.Execute(w, page); err != nil {
+		log.Error().Err(err).Msg("Error rendering unsubscribe page")
+	}
+}
+
+// ConfirmEmailAddress handles the GET and POST /api/settings/email/confirm
+// endpoints opened from confirmation e-mails. Like unsubscribing, GET only asks
+// for confirmation so that link scanners cannot confirm an address.
+func (app *App) ConfirmEmailAddress(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token := r.URL.Query().Get("token")
+
+	lang := GetLanguage(ctx)
+	settings, err := app.queries.GetEmailSettingsByVerificationToken(ctx, sql.NullString{String: token, Valid: true})
+	if err != nil && err != sql.ErrNoRows {
+		log.Error().Err(err).Msg("Error getting e-mail settings")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err == nil {
+		lang = settings.Language
+	}
+	texts := pageTexts(confirmationTexts, lang)
+
+	page := emailPage{Lang: lang, Title: texts["title"]}
+	status := http.StatusOK
+	now := time.Now()
+	switch {
+	case err == sql.ErrNoRows || token == "" || !settings.VerificationSentAt.Valid ||
+		now.Sub(settings.VerificationSentAt.Time) > emailConfirmationTTL:
+		page.Message = texts["invalid"]
+		status = http.StatusNotFound
+	case r.Method == http.MethodGet:
+		page.Message = fmt.Sprintf(texts["prompt"], settings.Email)
+		page.Button = texts["button"]
+	default:
+		if err := app.queries.ConfirmEmail(ctx, sqlcdb.ConfirmEmailParams{
+			VerifiedAt: sql.NullTime{Time: now, Valid: true},
+			UpdatedAt:  now,
+			UserID:     settings.UserID,
+		}); err != nil {
+			log.Error().Err(err).Str("user_id", settings.UserID).Msg("Error confirming e-mail address")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info().Str("user_id", settings.UserID).Msg("E-mail address confirmed")
+		page.Title = texts["done"]
+		page.Message = fmt.Sprintf(texts["message"], settings.Email)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := emailPageTemplate.Execute(w, page); err != nil {
+		log.Error().Err(err).Msg("Error rendering confirmation page")
+	}
+}
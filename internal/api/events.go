@@ -0,0 +1,337 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	sqlcdb "pkoforum/db/sqlc"
+	"pkoforum/internal/markdown"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	EventThreadCreated    string = "thread_created"
+	EventThreadUpdated    string = "thread_updated"
+	EventCommentCreated   string = "comment_created"
+	EventTranslationReady string = "translation_ready"
+
+	// threadsTopic is the global feed of new threads
+	threadsTopic = "threads"
+
+	eventHistorySize      = 512
+	eventSubscriberBuffer = 64
+	eventKeepAlive        = 30 * time.Second
+	eventRetry            = 3 * time.Second
+)
+
+// errEventsClosed is returned when subscribing after the broker shut down
+var errEventsClosed = errors.New("event stream is shutting down")
+
+// Event is a change pushed to clients as a server-sent event
+type Event struct {
+	ID    int64
+	Topic string
+	Type  string
+	Data  json.RawMessage
+}
+
+// ThreadUpdate is the payload of thread_updated events. CommentID is set when
+// the change concerns one of the thread's comments.
+type ThreadUpdate struct {
+	ThreadID  string `json:"thread_id"`
+	CommentID string `json:"comment_id,omitempty"`
+	Action    string `json:"action"`
+}
+
+// TranslationReady is the payload of translation_ready events
+type TranslationReady struct {
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	ThreadID   string `json:"thread_id"`
+	Language   string `json:"language"`
+}
+
+// eventBroker fans published events out to the subscribers of their topic and
+// keeps the most recent ones so reconnecting clients can resume
+type eventBroker struct {
+	mu          sync.Mutex
+	lastID      int64
+	history     []Event
+	subscribers map[*eventSubscriber]struct{}
+	closed      bool
+}
+
+type eventSubscriber struct {
+	topic  string
+	events chan Event
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		// Start from the clock so IDs keep increasing across restarts
+		lastID:      time.Now().UnixNano(),
+		subscribers: make(map[*eventSubscriber]struct{}),
+	}
+}
+
+// threadTopic is the topic of the events concerning one thread
+func threadTopic(threadID string) string {
+	return "thread:" + threadID
+}
+
+// publish sends an event to every subscriber of topic. Subscribers that fall
+// behind are dropped; their clients reconnect and resume from the history.
+func (b *eventBroker) publish(topic, eventType string, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Error().Err(err).Str("type", eventType).Msg("Error encoding event")
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	b.lastID++
+	event := Event{ID: b.lastID, Topic: topic, Type: eventType, Data: data}
+	if len(b.history) == eventHistorySize {
+		b.history = append(b.history[:0], b.history[1:]...)
+	}
+	b.history = append(b.history, event)
+
+	for sub := range b.subscribers {
+		if sub.topic != topic {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			delete(b.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// subscribe registers a subscriber for topic and returns the retained events
+// published after lastID, oldest first
+func (b *eventBroker) subscribe(topic string, lastID int64) (*eventSubscriber, []Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, nil, errEventsClosed
+	}
+
+	var missed []Event
+	if lastID > 0 {
+		for _, event := range b.history {
+			if event.ID > lastID && event.Topic == topic {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	sub := &eventSubscriber{topic: topic, events: make(chan Event, eventSubscriberBuffer)}
+	b.subscribers[sub] = struct{}{}
+	return sub, missed, nil
+}
+
+// unsubscribe removes a subscriber that is still registered
+func (b *eventBroker) unsubscribe(sub *eventSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// close ends every stream and rejects new subscribers
+func (b *eventBroker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// publishTranslationReady tells the subscribers of a thread that one of its
+// posts is now available in another language
+func (app *App) publishTranslationReady(ctx context.Context, job sqlcdb.TranslationJob) {
+	threadID := job.TargetID
+	if job.TargetType == JobTargetComment {
+		comment, err := app.queries.GetComment(ctx, job.TargetID)
+		if err != nil {
+			log.Error().Err(err).Str("comment_id", job.TargetID).Msg("Error getting translated comment")
+			return
+		}
+		threadID = comment.ThreadID
+	}
+
+	app.events.publish(threadTopic(threadID), EventTranslationReady, TranslationReady{
+		TargetType: job.TargetType,
+		TargetID:   job.TargetID,
+		ThreadID:   threadID,
+		Language:   job.TargetLanguage,
+	})
+}
+
+// publishThreadCreated announces a thread on the global feed once it became
+// visible to everyone, e.g. after it was approved
+func (app *App) publishThreadCreated(ctx context.Context, threadID string) {
+	thread, err := app.queries.GetThread(ctx, sqlcdb.GetThreadParams{
+		Language: GetLanguage(ctx),
+		ID:       threadID,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error getting thread")
+		return
+	}
+	if thread.Hidden || !thread.Approved {
+		return
+	}
+
+	app.events.publish(threadsTopic, EventThreadCreated, Thread{
+		ID:          thread.ID,
+		Title:       thread.Title,
+		Content:     thread.Content,
+		ContentHTML: markdown.Render(thread.Content),
+		Category:    thread.Category,
+		AuthorID:    thread.AuthorID.String,
+		AuthorName:  thread.AuthorName.String,
+		Pinned:      thread.Pinned,
+		Locked:      thread.Locked,
+		CreatedAt:   thread.CreatedAt,
+	})
+}
+
+// publishThreadUpdate tells the subscribers of a thread that it changed
+func (app *App) publishThreadUpdate(threadID, commentID, action string) {
+	app.events.publish(threadTopic(threadID), EventThreadUpdated, ThreadUpdate{
+		ThreadID:  threadID,
+		CommentID: commentID,
+		Action:    action,
+	})
+}
+
+// StopEvents closes all event streams so the server can shut down without
+// waiting for clients to disconnect
+func (app *App) StopEvents() {
+	app.events.close()
+}
+
+// GetEvents handles the GET /api/events endpoint, streaming new threads
+func (app *App) GetEvents(w http.ResponseWriter, r *http.Request) {
+	app.streamEvents(w, r, threadsTopic)
+}
+
+// GetThreadEvents handles the GET /api/threads/{id}/events endpoint, streaming
+// new comments, finished translations and other changes to one thread
+func (app *App) GetThreadEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	threadID := mux.Vars(r)["id"]
+
+	if _, err := app.getVisibleThread(ctx, threadID); err != nil {
+		if errors.Is(err, errNotFound) {
+			http.Error(w, "Thread not found", http.StatusNotFound)
+			return
+		}
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error getting thread")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.streamEvents(w, r, threadTopic(threadID))
+}
+
+// streamEvents writes the events of topic as a text/event-stream until the
+// client disconnects. Browsers resume with the Last-Event-ID header; other
+// clients may pass last_event_id instead.
+func (app *App) streamEvents(w http.ResponseWriter, r *http.Request, topic string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var lastID int64
+	if lastEventID != "" {
+		var err error
+		if lastID, err = strconv.ParseInt(lastEventID, 10, 64); err != nil {
+			http.Error(w, "Invalid last event ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	sub, missed, err := app.events.subscribe(topic, lastID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer app.events.unsubscribe(sub)
+
+	startEventStream(w)
+	for _, event := range missed {
+		writeEvent(w, event)
+	}
+	flusher.Flush()
+
+	relayEvents(r.Context(), w, flusher, sub, func(event Event) {
+		writeEvent(w, event)
+	})
+}
+
+// startEventStream writes the headers of a text/event-stream response and
+// tells the client how soon to reconnect
+func startEventStream(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Keep reverse proxies such as nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", eventRetry.Milliseconds())
+}
+
+// relayEvents hands each event of sub to send, keeping the connection alive
+// in between, until the client disconnects or the broker closes
+func relayEvents(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, sub *eventSubscriber, send func(Event)) {
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.events:
+			if !ok {
+				return
+			}
+			send(event)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes one event in the server-sent events format
+func writeEvent(w http.ResponseWriter, event Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
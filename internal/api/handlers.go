@@ -4,125 +4,239 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
 	"time"
 
 	sqlcdb "pkoforum/db/sqlc"
+	"pkoforum/internal/markdown"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog/log"
 )
 
 type Thread struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Category  string    `json:"category"`
-	CreatedAt time.Time `json:"created_at"`
-	Comments  []Comment `json:"comments,omitempty"`
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Content     string    `json:"content"`
+	ContentHTML string    `json:"content_html"`
+	Category    string    `json:"category"`
+	AuthorID    string    `json:"author_id,omitempty"`
+	AuthorName  string    `json:"author_name,omitempty"`
+	Pinned      bool      `json:"pinned"`
+	Locked      bool      `json:"locked"`
+	Pending     bool      `json:"pending,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	Comments    []Comment `json:"comments,omitempty"`
+
+	Attachments []Attachment `json:"attachments,omitempty"`
 }
 
 type Comment struct {
-	ID        string            `json:"id"`
-	ThreadID  string            `json:"thread_id"`
-	Content   map[string]string `json:"content"`
-	ImagePath string            `json:"image_path,omitempty"`
-	CreatedAt time.Time         `json:"created_at"`
+	ID               string            `json:"id"`
+	ThreadID         string            `json:"thread_id"`
+	ParentCommentID  string            `json:"parent_comment_id,omitempty"`
+	Depth            int64             `json:"depth"`
+	Content          map[string]string `json:"content"`
+	ContentHTML      map[string]string `json:"content_html,omitempty"`
+	OriginalLanguage string            `json:"original_language,omitempty"`
+	ImagePath        string            `json:"image_path,omitempty"` // first attachment, kept for older clients
+	Attachments      []Attachment      `json:"attachments,omitempty"`
+	AuthorID         string            `json:"author_id,omitempty"`
+	AuthorName       string            `json:"author_name,omitempty"`
+	Hidden           bool              `json:"hidden,omitempty"`
+	CreatedAt        time.Time         `json:"created_at"`
+	EditedAt         *time.Time        `json:"edited_at,omitempty"`
 }
 
 type LocalizedThread struct {
-	ID        string             `json:"id"`
-	Title     string             `json:"title"`
-	Content   string             `json:"content"`
-	Category  string             `json:"category"`
-	CreatedAt time.Time          `json:"created_at"`
-	Comments  []LocalizedComment `json:"comments,omitempty"`
-	Language  string             `json:"language"`
+	ID                 string             `json:"id"`
+	Title              string             `json:"title"`
+	Content            string             `json:"content"`
+	ContentHTML        string             `json:"content_html"`
+	Category           string             `json:"category"`
+	AuthorID           string             `json:"author_id,omitempty"`
+	AuthorName         string             `json:"author_name,omitempty"`
+	Pinned             bool               `json:"pinned"`
+	Locked             bool               `json:"locked"`
+	Hidden             bool               `json:"hidden,omitempty"`
+	Pending            bool               `json:"pending,omitempty"`
+	CreatedAt          time.Time          `json:"created_at"`
+	EditedAt           *time.Time         `json:"edited_at,omitempty"`
+	Reactions          Reactions          `json:"reactions"`
+	Attachments        []Attachment       `json:"attachments,omitempty"`
+	Comments           []LocalizedComment `json:"comments,omitempty"`
+	CommentsNextCursor string             `json:"comments_next_cursor,omitempty"`
+	AcceptedCommentID  string             `json:"accepted_comment_id,omitempty"`
+	AcceptedComment    *LocalizedComment  `json:"accepted_comment,omitempty"`
+	Language           string             `json:"language"`
 }
 
 type LocalizedComment struct {
-	ID        string    `json:"id"`
-	ThreadID  string    `json:"thread_id"`
-	Content   string    `json:"content"`
-	ImagePath string    `json:"image_path,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	Language  string    `json:"language"`
-}
-
-type CategoryOption struct {
-	Value string            `json:"value"`
-	Label map[string]string `json:"label"`
-}
-
-type LocalizedCategory struct {
-	Value string `json:"value"`
-	Label string `json:"label"`
+	ID              string       `json:"id"`
+	ThreadID        string       `json:"thread_id"`
+	ParentCommentID string       `json:"parent_comment_id,omitempty"`
+	Depth           int64        `json:"depth"`
+	Content         string       `json:"content"`
+	ContentHTML     string       `json:"content_html"`
+	ImagePath       string       `json:"image_path,omitempty"` // first attachment, kept for older clients
+	Attachments     []Attachment `json:"attachments,omitempty"`
+	AuthorID        string       `json:"author_id,omitempty"`
+	AuthorName      string       `json:"author_name,omitempty"`
+	Hidden          bool         `json:"hidden,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+	EditedAt        *time.Time   `json:"edited_at,omitempty"`
+	Reactions       Reactions    `json:"reactions"`
+	Accepted        bool         `json:"accepted,omitempty"`
+	Language        string       `json:"language"`
 }
 
 // GetThreads handles the GET /api/threads endpoint
 func (app *App) GetThreads(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	lang := GetLanguage(ctx)
 
 	category := r.URL.Query().Get("category")
-	var threads []sqlcdb.Thread
-	var err error
 
 	log.Debug().Str("category", category).Msg("Getting threads")
 
+	limit, cursor, err := parsePageParams(r, defaultThreadPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sort, err := parseSort(r, cursor, threadSorts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	solved, err := parseThreadState(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if category != "" {
-		if !ValidateCategory(category) {
-			http.Error(w, "Invalid category", http.StatusBadRequest)
+		if _, err := app.getCategory(ctx, category); err != nil {
+			if errors.Is(err, errNotFound) {
+				http.Error(w, "Invalid category", http.StatusBadRequest)
+				return
+			}
+			log.Error().Err(err).Str("category", category).Msg("Error getting category")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+	}
 
-		threads, err = app.queries.ListThreads(ctx, category)
-		if err != nil {
-			log.Error().Err(err).Str("category", category).Msg("Error listing threads")
-			threads = []sqlcdb.Thread{}
-		}
-	} else {
-		threads, err = app.queries.ListAllThreads(ctx)
-		if err != nil {
-			log.Error().Err(err).Msg("Error listing all threads")
-			threads = []sqlcdb.Thread{}
+	threads, nextCursor, err := app.listThreads(ctx, category, solved, sort, limit, cursor)
+	if err != nil {
+		log.Error().Err(err).Str("category", category).Msg("Error listing threads")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Page[LocalizedThread]{
+		Data:       threads,
+		NextCursor: nextCursor,
+	})
+}
+
+// listThreads returns one page of threads, pinned first and then in the given
+// sort order, localized for the request language, along with the cursor of the
+// following page. An empty category lists every category; a valid solved only
+// lists threads of categories with solutions enabled that are, or are not, solved.
+func (app *App) listThreads(ctx context.Context, category string, solved sql.NullBool, sort string, limit int64, cursor *pageCursor) ([]LocalizedThread, string, error) {
+	lang := GetLanguage(ctx)
+
+	// Fetch one extra row to learn whether another page follows
+	params := sqlcdb.ListThreadsParams{
+		UserID:        currentUserID(ctx),
+		Language:      lang,
+		IncludeHidden: IsModerator(ctx),
+		Solved:        solved,
+		Sort:          sort,
+		Limit:         limit + 1,
+	}
+	if category != "" {
+		params.Category = sql.NullString{String: category, Valid: true}
+	}
+	if cursor != nil {
+		params.HasCursor = true
+		params.CursorPinned = cursor.Pinned
+		params.CursorScore = cursor.Score
+		params.CursorActiveAt = cursor.ActiveAt
+		params.CursorCreatedAt = cursor.CreatedAt
+		params.CursorID = cursor.ID
+	}
+
+	threads, err := app.queries.ListThreads(ctx, params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if int64(len(threads)) > limit {
+		threads = threads[:limit]
+		last := threads[len(threads)-1]
+		next := pageCursor{Sort: sort, Pinned: last.Pinned, CreatedAt: last.CreatedAt, ID: last.ID}
+		switch sort {
+		case SortTop:
+			next.Score = last.Score
+		case SortActive:
+			next.ActiveAt = activeAt(last.LastActivityAt, last.CreatedAt)
 		}
+		nextCursor = encodeCursor(next)
 	}
 
 	log.Debug().Int("count", len(threads)).Str("category", category).Msg("Found threads")
 
-	displayThreads := make([]LocalizedThread, 0)
+	displayThreads := make([]LocalizedThread, 0, len(threads))
 
 	for _, t := range threads {
+		content := localizedText(t.Content, t.TranslatedContent)
 		displayThreads = append(displayThreads, LocalizedThread{
-			ID:        t.ID,
-			Title:     t.Title,
-			Content:   t.Content,
-			Category:  t.Category,
-			CreatedAt: t.CreatedAt,
-			Language:  lang,
-			Comments:  make([]LocalizedComment, 0),
+			ID:          t.ID,
+			Title:       localizedText(t.Title, t.TranslatedTitle),
+			Content:     content,
+			ContentHTML: markdown.Render(content),
+			Category:    t.Category,
+			AuthorID:    t.AuthorID.String,
+			AuthorName:  t.AuthorName.String,
+			Pinned:      t.Pinned,
+			Locked:      t.Locked,
+			Hidden:      t.Hidden,
+			Pending:     !t.Approved,
+			CreatedAt:   t.CreatedAt,
+			EditedAt:    nullTime(t.EditedAt),
+			Reactions:   decodeReactions(t.ReactionCounts, t.UserReactions),
+			Language:    lang,
+			Comments:    make([]LocalizedComment, 0),
+
+			AcceptedCommentID: t.AcceptedCommentID.String,
 		})
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(displayThreads)
+	return displayThreads, nextCursor, nil
 }
 
 // GetThread handles the GET /api/threads/{id} endpoint
 func (app *App) GetThread(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	lang := GetLanguage(ctx)
 	vars := mux.Vars(r)
 	threadID := vars["id"]
 
-	thread, err := app.queries.GetThread(ctx, threadID)
+	sort, err := parseSort(r, nil, commentSorts)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	thread, err := app.loadThread(ctx, threadID, sort, nil)
+	if err != nil {
+		if errors.Is(err, errNotFound) {
 			log.Debug().Str("thread_id", threadID).Msg("Thread not found")
 			http.Error(w, "Thread not found", http.StatusNotFound)
 			return
@@ -132,385 +246,763 @@ func (app *App) GetThread(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	comments, err := app.queries.GetThreadComments(ctx, threadID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(thread)
+}
+
+// loadThread returns a visible thread with its attachments, reactions, accepted
+// comment and a page of comments in the given sort order, the first one unless
+// cursor is set, localized for the request language
+func (app *App) loadThread(ctx context.Context, threadID, sort string, cursor *pageCursor) (LocalizedThread, error) {
+	thread, err := app.getVisibleThread(ctx, threadID)
+	if err != nil {
+		return LocalizedThread{}, err
+	}
+
+	attachments, err := app.loadThreadAttachments(ctx, threadID)
+	if err != nil {
+		return LocalizedThread{}, fmt.Errorf("getting thread attachments: %w", err)
+	}
+
+	reactions, err := app.getReactions(ctx, ReactionTargetThread, threadID)
+	if err != nil {
+		return LocalizedThread{}, fmt.Errorf("getting thread reactions: %w", err)
+	}
+
+	comments, nextCursor, err := app.loadComments(ctx, threadID, sort, defaultCommentPageSize, cursor)
+	if err != nil {
+		return LocalizedThread{}, fmt.Errorf("getting thread comments: %w", err)
+	}
+
+	accepted, err := app.loadAcceptedComment(ctx, thread)
+	if err != nil {
+		return LocalizedThread{}, fmt.Errorf("getting accepted comment: %w", err)
+	}
+
+	content := localizedText(thread.Content, thread.TranslatedContent)
+	return LocalizedThread{
+		ID:                 thread.ID,
+		Title:              localizedText(thread.Title, thread.TranslatedTitle),
+		Content:            content,
+		ContentHTML:        markdown.Render(content),
+		Category:           thread.Category,
+		AuthorID:           thread.AuthorID.String,
+		AuthorName:         thread.AuthorName.String,
+		Pinned:             thread.Pinned,
+		Locked:             thread.Locked,
+		Hidden:             thread.Hidden,
+		Pending:            !thread.Approved,
+		CreatedAt:          thread.CreatedAt,
+		EditedAt:           nullTime(thread.EditedAt),
+		Reactions:          reactions,
+		Attachments:        attachments,
+		Comments:           comments,
+		CommentsNextCursor: nextCursor,
+		AcceptedCommentID:  thread.AcceptedCommentID.String,
+		AcceptedComment:    accepted,
+		Language:           GetLanguage(ctx),
+	}, nil
+}
+
+// GetThreadComments handles the GET /api/threads/{id}/comments endpoint
+func (app *App) GetThreadComments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	threadID := mux.Vars(r)["id"]
+
+	limit, cursor, err := parsePageParams(r, defaultCommentPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sort, err := parseSort(r, cursor, commentSorts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := app.getVisibleThread(ctx, threadID); err != nil {
+		if errors.Is(err, errNotFound) {
+			http.Error(w, "Thread not found", http.StatusNotFound)
+			return
+		}
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error getting thread")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	comments, nextCursor, err := app.loadComments(ctx, threadID, sort, limit, cursor)
 	if err != nil {
 		log.Error().Err(err).Str("thread_id", threadID).Msg("Error getting thread comments")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Page[LocalizedComment]{
+		Data:       comments,
+		NextCursor: nextCursor,
+	})
+}
+
+// getVisibleThread loads a thread, treating hidden threads as missing for
+// non-moderators and threads awaiting approval as missing for everyone but
+// moderators and their author
+func (app *App) getVisibleThread(ctx context.Context, threadID string) (sqlcdb.GetThreadRow, error) {
+	thread, err := app.queries.GetThread(ctx, sqlcdb.GetThreadParams{
+		Language: GetLanguage(ctx),
+		ID:       threadID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return thread, errNotFound
+		}
+		return thread, err
+	}
+	if IsModerator(ctx) {
+		return thread, nil
+	}
+	if thread.Hidden || (!thread.Approved && !isAuthor(ctx, thread.AuthorID)) {
+		return thread, errNotFound
+	}
+	return thread, nil
+}
+
+// loadComments returns one page of a thread's comments in the given sort order,
+// localized for the request language, along with the cursor of the following page
+func (app *App) loadComments(ctx context.Context, threadID, sort string, limit int64, cursor *pageCursor) ([]LocalizedComment, string, error) {
+	// Fetch one extra comment to learn whether another page follows
+	params := sqlcdb.GetThreadCommentsParams{
+		UserID:        currentUserID(ctx),
+		ThreadID:      threadID,
+		IncludeHidden: IsModerator(ctx),
+		Sort:          sort,
+		Limit:         limit + 1,
+	}
+	if cursor != nil {
+		params.HasCursor = true
+		params.CursorScore = cursor.Score
+		params.CursorActiveAt = cursor.ActiveAt
+		params.CursorCreatedAt = cursor.CreatedAt
+		params.CursorID = cursor.ID
+	}
+
+	comments, positions, err := app.queryComments(ctx, params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if int64(len(comments)) > limit {
+		comments = comments[:limit]
+		nextCursor = encodeCursor(positions[limit-1])
+	}
+	return comments, nextCursor, nil
+}
+
+// queryComments runs GetThreadComments and returns the comments localized for
+// the request language, along with the keyset position of each
+func (app *App) queryComments(ctx context.Context, params sqlcdb.GetThreadCommentsParams) ([]LocalizedComment, []pageCursor, error) {
+	lang := GetLanguage(ctx)
+	threadID, sort := params.ThreadID, params.Sort
+
+	rows, err := app.queries.GetThreadComments(ctx, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Rows repeat per translation and image; collapse them in query order
+	var order []*Comment
 	commentMap := make(map[string]*Comment)
-	for _, c := range comments {
+	seenImages := make(map[string]bool)
+	reactions := make(map[string]Reactions)
+	accepted := make(map[string]bool)
+	positions := make(map[string]pageCursor)
+	for _, c := range rows {
 		comment, exists := commentMap[c.ID]
 		if !exists {
+			reactions[c.ID] = decodeReactions(c.ReactionCounts, c.UserReactions)
+			accepted[c.ID] = c.Accepted
+			position := pageCursor{Sort: sort, CreatedAt: c.CreatedAt, ID: c.ID}
+			switch sort {
+			case SortTop:
+				position.Score = c.Score
+			case SortActive:
+				position.ActiveAt = activeAt(c.LastActivityAt, c.CreatedAt)
+			}
+			positions[c.ID] = position
 			comment = &Comment{
-				ID:        c.ID,
-				ThreadID:  c.ThreadID,
-				Content:   make(map[string]string),
-				CreatedAt: c.CreatedAt,
+				ID:         c.ID,
+				ThreadID:   c.ThreadID,
+				Content:    make(map[string]string),
+				AuthorID:   c.AuthorID.String,
+				AuthorName: c.AuthorName.String,
+				Hidden:     c.Hidden,
+				CreatedAt:  c.CreatedAt,
+				EditedAt:   nullTime(c.EditedAt),
+
+				OriginalLanguage: c.OriginalLanguage,
+				ParentCommentID:  c.ParentCommentID.String,
+				Depth:            c.Depth,
 			}
 			commentMap[c.ID] = comment
+			order = append(order, comment)
 		}
 
 		if c.Language.Valid && c.Content.Valid {
 			comment.Content[c.Language.String] = c.Content.String
 		}
 
-		if c.ImageID.Valid && c.Filepath.Valid {
-			comment.ImagePath = c.Filepath.String
+		if c.ImageID.Valid && c.Filename.Valid && !seenImages[c.ImageID.String] {
+			seenImages[c.ImageID.String] = true
+			url := app.blobURL(ctx, c.Filename.String)
+			if comment.ImagePath == "" {
+				comment.ImagePath = url
+			}
+			comment.Attachments = append(comment.Attachments, Attachment{
+				ID:       c.ImageID.String,
+				URL:      url,
+				Caption:  c.ImageCaption.String,
+				Position: c.ImagePosition.Int64,
+				Width:    c.ImageWidth.Int64,
+				Height:   c.ImageHeight.Int64,
+			})
 		}
 	}
 
-	displayThread := LocalizedThread{
-		ID:        thread.ID,
-		Title:     thread.Title,
-		Content:   thread.Content,
-		Category:  thread.Category,
-		CreatedAt: thread.CreatedAt,
-		Language:  lang,
+	if len(seenImages) > 0 {
+		variants, err := app.queries.ListThreadCommentImageVariants(ctx, threadID)
+		if err != nil {
+			return nil, nil, err
+		}
+		byImage := make(map[string][]ImageVariant)
+		for _, v := range variants {
+			byImage[v.ImageID] = append(byImage[v.ImageID], ImageVariant{Name: v.Variant, URL: app.blobURL(ctx, v.Filename), Width: v.Width, Height: v.Height})
+		}
+		for _, comment := range order {
+			for i := range comment.Attachments {
+				a := &comment.Attachments[i]
+				a.Variants = imageVariants(byImage[a.ID], a.URL, a.Width, a.Height)
+			}
+		}
 	}
 
-	for _, comment := range commentMap {
-		localizedComment := LocalizedComment{
-			ID:        comment.ID,
-			ThreadID:  comment.ThreadID,
-			Content:   GetLocalizedContent(comment.Content, lang),
-			ImagePath: comment.ImagePath,
-			CreatedAt: comment.CreatedAt,
-			Language:  lang,
-		}
-		displayThread.Comments = append(displayThread.Comments, localizedComment)
+	comments := make([]LocalizedComment, 0, len(order))
+	commentPositions := make([]pageCursor, 0, len(order))
+	for _, comment := range order {
+		content := GetLocalizedContent(comment.Content, lang, comment.OriginalLanguage, app.defaultLang)
+		comments = append(comments, LocalizedComment{
+			ID:              comment.ID,
+			ThreadID:        comment.ThreadID,
+			ParentCommentID: comment.ParentCommentID,
+			Depth:           comment.Depth,
+			Content:         content,
+			ContentHTML:     markdown.Render(content),
+			ImagePath:       comment.ImagePath,
+			Attachments:     comment.Attachments,
+			AuthorID:        comment.AuthorID,
+			AuthorName:      comment.AuthorName,
+			Hidden:          comment.Hidden,
+			CreatedAt:       comment.CreatedAt,
+			EditedAt:        comment.EditedAt,
+			Reactions:       reactions[comment.ID],
+			Accepted:        accepted[comment.ID],
+			Language:        lang,
+		})
+		commentPositions = append(commentPositions, positions[comment.ID])
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(displayThread)
+	return comments, commentPositions, nil
+}
+
+// requestError is a rejected request, reported to the client with its HTTP status
+type requestError struct {
+	status  int
+	message string
 }
 
-// CreateThread handles the POST /api/threads endpoint
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// uploadError is a failure to validate or store the attachments of a post
+type uploadError struct {
+	err error
+}
+
+func (e *uploadError) Error() string {
+	return e.err.Error()
+}
+
+func (e *uploadError) Unwrap() error {
+	return e.err
+}
+
+// writeRequestError reports an error returned by createThread, createComment
+// and the other helpers shared by the API and the web UI
+func writeRequestError(w http.ResponseWriter, err error) {
+	status, message := requestErrorStatus(err)
+	http.Error(w, message, status)
+}
+
+// requestErrorStatus returns the HTTP status and message reporting err
+func requestErrorStatus(err error) (int, string) {
+	var rejected *requestError
+	var upload *uploadError
+	switch {
+	case errors.As(err, &rejected):
+		return rejected.status, rejected.message
+	case errors.As(err, &upload):
+		return imageErrorStatus(upload.err)
+	default:
+		return http.StatusInternalServerError, err.Error()
+	}
+}
+
+// threadInput is a new thread as submitted through the API or the web UI
+type threadInput struct {
+	Title    string
+	Content  string
+	Category string
+	Lang     string
+	// Form holds the uploaded images, if any
+	Form *multipart.Form
+}
+
+// commentInput is a new comment as submitted through the API or the web UI
+type commentInput struct {
+	ThreadID string
+	ParentID string
+	Content  string
+	Lang     string
+	// Form holds the uploaded images, if any
+	Form *multipart.Form
+}
+
+// CreateThread handles the POST /api/threads endpoint. It accepts a JSON body,
+// or a multipart form with the same fields when images are attached.
 func (app *App) CreateThread(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
 	var req struct {
 		Title    string `json:"title"`
 		Content  string `json:"content"`
 		Category string `json:"category"`
+		Lang     string `json:"lang"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if isMultipart(r) {
+		if !app.parseUploadForm(w, r) {
+			return
+		}
+		req.Title = r.FormValue("title")
+		req.Content = r.FormValue("content")
+		req.Category = r.FormValue("category")
+		req.Lang = r.FormValue("lang")
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error().Err(err).Msg("Error decoding request body")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if req.Category == "" {
-		log.Debug().Msg("Category is required")
-		http.Error(w, "Category is required", http.StatusBadRequest)
+	thread, err := app.createThread(r.Context(), threadInput{
+		Title:    req.Title,
+		Content:  req.Content,
+		Category: req.Category,
+		Lang:     req.Lang,
+		Form:     r.MultipartForm,
+	})
+	if err != nil {
+		writeRequestError(w, err)
 		return
 	}
 
-	if !ValidateCategory(req.Category) {
-		log.Debug().Str("category", req.Category).Msg("Invalid category")
-		http.Error(w, "Invalid category", http.StatusBadRequest)
-		return
-	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(thread)
+}
 
-	threadParams := sqlcdb.CreateThreadParams{
-		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
-		Title:     req.Title,
-		Content:   req.Content,
-		Category:  req.Category,
-		CreatedAt: time.Now(),
+// createThread validates and stores a new thread, queues its translations and
+// announces it on the event feed
+func (app *App) createThread(ctx context.Context, in threadInput) (Thread, error) {
+	if in.Category == "" {
+		log.Debug().Msg("Category is required")
+		return Thread{}, &requestError{status: http.StatusBadRequest, message: "Category is required"}
 	}
 
-	thread, err := app.queries.CreateThread(ctx, threadParams)
+	category, err := app.getCategory(ctx, in.Category)
 	if err != nil {
-		log.Error().Err(err).Interface("params", threadParams).Msg("Error creating thread")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		if errors.Is(err, errNotFound) {
+			log.Debug().Str("category", in.Category).Msg("Invalid category")
+			return Thread{}, &requestError{status: http.StatusBadRequest, message: "Invalid category"}
+		}
+		log.Error().Err(err).Str("category", in.Category).Msg("Error getting category")
+		return Thread{}, err
+	}
+
+	if !CanPost(ctx, category.ThreadRole) {
+		log.Debug().Str("category", in.Category).Str("role", category.ThreadRole).Msg("Not allowed to start threads in category")
+		return Thread{}, postingDenied(ctx, "You are not allowed to start threads in this category")
 	}
 
-	log.Info().Str("thread_id", thread.ID).Str("category", thread.Category).Msg("Thread created")
+	originalLang, detection := app.detectSourceLanguage(ctx, in.Lang, in.Title+"\n"+in.Content)
 
-	displayThread := Thread{
-		ID:        thread.ID,
-		Title:     thread.Title,
-		Content:   thread.Content,
-		Category:  thread.Category,
-		CreatedAt: thread.CreatedAt,
-		Comments:  []Comment{},
+	now := time.Now()
+	threadParams := sqlcdb.CreateThreadParams{
+		ID:        fmt.Sprintf("%d", now.UnixNano()),
+		Title:     in.Title,
+		Content:   in.Content,
+		Category:  category.Slug,
+		CreatedAt: now,
+		AuthorID:  currentUserID(ctx),
+		Language:  originalLang,
+
+		LastActivityAt: sql.NullTime{Time: now, Valid: true},
+		Approved:       !category.RequiresApproval || IsModerator(ctx),
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(displayThread)
-}
+	// Validate and store the images before touching the database so bad uploads fail fast
+	images, err := app.storeAttachments(ctx, in.Form)
+	if err != nil {
+		log.Debug().Err(err).Msg("Rejected thread attachments")
+		return Thread{}, &uploadError{err: err}
+	}
 
-// processCommentTranslationInBackground handles the translation and saving of translations
-func (app *App) processCommentTranslationInBackground(ctx context.Context, commentID string, originalContent string, isRussian bool) {
-	bgCtx := context.Background()
+	committed := false
+	defer func() {
+		if !committed {
+			images.Remove(context.WithoutCancel(ctx))
+		}
+	}()
 
 	tx, err := app.db.Begin()
 	if err != nil {
-		log.Error().Err(err).Str("comment_id", commentID).Msg("Error starting transaction for translation")
-		return
+		log.Error().Err(err).Msg("Error starting transaction")
+		return Thread{}, err
 	}
 	defer tx.Rollback()
 
 	qtx := app.queries.WithTx(tx)
 
-	var targetLang string
-	if isRussian {
-		targetLang = "en"
-	} else {
-		targetLang = "ru"
+	thread, err := qtx.CreateThread(ctx, threadParams)
+	if err != nil {
+		log.Error().Err(err).Interface("params", threadParams).Msg("Error creating thread")
+		return Thread{}, err
 	}
 
-	translation, err := app.translateText(bgCtx, originalContent, targetLang)
+	_, err = qtx.CreateThreadTranslation(ctx, sqlcdb.CreateThreadTranslationParams{
+		ID:       fmt.Sprintf("%d", time.Now().UnixNano()),
+		ThreadID: thread.ID,
+		Language: originalLang,
+		Title:    thread.Title,
+		Content:  thread.Content,
+
+		DetectedLanguage:    detection.Language,
+		DetectionConfidence: detection.Confidence,
+	})
 	if err != nil {
 		log.Error().Err(err).
-			Str("comment_id", commentID).
-			Str("target_lang", targetLang).
-			Msg("Error translating content")
-		return
+			Str("thread_id", thread.ID).
+			Str("language", originalLang).
+			Msg("Error creating thread translation")
+		return Thread{}, err
 	}
 
-	_, err = qtx.CreateCommentTranslation(bgCtx, sqlcdb.CreateCommentTranslationParams{
-		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
-		CommentID: commentID,
-		Language:  targetLang,
-		Content:   translation,
-	})
+	attachments, err := app.createThreadAttachments(ctx, qtx, thread.ID, images)
 	if err != nil {
-		log.Error().Err(err).
-			Str("comment_id", commentID).
-			Str("target_lang", targetLang).
-			Msg("Error saving translation")
-		return
+		log.Error().Err(err).Str("thread_id", thread.ID).Msg("Error creating thread attachments")
+		return Thread{}, err
 	}
 
-	log.Info().
-		Str("comment_id", commentID).
-		Str("target_lang", targetLang).
-		Msg("Translation saved")
+	for _, targetLang := range app.targetLanguages(originalLang) {
+		if err := enqueueTranslation(ctx, qtx, JobTargetThread, thread.ID, originalLang, targetLang); err != nil {
+			log.Error().Err(err).Str("thread_id", thread.ID).Msg("Error enqueueing thread translation")
+			return Thread{}, err
+		}
+	}
+
+	// Threads awaiting approval notify mentioned users once approved
+	var notifications []Notification
+	if thread.Approved {
+		author, _ := GetCurrentUser(ctx)
+		notifications, err = notifyThreadMentions(ctx, qtx, notificationPost{
+			ThreadID:    thread.ID,
+			ThreadTitle: thread.Title,
+			AuthorID:    thread.AuthorID,
+			AuthorName:  author.Username,
+		}, thread.Title+"\n\n"+thread.Content)
+		if err != nil {
+			log.Error().Err(err).Str("thread_id", thread.ID).Msg("Error creating notifications")
+			return Thread{}, err
+		}
+	}
 
 	if err := tx.Commit(); err != nil {
-		log.Error().Err(err).
-			Str("comment_id", commentID).
-			Msg("Error committing translation transaction")
-		return
+		log.Error().Err(err).Str("thread_id", thread.ID).Msg("Error committing transaction")
+		return Thread{}, err
+	}
+	committed = true
+
+	app.notifyTranslationWorkers()
+	app.deliverNotifications(ctx, notifications)
+
+	log.Info().
+		Str("thread_id", thread.ID).
+		Str("category", thread.Category).
+		Bool("approved", thread.Approved).
+		Int("attachments", len(attachments)).
+		Str("language", originalLang).
+		Float64("detection_confidence", detection.Confidence).
+		Msg("Thread created")
+
+	displayThread := Thread{
+		ID:          thread.ID,
+		Title:       thread.Title,
+		Content:     thread.Content,
+		ContentHTML: markdown.Render(thread.Content),
+		Category:    thread.Category,
+		AuthorID:    thread.AuthorID.String,
+		Pending:     !thread.Approved,
+		CreatedAt:   thread.CreatedAt,
+		Comments:    []Comment{},
+
+		Attachments: attachments,
+	}
+	if user, ok := GetCurrentUser(ctx); ok {
+		displayThread.AuthorName = user.Username
 	}
+
+	if thread.Approved {
+		app.events.publish(threadsTopic, EventThreadCreated, displayThread)
+	}
+	return displayThread, nil
 }
 
 // CreateComment handles the POST /api/threads/{id}/comments endpoint
 func (app *App) CreateComment(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	vars := mux.Vars(r)
-	threadID := vars["id"]
+	if !app.parseUploadForm(w, r) {
+		return
+	}
 
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		log.Error().Err(err).Msg("Error parsing multipart form")
-		http.Error(w, "Error parsing form", http.StatusBadRequest)
+	comment, err := app.createComment(r.Context(), commentInput{
+		ThreadID: mux.Vars(r)["id"],
+		ParentID: r.FormValue("parent_comment_id"),
+		Content:  r.FormValue("content"),
+		Lang:     r.FormValue("lang"),
+		Form:     r.MultipartForm,
+	})
+	if err != nil {
+		writeRequestError(w, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+// createComment validates and stores a new comment, queues its translations
+// and announces it to the thread's subscribers
+func (app *App) createComment(ctx context.Context, in commentInput) (Comment, error) {
+	threadID := in.ThreadID
+
+	thread, err := app.getVisibleThread(ctx, threadID)
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			log.Debug().Str("thread_id", threadID).Msg("Thread not found")
+			return Comment{}, &requestError{status: http.StatusNotFound, message: "Thread not found"}
+		}
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error getting thread")
+		return Comment{}, err
+	}
+
+	if thread.Locked && !IsModerator(ctx) {
+		log.Debug().Str("thread_id", threadID).Msg("Thread is locked")
+		return Comment{}, &requestError{status: http.StatusForbidden, message: "Thread is locked"}
+	}
+
+	category, err := app.getCategory(ctx, thread.Category)
+	if err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Str("category", thread.Category).Msg("Error getting category")
+		return Comment{}, err
+	}
+	if !CanPost(ctx, category.CommentRole) {
+		log.Debug().Str("thread_id", threadID).Str("role", category.CommentRole).Msg("Not allowed to comment in category")
+		return Comment{}, postingDenied(ctx, "You are not allowed to comment in this category")
+	}
+
+	parentID, depth, err := app.replyParent(ctx, threadID, in.ParentID)
+	if err != nil {
+		if errors.Is(err, errInvalidParent) || errors.Is(err, errReplyTooDeep) {
+			log.Debug().Err(err).Str("thread_id", threadID).Msg("Rejected reply")
+			return Comment{}, &requestError{status: http.StatusBadRequest, message: err.Error()}
+		}
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error getting parent comment")
+		return Comment{}, err
+	}
+
 	commentID := fmt.Sprintf("%d", time.Now().UnixNano())
-	originalContent := r.FormValue("content")
+	originalContent := in.Content
+	originalLang, detection := app.detectSourceLanguage(ctx, in.Lang, originalContent)
+
+	// Validate and store the images before touching the database so bad uploads fail fast
+	images, err := app.storeAttachments(ctx, in.Form)
+	if err != nil {
+		log.Debug().Err(err).Str("thread_id", threadID).Msg("Rejected comment attachments")
+		return Comment{}, &uploadError{err: err}
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			images.Remove(context.WithoutCancel(ctx))
+		}
+	}()
 
 	tx, err := app.db.Begin()
 	if err != nil {
 		log.Error().Err(err).Msg("Error starting transaction")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return Comment{}, err
 	}
 	defer tx.Rollback()
 
 	qtx := app.queries.WithTx(tx)
 
+	now := time.Now()
 	comment, err := qtx.CreateComment(ctx, sqlcdb.CreateCommentParams{
 		ID:        commentID,
 		ThreadID:  threadID,
-		CreatedAt: time.Now(),
+		CreatedAt: now,
+		AuthorID:  currentUserID(ctx),
+		Language:  originalLang,
+
+		ParentCommentID: parentID,
+		Depth:           depth,
+		LastActivityAt:  sql.NullTime{Time: now, Valid: true},
 	})
 	if err != nil {
 		log.Error().Err(err).
 			Str("thread_id", threadID).
 			Str("comment_id", commentID).
 			Msg("Error creating comment")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	isRussian := false
-	for _, r := range originalContent {
-		if r >= 0x0400 && r <= 0x04FF {
-			isRussian = true
-			break
-		}
+		return Comment{}, err
 	}
 
-	originalLang := "en"
-	if isRussian {
-		originalLang = "ru"
-	}
 	_, err = qtx.CreateCommentTranslation(ctx, sqlcdb.CreateCommentTranslationParams{
 		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
 		CommentID: comment.ID,
 		Language:  originalLang,
 		Content:   originalContent,
+
+		DetectedLanguage:    detection.Language,
+		DetectionConfidence: detection.Confidence,
 	})
 	if err != nil {
 		log.Error().Err(err).
 			Str("comment_id", comment.ID).
 			Str("language", originalLang).
 			Msg("Error creating comment translation")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return Comment{}, err
 	}
 
-	translations := make(map[string]string)
-	translations[originalLang] = originalContent
-
-	var imagePath string
-	file, header, err := r.FormFile("image")
-	if err == nil && file != nil {
-		defer file.Close()
+	if err := qtx.TouchThreadActivity(ctx, sqlcdb.TouchThreadActivityParams{
+		LastActivityAt: sql.NullTime{Time: comment.CreatedAt, Valid: true},
+		ID:             threadID,
+	}); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error updating thread activity")
+		return Comment{}, err
+	}
 
-		if err := os.MkdirAll(app.uploadsPath, 0755); err != nil {
-			log.Error().Err(err).Str("path", app.uploadsPath).Msg("Error creating uploads directory")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+	// A reply counts as activity on the comment it answers
+	if parentID.Valid {
+		if err := qtx.TouchCommentActivity(ctx, sqlcdb.TouchCommentActivityParams{
+			LastActivityAt: sql.NullTime{Time: comment.CreatedAt, Valid: true},
+			ID:             parentID.String,
+		}); err != nil {
+			log.Error().Err(err).Str("comment_id", parentID.String).Msg("Error updating comment activity")
+			return Comment{}, err
 		}
+	}
 
-		filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), header.Filename)
-		filepath := filepath.Join(app.uploadsPath, filename)
+	translations := make(map[string]string)
+	translations[originalLang] = originalContent
 
-		dst, err := os.Create(filepath)
-		if err != nil {
-			log.Error().Err(err).Str("path", filepath).Msg("Error creating file")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		defer dst.Close()
+	attachments, err := app.createCommentAttachments(ctx, qtx, comment.ID, images)
+	if err != nil {
+		log.Error().Err(err).Str("comment_id", comment.ID).Msg("Error creating comment attachments")
+		return Comment{}, err
+	}
 
-		if _, err := io.Copy(dst, file); err != nil {
-			log.Error().Err(err).Str("path", filepath).Msg("Error copying file")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+	for _, targetLang := range app.targetLanguages(originalLang) {
+		if err := enqueueTranslation(ctx, qtx, JobTargetComment, comment.ID, originalLang, targetLang); err != nil {
+			log.Error().Err(err).Str("comment_id", comment.ID).Msg("Error enqueueing comment translation")
+			return Comment{}, err
 		}
+	}
 
-		webPath := "/static/uploads/" + filename
-		_, err = qtx.CreateCommentImage(ctx, sqlcdb.CreateCommentImageParams{
-			ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
-			CommentID: comment.ID,
-			Filename:  filename,
-			Filepath:  webPath,
-			CreatedAt: time.Now(),
-		})
-		if err != nil {
-			log.Error().Err(err).
-				Str("comment_id", comment.ID).
-				Str("filename", filename).
-				Msg("Error creating comment image")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		imagePath = webPath
-		log.Debug().
-			Str("comment_id", comment.ID).
-			Str("path", webPath).
-			Msg("Image uploaded")
+	notifications, err := notifyComment(ctx, qtx, thread, comment, originalContent)
+	if err != nil {
+		log.Error().Err(err).Str("comment_id", comment.ID).Msg("Error creating notifications")
+		return Comment{}, err
 	}
 
 	if err := tx.Commit(); err != nil {
 		log.Error().Err(err).Str("comment_id", comment.ID).Msg("Error committing transaction")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return Comment{}, err
 	}
+	committed = true
 
-	go app.processCommentTranslationInBackground(ctx, comment.ID, originalContent, isRussian)
+	app.notifyTranslationWorkers()
+	app.deliverNotifications(ctx, notifications)
 
 	log.Info().
 		Str("comment_id", comment.ID).
 		Str("thread_id", comment.ThreadID).
-		Bool("has_image", imagePath != "").
+		Int("attachments", len(attachments)).
+		Str("language", originalLang).
+		Float64("detection_confidence", detection.Confidence).
 		Msg("Comment created")
 
 	response := Comment{
-		ID:        comment.ID,
-		ThreadID:  comment.ThreadID,
-		Content:   translations,
-		ImagePath: imagePath,
-		CreatedAt: comment.CreatedAt,
+		ID:          comment.ID,
+		ThreadID:    comment.ThreadID,
+		Content:     translations,
+		ContentHTML: map[string]string{originalLang: markdown.Render(originalContent)},
+
+		OriginalLanguage: comment.Language,
+		ParentCommentID:  comment.ParentCommentID.String,
+		Depth:            comment.Depth,
+		Attachments:      attachments,
+		AuthorID:         comment.AuthorID.String,
+		CreatedAt:        comment.CreatedAt,
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-// GetCategories handles the GET /api/categories endpoint
-func (app *App) GetCategories(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	lang := GetLanguage(ctx)
-
-	categories := GetLocalizedCategories()
-
-	var localizedCategories []LocalizedCategory
-	for _, cat := range categories {
-		label := cat.Label[lang]
-		if label == "" {
-			label = cat.Label["en"]
-		}
-
-		localizedCategories = append(localizedCategories, LocalizedCategory{
-			Value: cat.Value,
-			Label: label,
-		})
+	if len(attachments) > 0 {
+		response.ImagePath = attachments[0].URL
+	}
+	if user, ok := GetCurrentUser(ctx); ok {
+		response.AuthorName = user.Username
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(localizedCategories)
+	app.events.publish(threadTopic(comment.ThreadID), EventCommentCreated, response)
+	return response, nil
 }
 
-// GetLocalizedCategories returns the list of categories with translations
-func GetLocalizedCategories() []CategoryOption {
-	return []CategoryOption{
-		{
-			Value: "general",
-			Label: map[string]string{
-				"en": "General",
-				"ru": "Общее",
-			},
-		},
-		{
-			Value: "help",
-			Label: map[string]string{
-				"en": "Help",
-				"ru": "Помощь",
-			},
-		},
-		{
-			Value: "discussion",
-			Label: map[string]string{
-				"en": "Discussion",
-				"ru": "Обсуждение",
-			},
-		},
-		{
-			Value: "announcement",
-			Label: map[string]string{
-				"en": "Announcement",
-				"ru": "Объявление",
-			},
-		},
+// replyParent validates the comment a new comment replies to and returns it
+// along with the depth of the reply. An empty parentID starts a top-level comment.
+func (app *App) replyParent(ctx context.Context, threadID, parentID string) (sql.NullString, int64, error) {
+	if parentID == "" {
+		return sql.NullString{}, 0, nil
 	}
-}
 
-// ValidateCategory checks if a category is valid
-func ValidateCategory(category string) bool {
-	validCategories := GetLocalizedCategories()
-	for _, cat := range validCategories {
-		if cat.Value == category {
-			return true
+	parent, err := app.queries.GetComment(ctx, parentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return sql.NullString{}, 0, errInvalidParent
 		}
+		return sql.NullString{}, 0, err
+	}
+	if parent.ThreadID != threadID || (parent.Hidden && !IsModerator(ctx)) {
+		return sql.NullString{}, 0, errInvalidParent
+	}
+	if parent.Depth+1 > app.maxReplyDepth {
+		return sql.NullString{}, 0, errReplyTooDeep
 	}
-	return false
+	return sql.NullString{String: parent.ID, Valid: true}, parent.Depth + 1, nil
 }
@@ -2,36 +2,41 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"net/http"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+
+	"pkoforum/internal/langdetect"
 )
 
 type langContextKey string
 
 const (
 	LangContextKey langContextKey = "lang"
-	DefaultLang    string         = "en"
+	DefaultLang    string         = "en" // used when no language was resolved for the context
 )
 
-// Language middleware to extract language preference from request
-func LanguageMiddleware(next http.Handler) http.Handler {
+// LanguageMiddleware resolves the request language from the lang query parameter
+// or the Accept-Language header, limited to the configured languages
+func (app *App) LanguageMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Try to get language from query parameter
-		lang := r.URL.Query().Get("lang")
+		lang := app.matchLanguage(r.URL.Query().Get("lang"))
 
-		// If not in query, try to get from Accept-Language header
+		// If not in query, take the most preferred supported Accept-Language entry
 		if lang == "" {
-			acceptLang := r.Header.Get("Accept-Language")
-			if strings.Contains(strings.ToLower(acceptLang), "ru") {
-				lang = "ru"
-			} else {
-				lang = DefaultLang
+			for _, tag := range parseAcceptLanguage(r.Header.Get("Accept-Language")) {
+				if lang = app.matchLanguage(tag); lang != "" {
+					break
+				}
 			}
 		}
 
-		// Validate language
-		if lang != "ru" && lang != "en" {
-			lang = DefaultLang
+		if lang == "" {
+			lang = app.defaultLang
 		}
 
 		// Add language to context
@@ -48,21 +53,121 @@ func GetLanguage(ctx context.Context) string {
 	return DefaultLang
 }
 
-// GetLocalizedContent returns content in the requested language
-func GetLocalizedContent(content map[string]string, lang string) string {
-	if content == nil {
+// matchLanguage maps a language tag such as "pt-BR" onto a configured
+// language code. It returns "" when the language is not supported.
+func (app *App) matchLanguage(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
 		return ""
 	}
+	if slices.Contains(app.languages, tag) {
+		return tag
+	}
+	base, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	if slices.Contains(app.languages, base) {
+		return base
+	}
+	return ""
+}
+
+// targetLanguages returns every configured language other than the source,
+// or none when translation is disabled
+func (app *App) targetLanguages(source string) []string {
+	if app.translator == nil {
+		return nil
+	}
+	targets := make([]string, 0, len(app.languages))
+	for _, lang := range app.languages {
+		if lang != source {
+			targets = append(targets, lang)
+		}
+	}
+	return targets
+}
+
+// parseAcceptLanguage returns the language tags of an Accept-Language header,
+// most preferred first. Wildcards and entries with q=0 are dropped.
+func parseAcceptLanguage(header string) []string {
+	type weightedTag struct {
+		tag string
+		q   float64
+	}
+
+	var weighted []weightedTag
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(param, "=")
+			if !ok || strings.TrimSpace(key) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				parsed = 0
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+
+		weighted = append(weighted, weightedTag{tag: tag, q: q})
+	}
+
+	// Stable so that equally weighted tags keep the client's order
+	sort.SliceStable(weighted, func(i, j int) bool {
+		return weighted[i].q > weighted[j].q
+	})
 
-	if val, ok := content[lang]; ok && val != "" {
-		return val
+	tags := make([]string, 0, len(weighted))
+	for _, w := range weighted {
+		tags = append(tags, w.tag)
 	}
+	return tags
+}
 
-	// Fallback to default language if requested language is not available
-	if val, ok := content[DefaultLang]; ok {
-		return val
+// GetLocalizedContent returns content in the requested language, falling back
+// to each of the given languages in turn
+func GetLocalizedContent(content map[string]string, lang string, fallbacks ...string) string {
+	if content == nil {
+		return ""
+	}
+
+	for _, candidate := range append([]string{lang}, fallbacks...) {
+		if val, ok := content[candidate]; ok && val != "" {
+			return val
+		}
 	}
 
 	// If no content is available in any language
 	return ""
 }
+
+// localizedText returns the translation for the request language, falling back
+// to the original text while the translation is missing or still in progress
+func localizedText(original string, translated sql.NullString) string {
+	if translated.Valid && translated.String != "" {
+		return translated.String
+	}
+	return original
+}
+
+// detectSourceLanguage decides which configured language new content was
+// written in. The author's explicit choice wins, then a reliable detection,
+// then the request language. The detection is returned for storage either way.
+func (app *App) detectSourceLanguage(ctx context.Context, explicit, text string) (string, langdetect.Result) {
+	detection := app.detector.Detect(text)
+	if lang := app.matchLanguage(explicit); lang != "" {
+		return lang, detection
+	}
+	if detection.Reliable {
+		return detection.Language, detection
+	}
+	return GetLanguage(ctx), detection
+}
@@ -0,0 +1,676 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	sqlcdb "pkoforum/db/sqlc"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	RoleUser      string = "user"
+	RoleModerator string = "moderator"
+	RoleAdmin     string = "admin"
+
+	defaultModerationLogLimit int64 = 100
+	maxModerationLogLimit     int64 = 500
+)
+
+// roleRank orders roles so that higher roles inherit lower role permissions
+var roleRank = map[string]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+type ModerationLogEntry struct {
+	ID            string    `json:"id"`
+	ModeratorID   string    `json:"moderator_id"`
+	ModeratorName string    `json:"moderator_name,omitempty"`
+	Action        string    `json:"action"`
+	TargetType    string    `json:"target_type"`
+	TargetID      string    `json:"target_id"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type moderationRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ValidateRole checks if a role is valid
+func ValidateRole(role string) bool {
+	_, ok := roleRank[role]
+	return ok
+}
+
+// HasRole reports whether the authenticated user has at least the given role
+func HasRole(ctx context.Context, role string) bool {
+	user, ok := GetCurrentUser(ctx)
+	if !ok {
+		return false
+	}
+	return roleRank[user.Role] >= roleRank[role]
+}
+
+// IsModerator reports whether the authenticated user may moderate content
+func IsModerator(ctx context.Context) bool {
+	return HasRole(ctx, RoleModerator)
+}
+
+// RequireRole rejects requests from users below the given role
+func RequireRole(role string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := GetCurrentUser(r.Context()); !ok {
+				http.Error(w, "Not authenticated", http.StatusUnauthorized)
+				return
+			}
+			if !HasRole(r.Context(), role) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// decodeModerationRequest reads the optional reason from the request body
+func decodeModerationRequest(r *http.Request) (moderationRequest, error) {
+	var req moderationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return req, err
+	}
+	return req, nil
+}
+
+// logModeration records a moderation action in the audit log
+func logModeration(ctx context.Context, qtx *sqlcdb.Queries, action, targetType, targetID, reason string) error {
+	moderator, _ := GetCurrentUser(ctx)
+	_, err := qtx.CreateModerationLog(ctx, sqlcdb.CreateModerationLogParams{
+		ID:          fmt.Sprintf("%d", time.Now().UnixNano()),
+		ModeratorID: moderator.ID,
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Reason:      reason,
+		CreatedAt:   time.Now(),
+	})
+	return err
+}
+
+// ModerateThread handles the POST /api/mod/threads/{id}/{action} endpoint
+func (app *App) ModerateThread(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	threadID := vars["id"]
+	action := vars["action"]
+
+	req, err := decodeModerationRequest(r)
+	if err != nil {
+		log.Error().Err(err).Msg("Error decoding request body")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	previous, err := qtx.GetThread(ctx, sqlcdb.GetThreadParams{Language: GetLanguage(ctx), ID: threadID})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Thread not found", http.StatusNotFound)
+			return
+		}
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error getting thread")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	approved, hidden := previous.Approved, previous.Hidden
+
+	var affected int64
+	switch action {
+	case "pin", "unpin":
+		affected, err = qtx.SetThreadPinned(ctx, sqlcdb.SetThreadPinnedParams{Pinned: action == "pin", ID: threadID})
+	case "lock", "unlock":
+		affected, err = qtx.SetThreadLocked(ctx, sqlcdb.SetThreadLockedParams{Locked: action == "lock", ID: threadID})
+	case "hide", "unhide":
+		hidden = action == "hide"
+		affected, err = qtx.SetThreadHidden(ctx, sqlcdb.SetThreadHiddenParams{Hidden: hidden, ID: threadID})
+	case "approve", "unapprove":
+		approved = action == "approve"
+		affected, err = qtx.SetThreadApproved(ctx, sqlcdb.SetThreadApprovedParams{Approved: approved, ID: threadID})
+	default:
+		http.Error(w, "Unknown moderation action", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Str("action", action).Msg("Error moderating thread")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if affected == 0 {
+		// Approving an approved thread (or the reverse) changes nothing
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := logModeration(ctx, qtx, action, "thread", threadID, req.Reason); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error writing moderation log")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var notifications []Notification
+	if action == "approve" {
+		notifications, err = notifyApprovedThread(ctx, qtx, threadID)
+		if err != nil {
+			log.Error().Err(err).Str("thread_id", threadID).Msg("Error creating notifications")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.deliverNotifications(ctx, notifications)
+	app.publishThreadUpdate(threadID, "", action)
+	// Announce the thread only when it just became visible to everyone
+	if !(previous.Approved && !previous.Hidden) && approved && !hidden {
+		app.publishThreadCreated(ctx, threadID)
+	}
+
+	log.Info().Str("thread_id", threadID).Str("action", action).Msg("Thread moderated")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ModerateComment handles the POST /api/mod/comments/{id}/{action} endpoint
+func (app *App) ModerateComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	commentID := vars["id"]
+	action := vars["action"]
+
+	req, err := decodeModerationRequest(r)
+	if err != nil {
+		log.Error().Err(err).Msg("Error decoding request body")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	comment, err := qtx.GetComment(ctx, commentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Comment not found", http.StatusNotFound)
+			return
+		}
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error getting comment")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var affected int64
+	switch action {
+	case "hide", "unhide":
+		affected, err = qtx.SetCommentHidden(ctx, sqlcdb.SetCommentHiddenParams{Hidden: action == "hide", ID: commentID})
+	default:
+		http.Error(w, "Unknown moderation action", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("comment_id", commentID).Str("action", action).Msg("Error moderating comment")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if affected == 0 {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+
+	if err := logModeration(ctx, qtx, action, "comment", commentID, req.Reason); err != nil {
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error writing moderation log")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.publishThreadUpdate(comment.ThreadID, commentID, action)
+
+	log.Info().Str("comment_id", commentID).Str("action", action).Msg("Comment moderated")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ModDeleteThread handles the DELETE /api/mod/threads/{id} endpoint
+func (app *App) ModDeleteThread(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	threadID := mux.Vars(r)["id"]
+
+	req, err := decodeModerationRequest(r)
+	if err != nil {
+		log.Error().Err(err).Msg("Error decoding request body")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	files, err := deleteThreadTx(ctx, qtx, threadID)
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			http.Error(w, "Thread not found", http.StatusNotFound)
+			return
+		}
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error deleting thread")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := logModeration(ctx, qtx, "delete", "thread", threadID, req.Reason); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error writing moderation log")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.removeImageFiles(context.WithoutCancel(ctx), files)
+	app.publishThreadUpdate(threadID, "", "delete")
+
+	log.Info().Str("thread_id", threadID).Int("files", len(files)).Msg("Thread deleted")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ModDeleteComment handles the DELETE /api/mod/comments/{id} endpoint
+func (app *App) ModDeleteComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commentID := mux.Vars(r)["id"]
+
+	req, err := decodeModerationRequest(r)
+	if err != nil {
+		log.Error().Err(err).Msg("Error decoding request body")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	comment, err := qtx.GetComment(ctx, commentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Comment not found", http.StatusNotFound)
+			return
+		}
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error getting comment")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	files, err := deleteCommentTx(ctx, qtx, commentID)
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			http.Error(w, "Comment not found", http.StatusNotFound)
+			return
+		}
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error deleting comment")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := logModeration(ctx, qtx, "delete", "comment", commentID, req.Reason); err != nil {
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error writing moderation log")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("comment_id", commentID).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.removeImageFiles(context.WithoutCancel(ctx), files)
+	app.publishThreadUpdate(comment.ThreadID, commentID, "delete")
+
+	log.Info().Str("comment_id", commentID).Int("files", len(files)).Msg("Comment deleted")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetModerationLog handles the GET /api/mod/log endpoint
+func (app *App) GetModerationLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit := defaultModerationLogLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxModerationLogLimit)
+	}
+
+	rows, err := app.queries.ListModerationLog(ctx, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Error listing moderation log")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	entries := make([]ModerationLogEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, ModerationLogEntry{
+			ID:            row.ID,
+			ModeratorID:   row.ModeratorID,
+			ModeratorName: row.ModeratorName.String,
+			Action:        row.Action,
+			TargetType:    row.TargetType,
+			TargetID:      row.TargetID,
+			Reason:        row.Reason,
+			CreatedAt:     row.CreatedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// SetUserRole handles the PUT /api/mod/users/{id}/role endpoint
+func (app *App) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := mux.Vars(r)["id"]
+
+	var req struct {
+		Role   string `json:"role"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error().Err(err).Msg("Error decoding request body")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !ValidateRole(req.Role) {
+		http.Error(w, "Invalid role", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	affected, err := qtx.SetUserRole(ctx, sqlcdb.SetUserRoleParams{Role: req.Role, ID: userID})
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Error setting user role")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if affected == 0 {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if err := logModeration(ctx, qtx, "set_role:"+req.Role, "user", userID, req.Reason); err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Error writing moderation log")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Info().Str("user_id", userID).Str("role", req.Role).Msg("User role changed")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteThreadTx removes a thread with all of its comments and their dependent rows.
+// It returns the image files that should be removed once the transaction commits.
+func deleteThreadTx(ctx context.Context, qtx *sqlcdb.Queries, threadID string) ([]string, error) {
+	images, err := qtx.ListThreadCommentImages(ctx, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("listing comment images: %w", err)
+	}
+	variants, err := qtx.ListThreadCommentImageVariants(ctx, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("listing comment image variants: %w", err)
+	}
+	threadImages, err := qtx.ListThreadImages(ctx, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("listing thread images: %w", err)
+	}
+	threadVariants, err := qtx.ListThreadImageVariants(ctx, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("listing thread image variants: %w", err)
+	}
+	if err := qtx.DeleteTranslationJobs(ctx, sqlcdb.DeleteTranslationJobsParams{
+		TargetType: JobTargetThread,
+		TargetID:   threadID,
+	}); err != nil {
+		return nil, fmt.Errorf("deleting thread translation jobs: %w", err)
+	}
+	if err := qtx.DeleteThreadCommentTranslationJobs(ctx, threadID); err != nil {
+		return nil, fmt.Errorf("deleting comment translation jobs: %w", err)
+	}
+	if err := qtx.DeleteThreadTranslations(ctx, threadID); err != nil {
+		return nil, fmt.Errorf("deleting thread translations: %w", err)
+	}
+	if err := qtx.DeleteThreadCommentTranslations(ctx, threadID); err != nil {
+		return nil, fmt.Errorf("deleting comment translations: %w", err)
+	}
+	if err := qtx.DeleteRevisions(ctx, sqlcdb.DeleteRevisionsParams{
+		TargetType: RevisionTargetThread,
+		TargetID:   threadID,
+	}); err != nil {
+		return nil, fmt.Errorf("deleting thread revisions: %w", err)
+	}
+	if err := qtx.DeleteThreadCommentRevisions(ctx, threadID); err != nil {
+		return nil, fmt.Errorf("deleting comment revisions: %w", err)
+	}
+	if err := qtx.DeleteReactions(ctx, sqlcdb.DeleteReactionsParams{
+		TargetType: ReactionTargetThread,
+		TargetID:   threadID,
+	}); err != nil {
+		return nil, fmt.Errorf("deleting thread reactions: %w", err)
+	}
+	if err := qtx.DeleteThreadCommentReactions(ctx, threadID); err != nil {
+		return nil, fmt.Errorf("deleting comment reactions: %w", err)
+	}
+	if err := qtx.DeleteThreadNotifications(ctx, threadID); err != nil {
+		return nil, fmt.Errorf("deleting thread notifications: %w", err)
+	}
+	if err := qtx.DeleteThreadSubscriptions(ctx, threadID); err != nil {
+		return nil, fmt.Errorf("deleting thread subscriptions: %w", err)
+	}
+	if err := qtx.DeleteThreadCommentImageVariants(ctx, threadID); err != nil {
+		return nil, fmt.Errorf("deleting comment image variants: %w", err)
+	}
+	if err := qtx.DeleteThreadCommentImages(ctx, threadID); err != nil {
+		return nil, fmt.Errorf("deleting comment images: %w", err)
+	}
+	if err := qtx.DeleteThreadImageVariants(ctx, threadID); err != nil {
+		return nil, fmt.Errorf("deleting thread image variants: %w", err)
+	}
+	if err := qtx.DeleteThreadImages(ctx, threadID); err != nil {
+		return nil, fmt.Errorf("deleting thread images: %w", err)
+	}
+	if err := qtx.DeleteThreadComments(ctx, threadID); err != nil {
+		return nil, fmt.Errorf("deleting comments: %w", err)
+	}
+	affected, err := qtx.DeleteThread(ctx, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("deleting thread: %w", err)
+	}
+	if affected == 0 {
+		return nil, errNotFound
+	}
+	return append(imageFilenames(images, variants), threadImageFilenames(threadImages, threadVariants)...), nil
+}
+
+// deleteCommentTx removes a comment and its dependent rows.
+// It returns the image files that should be removed once the transaction commits.
+func deleteCommentTx(ctx context.Context, qtx *sqlcdb.Queries, commentID string) ([]string, error) {
+	comment, err := qtx.GetComment(ctx, commentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errNotFound
+		}
+		return nil, fmt.Errorf("getting comment: %w", err)
+	}
+	images, err := qtx.ListCommentImages(ctx, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("listing comment images: %w", err)
+	}
+	variants, err := qtx.ListCommentImageVariants(ctx, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("listing comment image variants: %w", err)
+	}
+	if err := qtx.DeleteTranslationJobs(ctx, sqlcdb.DeleteTranslationJobsParams{
+		TargetType: JobTargetComment,
+		TargetID:   commentID,
+	}); err != nil {
+		return nil, fmt.Errorf("deleting comment translation jobs: %w", err)
+	}
+	if err := qtx.DeleteCommentTranslations(ctx, commentID); err != nil {
+		return nil, fmt.Errorf("deleting comment translations: %w", err)
+	}
+	if err := qtx.DeleteRevisions(ctx, sqlcdb.DeleteRevisionsParams{
+		TargetType: RevisionTargetComment,
+		TargetID:   commentID,
+	}); err != nil {
+		return nil, fmt.Errorf("deleting comment revisions: %w", err)
+	}
+	if err := qtx.DeleteReactions(ctx, sqlcdb.DeleteReactionsParams{
+		TargetType: ReactionTargetComment,
+		TargetID:   commentID,
+	}); err != nil {
+		return nil, fmt.Errorf("deleting comment reactions: %w", err)
+	}
+	if err := qtx.ClearAcceptedComment(ctx, sql.NullString{String: commentID, Valid: true}); err != nil {
+		return nil, fmt.Errorf("clearing accepted comment: %w", err)
+	}
+	if err := qtx.DeleteCommentNotifications(ctx, sql.NullString{String: commentID, Valid: true}); err != nil {
+		return nil, fmt.Errorf("deleting comment notifications: %w", err)
+	}
+	// Replies move up a level rather than disappearing with the comment
+	if err := qtx.DecrementReplyDepths(ctx, commentID); err != nil {
+		return nil, fmt.Errorf("updating reply depths: %w", err)
+	}
+	if err := qtx.ReparentCommentReplies(ctx, sqlcdb.ReparentCommentRepliesParams{
+		NewParentID: comment.ParentCommentID,
+		CommentID:   commentID,
+	}); err != nil {
+		return nil, fmt.Errorf("reparenting replies: %w", err)
+	}
+	if err := qtx.DeleteCommentImageVariants(ctx, commentID); err != nil {
+		return nil, fmt.Errorf("deleting comment image variants: %w", err)
+	}
+	if err := qtx.DeleteCommentImages(ctx, commentID); err != nil {
+		return nil, fmt.Errorf("deleting comment images: %w", err)
+	}
+	affected, err := qtx.DeleteComment(ctx, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("deleting comment: %w", err)
+	}
+	if affected == 0 {
+		return nil, errNotFound
+	}
+	return imageFilenames(images, variants), nil
+}
+
+// imageFilenames collects the files backing comment images and their variants
+func imageFilenames(images []sqlcdb.CommentImage, variants []sqlcdb.CommentImageVariant) []string {
+	filenames := make([]string, 0, len(images)+len(variants))
+	for _, img := range images {
+		filenames = append(filenames, img.Filename)
+	}
+	for _, v := range variants {
+		filenames = append(filenames, v.Filename)
+	}
+	return filenames
+}
+
+// threadImageFilenames collects the files backing thread images and their variants
+func threadImageFilenames(images []sqlcdb.ThreadImage, variants []sqlcdb.ThreadImageVariant) []string {
+	filenames := make([]string, 0, len(images)+len(variants))
+	for _, img := range images {
+		filenames = append(filenames, img.Filename)
+	}
+	for _, v := range variants {
+		filenames = append(filenames, v.Filename)
+	}
+	return filenames
+}
+
+// removeImageFiles deletes uploaded image blobs, logging rather than failing on errors
+func (app *App) removeImageFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := app.blobs.Delete(ctx, filepath.Base(key)); err != nil {
+			log.Warn().Err(err).Str("key", key).Msg("Error removing image file")
+		}
+	}
+}
@@ -0,0 +1,529 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	sqlcdb "pkoforum/db/sqlc"
+	"pkoforum/internal/markdown"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// Kinds of notifications, from the most to the least specific. A user who has
+// several reasons to hear about a post gets one notification of the first kind.
+const (
+	NotificationMention      string = "mention"       // the post mentions the user
+	NotificationCommentReply string = "comment_reply" // a reply to the user's comment
+	NotificationThreadReply  string = "thread_reply"  // a comment in the user's thread
+	NotificationSubscription string = "subscription"  // a comment in a thread the user follows
+)
+
+const (
+	defaultNotificationPageSize int64 = 30
+
+	// maxMentions caps how many users one post can notify by mentioning them
+	maxMentions = 10
+)
+
+// Notification is something that happened on the forum a user should know about
+type Notification struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	Kind        string     `json:"kind"`
+	ThreadID    string     `json:"thread_id"`
+	ThreadTitle string     `json:"thread_title"`
+	CommentID   string     `json:"comment_id,omitempty"`
+	ActorID     string     `json:"actor_id,omitempty"`
+	ActorName   string     `json:"actor_name,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+}
+
+// NotificationPage is the response of the notification listing
+type NotificationPage struct {
+	Page[Notification]
+	// Unread counts all unread notifications, not only those on the page
+	Unread int64 `json:"unread"`
+}
+
+// ThreadSubscription tells whether the current user follows a thread
+type ThreadSubscription struct {
+	ThreadID   string `json:"thread_id"`
+	Subscribed bool   `json:"subscribed"`
+}
+
+// NotificationHook delivers notifications through another channel, such as
+// e-mail or webhooks. Deliver is called once the notification is stored.
+type NotificationHook interface {
+	Deliver(ctx context.Context, notification Notification) error
+}
+
+// notificationHooks holds the registered delivery hooks
+type notificationHooks struct {
+	mu    sync.RWMutex
+	hooks []NotificationHook
+}
+
+// AddNotificationHook registers a hook that is called for every new notification
+func (app *App) AddNotificationHook(hook NotificationHook) {
+	app.notificationHooks.mu.Lock()
+	defer app.notificationHooks.mu.Unlock()
+	app.notificationHooks.hooks = append(app.notificationHooks.hooks, hook)
+}
+
+// deliverNotifications hands notifications to the delivery hooks in the
+// background so slow channels do not hold up the request
+func (app *App) deliverNotifications(ctx context.Context, notifications []Notification) {
+	app.notificationHooks.mu.RLock()
+	hooks := app.notificationHooks.hooks
+	app.notificationHooks.mu.RUnlock()
+	if len(hooks) == 0 || len(notifications) == 0 {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		for _, notification := range notifications {
+			for _, hook := range hooks {
+				if err := hook.Deliver(ctx, notification); err != nil {
+					log.Error().Err(err).
+						Str("notification_id", notification.ID).
+						Str("user_id", notification.UserID).
+						Msg("Error delivering notification")
+				}
+			}
+		}
+	}()
+}
+
+// notificationPost identifies the thread or comment a notification is about
+// and who wrote it
+type notificationPost struct {
+	ThreadID    string
+	ThreadTitle string
+	CommentID   sql.NullString
+	AuthorID    sql.NullString
+	AuthorName  string
+}
+
+// notificationRecipients collects who to notify about a post, keeping the
+// most specific kind for each user
+type notificationRecipients struct {
+	order []string
+	kinds map[string]string
+}
+
+func (r *notificationRecipients) add(userID, kind string) {
+	if r.kinds == nil {
+		r.kinds = make(map[string]string)
+	}
+	if _, ok := r.kinds[userID]; ok {
+		return
+	}
+	r.kinds[userID] = kind
+	r.order = append(r.order, userID)
+}
+
+// addMentions adds the existing users mentioned in content
+func (r *notificationRecipients) addMentions(ctx context.Context, qtx *sqlcdb.Queries, content string) error {
+	names := markdown.Mentions(content)
+	if len(names) > maxMentions {
+		names = names[:maxMentions]
+	}
+	for _, name := range names {
+		user, err := qtx.GetUserByUsername(ctx, name)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("getting mentioned user: %w", err)
+		}
+		r.add(user.ID, NotificationMention)
+	}
+	return nil
+}
+
+// createNotifications stores a notification about a post for each recipient
+// other than its author and returns those that were new
+func createNotifications(ctx context.Context, qtx *sqlcdb.Queries, recipients notificationRecipients, post notificationPost) ([]Notification, error) {
+	now := time.Now()
+
+	var created []Notification
+	for _, userID := range recipients.order {
+		if post.AuthorID.Valid && userID == post.AuthorID.String {
+			continue
+		}
+		notification := Notification{
+			ID:          fmt.Sprintf("%d", time.Now().UnixNano()),
+			UserID:      userID,
+			Kind:        recipients.kinds[userID],
+			ThreadID:    post.ThreadID,
+			ThreadTitle: post.ThreadTitle,
+			CommentID:   post.CommentID.String,
+			ActorID:     post.AuthorID.String,
+			ActorName:   post.AuthorName,
+			CreatedAt:   now,
+		}
+		affected, err := qtx.CreateNotification(ctx, sqlcdb.CreateNotificationParams{
+			ID:        notification.ID,
+			UserID:    userID,
+			Kind:      notification.Kind,
+			ThreadID:  post.ThreadID,
+			CommentID: post.CommentID,
+			ActorID:   post.AuthorID,
+			CreatedAt: now,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("creating notification: %w", err)
+		}
+		// Already notified about this post, e.g. when a thread is approved again
+		if affected == 0 {
+			continue
+		}
+		created = append(created, notification)
+	}
+	return created, nil
+}
+
+// notifyComment notifies the users mentioned in a new comment, the author of
+// the comment it replies to, the thread's author and its subscribers
+func notifyComment(ctx context.Context, qtx *sqlcdb.Queries, thread sqlcdb.GetThreadRow, comment sqlcdb.Comment, content string) ([]Notification, error) {
+	// Nobody else can see posts in hidden or unapproved threads yet
+	if thread.Hidden || !thread.Approved {
+		return nil, nil
+	}
+
+	var recipients notificationRecipients
+	if err := recipients.addMentions(ctx, qtx, content); err != nil {
+		return nil, err
+	}
+
+	if comment.ParentCommentID.Valid {
+		parent, err := qtx.GetComment(ctx, comment.ParentCommentID.String)
+		if err != nil {
+			return nil, fmt.Errorf("getting parent comment: %w", err)
+		}
+		if parent.AuthorID.Valid {
+			recipients.add(parent.AuthorID.String, NotificationCommentReply)
+		}
+	}
+
+	// Authors follow their threads unless they unsubscribed
+	if thread.AuthorID.Valid {
+		subscribed, err := threadSubscribed(ctx, qtx, thread.AuthorID.String, thread.ID, thread.AuthorID)
+		if err != nil {
+			return nil, err
+		}
+		if subscribed {
+			recipients.add(thread.AuthorID.String, NotificationThreadReply)
+		}
+	}
+
+	subscribers, err := qtx.ListThreadSubscribers(ctx, thread.ID)
+	if err != nil {
+		return nil, fmt.Errorf("listing thread subscribers: %w", err)
+	}
+	for _, userID := range subscribers {
+		recipients.add(userID, NotificationSubscription)
+	}
+
+	author, _ := GetCurrentUser(ctx)
+	return createNotifications(ctx, qtx, recipients, notificationPost{
+		ThreadID:    thread.ID,
+		ThreadTitle: thread.Title,
+		CommentID:   sql.NullString{String: comment.ID, Valid: true},
+		AuthorID:    comment.AuthorID,
+		AuthorName:  author.Username,
+	})
+}
+
+// notifyThreadMentions notifies the users mentioned in a thread. It is called
+// when the thread becomes visible to everyone, which may be long after it was
+// posted if it needed approval.
+func notifyThreadMentions(ctx context.Context, qtx *sqlcdb.Queries, post notificationPost, content string) ([]Notification, error) {
+	var recipients notificationRecipients
+	if err := recipients.addMentions(ctx, qtx, content); err != nil {
+		return nil, err
+	}
+	return createNotifications(ctx, qtx, recipients, post)
+}
+
+// notifyApprovedThread notifies the users mentioned in a thread a moderator
+// just approved
+func notifyApprovedThread(ctx context.Context, qtx *sqlcdb.Queries, threadID string) ([]Notification, error) {
+	thread, err := qtx.GetThread(ctx, sqlcdb.GetThreadParams{Language: GetLanguage(ctx), ID: threadID})
+	if err != nil {
+		return nil, fmt.Errorf("getting thread: %w", err)
+	}
+	if thread.Hidden {
+		return nil, nil
+	}
+	return notifyThreadMentions(ctx, qtx, notificationPost{
+		ThreadID:    thread.ID,
+		ThreadTitle: thread.Title,
+		AuthorID:    thread.AuthorID,
+		AuthorName:  thread.AuthorName.String,
+	}, thread.Title+"\n\n"+thread.Content)
+}
+
+// threadSubscribed reports whether a user follows a thread: explicitly, or
+// implicitly as its author
+func threadSubscribed(ctx context.Context, qtx Querier, userID, threadID string, authorID sql.NullString) (bool, error) {
+	subscription, err := qtx.GetThreadSubscription(ctx, sqlcdb.GetThreadSubscriptionParams{
+		UserID:   userID,
+		ThreadID: threadID,
+	})
+	if err == sql.ErrNoRows {
+		return authorID.Valid && authorID.String == userID, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("getting thread subscription: %w", err)
+	}
+	return subscription.Subscribed, nil
+}
+
+// GetNotifications handles the GET /api/notifications endpoint. Pass
+// unread=true to only list unread notifications.
+func (app *App) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := GetCurrentUser(ctx)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	limit, cursor, err := parsePageParams(r, defaultNotificationPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params := sqlcdb.ListNotificationsParams{
+		Language:      GetLanguage(ctx),
+		UserID:        user.ID,
+		IncludeHidden: IsModerator(ctx),
+		UnreadOnly:    r.URL.Query().Get("unread") == "true",
+		Limit:         limit + 1,
+	}
+	if cursor != nil {
+		params.HasCursor = true
+		params.CursorCreatedAt = cursor.CreatedAt
+		params.CursorID = cursor.ID
+	}
+
+	rows, err := app.queries.ListNotifications(ctx, params)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Error listing notifications")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	unread, err := app.queries.CountUnreadNotifications(ctx, sqlcdb.CountUnreadNotificationsParams{
+		UserID:        user.ID,
+		IncludeHidden: IsModerator(ctx),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Error counting unread notifications")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var nextCursor string
+	if int64(len(rows)) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		nextCursor = encodeCursor(pageCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	notifications := make([]Notification, 0, len(rows))
+	for _, row := range rows {
+		notification := Notification{
+			ID:          row.ID,
+			UserID:      row.UserID,
+			Kind:        row.Kind,
+			ThreadID:    row.ThreadID,
+			ThreadTitle: row.ThreadTitle,
+			CommentID:   row.CommentID.String,
+			ActorID:     row.ActorID.String,
+			ActorName:   row.ActorName.String,
+			CreatedAt:   row.CreatedAt,
+			ReadAt:      nullTime(row.ReadAt),
+		}
+		if row.TranslatedTitle.Valid {
+			notification.ThreadTitle = row.TranslatedTitle.String
+		}
+		notifications = append(notifications, notification)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(NotificationPage{
+		Page:   Page[Notification]{Data: notifications, NextCursor: nextCursor},
+		Unread: unread,
+	})
+}
+
+type notificationReadRequest struct {
+	Read *bool `json:"read"`
+}
+
+// decodeReadRequest reads the read flag of the notification update endpoints
+func decodeReadRequest(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	var req notificationReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error().Err(err).Msg("Error decoding request body")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false, false
+	}
+	if req.Read == nil {
+		http.Error(w, "read is required", http.StatusBadRequest)
+		return false, false
+	}
+	return *req.Read, true
+}
+
+// MarkNotificationsRead handles the PATCH /api/notifications endpoint, marking
+// all of the current user's notifications read
+func (app *App) MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := GetCurrentUser(ctx)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	read, ok := decodeReadRequest(w, r)
+	if !ok {
+		return
+	}
+	if !read {
+		http.Error(w, "Only marking all notifications read is supported", http.StatusBadRequest)
+		return
+	}
+
+	affected, err := app.queries.MarkAllNotificationsRead(ctx, sqlcdb.MarkAllNotificationsReadParams{
+		ReadAt: sql.NullTime{Time: time.Now(), Valid: true},
+		UserID: user.ID,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Error marking notifications read")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Debug().Str("user_id", user.ID).Int64("count", affected).Msg("Notifications marked read")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateNotification handles the PATCH /api/notifications/{id} endpoint,
+// marking one notification read or unread
+func (app *App) UpdateNotification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	notificationID := mux.Vars(r)["id"]
+
+	user, ok := GetCurrentUser(ctx)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	read, ok := decodeReadRequest(w, r)
+	if !ok {
+		return
+	}
+
+	params := sqlcdb.SetNotificationReadParams{ID: notificationID, UserID: user.ID}
+	if read {
+		params.ReadAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	affected, err := app.queries.SetNotificationRead(ctx, params)
+	if err != nil {
+		log.Error().Err(err).Str("notification_id", notificationID).Msg("Error updating notification")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if affected == 0 {
+		http.Error(w, "Notification not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetThreadSubscription handles the GET /api/threads/{id}/subscription endpoint
+func (app *App) GetThreadSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	threadID := mux.Vars(r)["id"]
+
+	user, ok := GetCurrentUser(ctx)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	thread, err := app.getVisibleThread(ctx, threadID)
+	if err != nil {
+		writeEditError(w, err, "Thread not found")
+		return
+	}
+
+	subscribed, err := threadSubscribed(ctx, app.queries, user.ID, threadID, thread.AuthorID)
+	if err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error getting thread subscription")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ThreadSubscription{ThreadID: threadID, Subscribed: subscribed})
+}
+
+// Subscribe handles the PUT /api/threads/{id}/subscription endpoint
+func (app *App) Subscribe(w http.ResponseWriter, r *http.Request) {
+	app.setThreadSubscription(w, r, true)
+}
+
+// Unsubscribe handles the DELETE /api/threads/{id}/subscription endpoint
+func (app *App) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	app.setThreadSubscription(w, r, false)
+}
+
+// setThreadSubscription follows or unfollows a thread for the current user
+func (app *App) setThreadSubscription(w http.ResponseWriter, r *http.Request, subscribed bool) {
+	ctx := r.Context()
+	threadID := mux.Vars(r)["id"]
+
+	user, ok := GetCurrentUser(ctx)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	if _, err := app.getVisibleThread(ctx, threadID); err != nil {
+		writeEditError(w, err, "Thread not found")
+		return
+	}
+
+	// Unsubscribing is stored too, so authors can stop following their threads
+	if err := app.queries.UpsertThreadSubscription(ctx, sqlcdb.UpsertThreadSubscriptionParams{
+		UserID:     user.ID,
+		ThreadID:   threadID,
+		Subscribed: subscribed,
+		CreatedAt:  time.Now(),
+	}); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error saving thread subscription")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ThreadSubscription{ThreadID: threadID, Subscribed: subscribed})
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultThreadPageSize  int64 = 20
+	defaultCommentPageSize int64 = 50
+	maxPageSize            int64 = 100
+)
+
+// Orders threads and comments can be listed in
+const (
+	SortNew    string = "new"    // newest first
+	SortOld    string = "old"    // oldest first, only for comments
+	SortTop    string = "top"    // highest score first
+	SortActive string = "active" // most recently posted to or replied to first
+)
+
+// Sort options of each listing; the first is the default
+var (
+	threadSorts  = []string{SortNew, SortTop, SortActive}
+	commentSorts = []string{SortOld, SortNew, SortTop, SortActive}
+)
+
+// Page is the response envelope for paginated listings
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// pageCursor is the keyset position of the last item on a page.
+// Pinned is only meaningful for thread listings; Score and ActiveAt are set
+// for the listings sorted by them.
+type pageCursor struct {
+	Sort      string    `json:"o,omitempty"`
+	Pinned    bool      `json:"p,omitempty"`
+	Score     int64     `json:"s,omitempty"`
+	ActiveAt  time.Time `json:"a,omitzero"`
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// encodeCursor turns a keyset position into an opaque URL-safe token
+func encodeCursor(c pageCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor parses a token produced by encodeCursor
+func decodeCursor(token string) (pageCursor, error) {
+	var c pageCursor
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// parsePageParams reads the limit and cursor query parameters.
+// The returned cursor is nil when the first page is requested.
+func parsePageParams(r *http.Request, defaultLimit int64) (int64, *pageCursor, error) {
+	query := r.URL.Query()
+
+	limit := defaultLimit
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 1 {
+			return 0, nil, fmt.Errorf("invalid limit")
+		}
+		limit = min(n, maxPageSize)
+	}
+
+	token := query.Get("cursor")
+	if token == "" {
+		return limit, nil, nil
+	}
+
+	cursor, err := decodeCursor(token)
+	if err != nil {
+		return 0, nil, err
+	}
+	return limit, &cursor, nil
+}
+
+// parseSort reads the sort query parameter, defaulting to the first of sorts,
+// and checks that cursor, if any, was issued for the same order
+func parseSort(r *http.Request, cursor *pageCursor, sorts []string) (string, error) {
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = sorts[0]
+	}
+	if !slices.Contains(sorts, sort) {
+		return "", fmt.Errorf("sort must be one of %s", strings.Join(sorts, ", "))
+	}
+
+	// Cursors issued before sorting existed belong to the default order
+	if cursor != nil && cmp.Or(cursor.Sort, sorts[0]) != sort {
+		return "", fmt.Errorf("cursor does not match sort")
+	}
+	return sort, nil
+}
+
+// activeAt returns the activity time the active sort orders by, falling back
+// to the creation time of items nobody has replied to
+func activeAt(lastActivityAt sql.NullTime, createdAt time.Time) time.Time {
+	if lastActivityAt.Valid {
+		return lastActivityAt.Time
+	}
+	return createdAt
+}
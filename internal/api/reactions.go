@@ -0,0 +1,248 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	sqlcdb "pkoforum/db/sqlc"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	ReactionTargetThread  string = "thread"
+	ReactionTargetComment string = "comment"
+
+	// Votes count towards a post's score; a user's up and down votes exclude each other
+	ReactionUp   string = "up"
+	ReactionDown string = "down"
+)
+
+// errOwnPost is returned when users vote on their own posts
+var errOwnPost = errors.New("you cannot vote on your own post")
+
+// Reactions summarizes the reactions to a thread or comment
+type Reactions struct {
+	// Score is the number of up votes minus the number of down votes
+	Score  int64            `json:"score"`
+	Counts map[string]int64 `json:"counts"`
+	// Mine lists the reactions of the current user
+	Mine []string `json:"mine,omitempty"`
+}
+
+// ReactionTypes lists the reactions clients may offer
+type ReactionTypes struct {
+	Votes []string `json:"votes"`
+	Emoji []string `json:"emoji"`
+}
+
+// decodeReactions builds a summary from the JSON aggregates returned by the
+// listing queries: an object of counts and an array of the user's reactions
+func decodeReactions(counts, mine string) Reactions {
+	reactions := Reactions{Counts: make(map[string]int64)}
+	if err := json.Unmarshal([]byte(counts), &reactions.Counts); err != nil {
+		log.Error().Err(err).Msg("Error decoding reaction counts")
+	}
+	if err := json.Unmarshal([]byte(mine), &reactions.Mine); err != nil {
+		log.Error().Err(err).Msg("Error decoding user reactions")
+	}
+	reactions.Score = reactions.Counts[ReactionUp] - reactions.Counts[ReactionDown]
+	return reactions
+}
+
+// isVote reports whether reaction is an up or down vote
+func isVote(reaction string) bool {
+	return reaction == ReactionUp || reaction == ReactionDown
+}
+
+// validReaction reports whether users may react with reaction
+func (app *App) validReaction(reaction string) bool {
+	return isVote(reaction) || slices.Contains(app.reactions, reaction)
+}
+
+// getReactions returns the reaction summary of one thread or comment
+func (app *App) getReactions(ctx context.Context, targetType, targetID string) (Reactions, error) {
+	row, err := app.queries.GetReactions(ctx, sqlcdb.GetReactionsParams{
+		TargetType: targetType,
+		TargetID:   targetID,
+		UserID:     currentUserID(ctx),
+	})
+	if err != nil {
+		return Reactions{}, err
+	}
+	return decodeReactions(row.ReactionCounts, row.UserReactions), nil
+}
+
+// GetReactionTypes handles the GET /api/reactions endpoint
+func (app *App) GetReactionTypes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReactionTypes{
+		Votes: []string{ReactionUp, ReactionDown},
+		Emoji: app.reactions,
+	})
+}
+
+// AddReaction handles the PUT /api/threads/{id}/reactions/{reaction} and
+// PUT /api/threads/{id}/comments/{commentID}/reactions/{reaction} endpoints
+func (app *App) AddReaction(w http.ResponseWriter, r *http.Request) {
+	app.setReaction(w, r, true)
+}
+
+// RemoveReaction handles the DELETE /api/threads/{id}/reactions/{reaction} and
+// DELETE /api/threads/{id}/comments/{commentID}/reactions/{reaction} endpoints
+func (app *App) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	app.setReaction(w, r, false)
+}
+
+// setReaction adds or removes one of the current user's reactions to a thread
+// or comment and responds with the updated summary
+func (app *App) setReaction(w http.ResponseWriter, r *http.Request, add bool) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	threadID := vars["id"]
+	commentID := vars["commentID"]
+	reaction := vars["reaction"]
+
+	user, ok := GetCurrentUser(ctx)
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	if add && !app.validReaction(reaction) {
+		http.Error(w, "Unknown reaction", http.StatusBadRequest)
+		return
+	}
+
+	targetType, targetID := ReactionTargetThread, threadID
+	if commentID != "" {
+		targetType, targetID = ReactionTargetComment, commentID
+	}
+
+	if err := app.checkReactionTarget(ctx, threadID, commentID, add && isVote(reaction)); err != nil {
+		switch {
+		case errors.Is(err, errNotFound):
+			http.Error(w, "Post not found", http.StatusNotFound)
+		case errors.Is(err, errOwnPost):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("thread_id", threadID).Str("comment_id", commentID).Msg("Error getting post")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	if add {
+		// A new vote replaces the user's opposite one
+		if isVote(reaction) {
+			opposite := ReactionUp
+			if reaction == ReactionUp {
+				opposite = ReactionDown
+			}
+			if _, err := qtx.DeleteReaction(ctx, sqlcdb.DeleteReactionParams{
+				TargetType: targetType,
+				TargetID:   targetID,
+				UserID:     user.ID,
+				Reaction:   opposite,
+			}); err != nil {
+				log.Error().Err(err).Str("target_id", targetID).Msg("Error removing opposite vote")
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		err = qtx.AddReaction(ctx, sqlcdb.AddReactionParams{
+			TargetType: targetType,
+			TargetID:   targetID,
+			UserID:     user.ID,
+			Reaction:   reaction,
+			CreatedAt:  time.Now(),
+		})
+	} else {
+		_, err = qtx.DeleteReaction(ctx, sqlcdb.DeleteReactionParams{
+			TargetType: targetType,
+			TargetID:   targetID,
+			UserID:     user.ID,
+			Reaction:   reaction,
+		})
+	}
+	if err != nil {
+		log.Error().Err(err).Str("target_id", targetID).Str("reaction", reaction).Msg("Error saving reaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if targetType == ReactionTargetThread {
+		err = qtx.UpdateThreadScore(ctx, targetID)
+	} else {
+		err = qtx.UpdateCommentScore(ctx, targetID)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("target_id", targetID).Msg("Error updating score")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	reactions, err := app.getReactions(ctx, targetType, targetID)
+	if err != nil {
+		log.Error().Err(err).Str("target_id", targetID).Msg("Error getting reactions")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.publishThreadUpdate(threadID, commentID, "reaction")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reactions)
+}
+
+// checkReactionTarget makes sure the current user can see the thread or
+// comment they react to and, for votes, did not write it
+func (app *App) checkReactionTarget(ctx context.Context, threadID, commentID string, vote bool) error {
+	thread, err := app.getVisibleThread(ctx, threadID)
+	if err != nil {
+		return err
+	}
+	if commentID == "" {
+		if vote && isAuthor(ctx, thread.AuthorID) {
+			return errOwnPost
+		}
+		return nil
+	}
+
+	comment, err := app.queries.GetComment(ctx, commentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errNotFound
+		}
+		return err
+	}
+	if comment.ThreadID != threadID || (comment.Hidden && !IsModerator(ctx)) {
+		return errNotFound
+	}
+	if vote && isAuthor(ctx, comment.AuthorID) {
+		return errOwnPost
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	sqlcdb "pkoforum/db/sqlc"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultSearchLimit int64 = 20
+	maxSearchTerms     int   = 16
+
+	// Private-use characters SQLite wraps around matched terms; they are
+	// swapped for <mark> tags only after the snippet has been HTML-escaped.
+	snippetMatchStart = "\ue000"
+	snippetMatchEnd   = "\ue001"
+)
+
+type SearchResult struct {
+	ThreadID  string    `json:"thread_id"`
+	CommentID string    `json:"comment_id,omitempty"`
+	Kind      string    `json:"kind"`
+	Title     string    `json:"title"`
+	Category  string    `json:"category"`
+	Snippet   string    `json:"snippet"`
+	Language  string    `json:"language,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Search handles the GET /api/search endpoint
+func (app *App) Search(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	match := buildMatchQuery(query.Get("q"))
+	if match == "" {
+		http.Error(w, "Search query is required", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultSearchLimit
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxPageSize)
+	}
+
+	params := sqlcdb.SearchPostsParams{
+		DisplayLanguage: GetLanguage(ctx),
+		Query:           match,
+		IncludeHidden:   IsModerator(ctx),
+		Limit:           limit,
+	}
+
+	if category := query.Get("category"); category != "" {
+		if _, err := app.getCategory(ctx, category); err != nil {
+			if errors.Is(err, errNotFound) {
+				http.Error(w, "Invalid category", http.StatusBadRequest)
+				return
+			}
+			log.Error().Err(err).Str("category", category).Msg("Error getting category")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		params.Category = sql.NullString{String: category, Valid: true}
+	}
+
+	// An explicit lang restricts hits to that translation. Without it
+	// every translation is searched and a post can match more than once,
+	// so over-fetch to leave room for collapsing duplicates.
+	lang := GetLanguage(ctx)
+	if raw := query.Get("lang"); raw != "" {
+		filter := app.matchLanguage(raw)
+		if filter == "" {
+			http.Error(w, "Invalid language", http.StatusBadRequest)
+			return
+		}
+		params.Language = sql.NullString{String: filter, Valid: true}
+	} else {
+		params.Limit = limit * int64(len(app.languages))
+	}
+
+	log.Debug().Str("query", match).Str("category", query.Get("category")).Msg("Searching posts")
+
+	rows, err := app.queries.SearchPosts(ctx, params)
+	if err != nil {
+		log.Error().Err(err).Str("query", match).Msg("Error searching posts")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]SearchResult, 0, len(rows))
+	seen := make(map[string]int)
+	for _, row := range rows {
+		result := toSearchResult(row)
+
+		// Keep the best-ranked position but show the reader's own language when it matched too
+		key := result.ThreadID + "/" + result.CommentID
+		if i, ok := seen[key]; ok {
+			if result.Language == lang && results[i].Language != lang {
+				results[i].Snippet = result.Snippet
+				results[i].Language = result.Language
+			}
+			continue
+		}
+
+		if int64(len(results)) == limit {
+			continue
+		}
+		seen[key] = len(results)
+		results = append(results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Page[SearchResult]{Data: results})
+}
+
+// buildMatchQuery turns free-form user input into a safe FTS5 expression.
+// Every word becomes a quoted prefix term, so operators and column filters
+// typed by the user are matched literally instead of being interpreted.
+func buildMatchQuery(input string) string {
+	words := strings.FieldsFunc(input, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if len(terms) == maxSearchTerms {
+			break
+		}
+		terms = append(terms, `"`+word+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// highlightSnippet escapes a snippet for HTML and marks the matched terms
+func highlightSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, snippetMatchStart, "<mark>")
+	return strings.ReplaceAll(escaped, snippetMatchEnd, "</mark>")
+}
+
+func toSearchResult(row sqlcdb.SearchPostsRow) SearchResult {
+	result := SearchResult{
+		ThreadID:  row.ThreadID,
+		Kind:      row.Kind,
+		Title:     localizedText(row.Title, row.TranslatedTitle),
+		Category:  row.Category,
+		Snippet:   highlightSnippet(row.Snippet),
+		Language:  row.Language,
+		CreatedAt: row.CreatedAt,
+	}
+	if row.CommentID.Valid {
+		result.CommentID = row.CommentID.String
+	}
+	if row.CommentCreatedAt.Valid {
+		result.CreatedAt = row.CommentCreatedAt.Time
+	}
+	return result
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	sqlcdb "pkoforum/db/sqlc"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// Values of the state filter of thread listings
+const (
+	ThreadStateSolved   string = "solved"
+	ThreadStateUnsolved string = "unsolved"
+)
+
+// errSolutionsDisabled is returned when a thread's category does not allow
+// marking solutions
+var errSolutionsDisabled = errors.New("solutions are not enabled in this category")
+
+type solutionRequest struct {
+	CommentID string `json:"comment_id"`
+}
+
+// parseThreadState reads the state query parameter. The result is invalid
+// when no state was asked for.
+func parseThreadState(r *http.Request) (sql.NullBool, error) {
+	switch state := r.URL.Query().Get("state"); state {
+	case "":
+		return sql.NullBool{}, nil
+	case ThreadStateSolved, ThreadStateUnsolved:
+		return sql.NullBool{Bool: state == ThreadStateSolved, Valid: true}, nil
+	default:
+		return sql.NullBool{}, fmt.Errorf("state must be %s or %s", ThreadStateSolved, ThreadStateUnsolved)
+	}
+}
+
+// loadAcceptedComment returns the accepted comment of a thread localized for
+// the request language, or nil if there is none the current user may see
+func (app *App) loadAcceptedComment(ctx context.Context, thread sqlcdb.GetThreadRow) (*LocalizedComment, error) {
+	if !thread.AcceptedCommentID.Valid {
+		return nil, nil
+	}
+	category, err := app.getCategory(ctx, thread.Category)
+	if err != nil {
+		return nil, fmt.Errorf("getting category: %w", err)
+	}
+	if !category.SolutionsEnabled {
+		return nil, nil
+	}
+
+	comments, _, err := app.queryComments(ctx, sqlcdb.GetThreadCommentsParams{
+		UserID:        currentUserID(ctx),
+		ThreadID:      thread.ID,
+		IncludeHidden: IsModerator(ctx),
+		CommentID:     thread.AcceptedCommentID,
+		Sort:          SortOld,
+		Limit:         1,
+	})
+	if err != nil || len(comments) == 0 {
+		return nil, err
+	}
+	return &comments[0], nil
+}
+
+// solvableThread loads a thread the current user may mark a solution in: one
+// of theirs, or any for moderators, in a category with solutions enabled
+func solvableThread(ctx context.Context, qtx *sqlcdb.Queries, threadID string) (sqlcdb.GetThreadRow, error) {
+	thread, err := editableThread(ctx, qtx, threadID)
+	if err != nil {
+		return thread, err
+	}
+	category, err := qtx.GetCategory(ctx, thread.Category)
+	if err != nil {
+		return thread, fmt.Errorf("getting category: %w", err)
+	}
+	if !category.SolutionsEnabled {
+		return thread, errSolutionsDisabled
+	}
+	return thread, nil
+}
+
+// AcceptSolution handles the PUT /api/threads/{id}/solution endpoint, marking
+// one of the thread's comments as its accepted answer
+func (app *App) AcceptSolution(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	threadID := mux.Vars(r)["id"]
+
+	if _, ok := GetCurrentUser(ctx); !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	var req solutionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error().Err(err).Msg("Error decoding request body")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.CommentID == "" {
+		http.Error(w, "comment_id is required", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	thread, err := solvableThread(ctx, qtx, threadID)
+	if err != nil {
+		writeSolutionError(w, err)
+		return
+	}
+
+	comment, err := qtx.GetComment(ctx, req.CommentID)
+	if err != nil && err != sql.ErrNoRows {
+		log.Error().Err(err).Str("comment_id", req.CommentID).Msg("Error getting comment")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err == sql.ErrNoRows || comment.ThreadID != threadID || comment.Hidden {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+
+	if _, err := qtx.SetAcceptedComment(ctx, sqlcdb.SetAcceptedCommentParams{
+		AcceptedCommentID: sql.NullString{String: comment.ID, Valid: true},
+		ID:                threadID,
+	}); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error accepting solution")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !isAuthor(ctx, thread.AuthorID) {
+		if err := logModeration(ctx, qtx, "accept_solution", "thread", threadID, ""); err != nil {
+			log.Error().Err(err).Str("thread_id", threadID).Msg("Error writing moderation log")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.publishThreadUpdate(threadID, comment.ID, "accept_solution")
+
+	log.Info().Str("thread_id", threadID).Str("comment_id", comment.ID).Msg("Solution accepted")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnacceptSolution handles the DELETE /api/threads/{id}/solution endpoint,
+// marking the thread as unsolved again
+func (app *App) UnacceptSolution(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	threadID := mux.Vars(r)["id"]
+
+	if _, ok := GetCurrentUser(ctx); !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	tx, err := app.db.Begin()
+	if err != nil {
+		log.Error().Err(err).Msg("Error starting transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := app.queries.WithTx(tx)
+
+	thread, err := solvableThread(ctx, qtx, threadID)
+	if err != nil {
+		writeSolutionError(w, err)
+		return
+	}
+	if !thread.AcceptedCommentID.Valid {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if _, err := qtx.SetAcceptedComment(ctx, sqlcdb.SetAcceptedCommentParams{ID: threadID}); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error removing solution")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !isAuthor(ctx, thread.AuthorID) {
+		if err := logModeration(ctx, qtx, "unaccept_solution", "thread", threadID, ""); err != nil {
+			log.Error().Err(err).Str("thread_id", threadID).Msg("Error writing moderation log")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error committing transaction")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.publishThreadUpdate(threadID, thread.AcceptedCommentID.String, "unaccept_solution")
+
+	log.Info().Str("thread_id", threadID).Msg("Solution removed")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeSolutionError maps the errors of solvableThread onto responses
+func writeSolutionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errSolutionsDisabled) {
+		http.Error(w, "Solutions are not enabled in this category", http.StatusBadRequest)
+		return
+	}
+	writeEditError(w, err, "Thread not found")
+}
@@ -0,0 +1,469 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	sqlcdb "pkoforum/db/sqlc"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	JobStatusPending string = "pending"
+	JobStatusRunning string = "running"
+	JobStatusFailed  string = "failed"
+
+	JobTargetThread  string = "thread"
+	JobTargetComment string = "comment"
+
+	translationPollInterval = 5 * time.Second
+	translationBaseBackoff  = 30 * time.Second
+	translationMaxBackoff   = time.Hour
+
+	defaultTranslationJobLimit int64 = 100
+	maxTranslationJobLimit     int64 = 500
+)
+
+// errTranslationSuperseded means the source text was edited while it was being
+// translated; the edit queued a fresh job
+var errTranslationSuperseded = errors.New("source text changed during translation")
+
+type TranslationJob struct {
+	ID             string    `json:"id"`
+	TargetType     string    `json:"target_type"`
+	TargetID       string    `json:"target_id"`
+	SourceLanguage string    `json:"source_language"`
+	TargetLanguage string    `json:"target_language"`
+	Status         string    `json:"status"`
+	Attempts       int64     `json:"attempts"`
+	LastError      string    `json:"last_error,omitempty"`
+	RunAt          time.Time `json:"run_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// translationQueue tracks the worker pool that drains the translation_jobs table
+type translationQueue struct {
+	workers     int
+	maxAttempts int64
+	wake        chan struct{}
+	stop        chan struct{}
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+func newTranslationQueue(workers, maxAttempts int) *translationQueue {
+	return &translationQueue{
+		workers:     workers,
+		maxAttempts: int64(maxAttempts),
+		wake:        make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+	}
+}
+
+// enqueueTranslation records a translation job in the caller's transaction.
+// Call notifyTranslationWorkers once the transaction has committed.
+func enqueueTranslation(ctx context.Context, qtx *sqlcdb.Queries, targetType, targetID, sourceLang, targetLang string) error {
+	now := time.Now()
+	_, err := qtx.CreateTranslationJob(ctx, sqlcdb.CreateTranslationJobParams{
+		ID:             fmt.Sprintf("%d", now.UnixNano()),
+		TargetType:     targetType,
+		TargetID:       targetID,
+		SourceLanguage: sourceLang,
+		TargetLanguage: targetLang,
+		RunAt:          now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	})
+	return err
+}
+
+// enqueueMissingTranslations queues translations for every configured language
+// that existing threads and comments lack, e.g. after a language was added
+func (app *App) enqueueMissingTranslations(ctx context.Context) {
+	now := time.Now()
+	for _, lang := range app.languages {
+		threads, err := app.queries.EnqueueMissingThreadTranslations(ctx, sqlcdb.EnqueueMissingThreadTranslationsParams{
+			TargetLanguage: lang,
+			Now:            now,
+		})
+		if err != nil {
+			log.Error().Err(err).Str("target_lang", lang).Msg("Error enqueueing missing thread translations")
+			continue
+		}
+
+		comments, err := app.queries.EnqueueMissingCommentTranslations(ctx, sqlcdb.EnqueueMissingCommentTranslationsParams{
+			TargetLanguage: lang,
+			Now:            now,
+		})
+		if err != nil {
+			log.Error().Err(err).Str("target_lang", lang).Msg("Error enqueueing missing comment translations")
+			continue
+		}
+
+		if threads+comments > 0 {
+			log.Info().
+				Str("target_lang", lang).
+				Int64("threads", threads).
+				Int64("comments", comments).
+				Msg("Queued missing translations")
+		}
+	}
+}
+
+// notifyTranslationWorkers wakes an idle worker instead of waiting for the next poll
+func (app *App) notifyTranslationWorkers() {
+	select {
+	case app.translations.wake <- struct{}{}:
+	default:
+	}
+}
+
+// StartTranslationWorkers launches the worker pool. Jobs left running by a
+// previous process are returned to the queue first, and content missing a
+// translation into any configured language is queued. It does nothing when
+// translation is disabled, leaving queued jobs for when a backend is set.
+func (app *App) StartTranslationWorkers() {
+	q := app.translations
+	if app.translator == nil {
+		return
+	}
+
+	if err := app.queries.ResetRunningTranslationJobs(context.Background(), time.Now()); err != nil {
+		log.Error().Err(err).Msg("Error resetting interrupted translation jobs")
+	}
+	app.enqueueMissingTranslations(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go app.translationWorker(ctx, i)
+	}
+
+	log.Info().Int("workers", q.workers).Msg("Translation workers started")
+}
+
+// StopTranslationWorkers stops claiming new jobs and waits for in-flight ones to
+// finish. If ctx expires first the remaining jobs are cancelled and requeued.
+func (app *App) StopTranslationWorkers(ctx context.Context) error {
+	q := app.translations
+	close(q.stop)
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Info().Msg("Translation workers drained")
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-done
+		log.Warn().Msg("Translation workers cancelled before draining")
+		return ctx.Err()
+	}
+}
+
+// translationWorker claims and processes jobs until the queue is stopped
+func (app *App) translationWorker(ctx context.Context, worker int) {
+	q := app.translations
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		job, err := app.queries.ClaimTranslationJob(ctx, time.Now())
+		if err != nil {
+			if err != sql.ErrNoRows && ctx.Err() == nil {
+				log.Error().Err(err).Int("worker", worker).Msg("Error claiming translation job")
+			}
+
+			select {
+			case <-q.stop:
+				return
+			case <-q.wake:
+			case <-time.After(translationPollInterval):
+			}
+			continue
+		}
+
+		app.runTranslationJob(ctx, job)
+	}
+}
+
+// runTranslationJob processes a claimed job and records its outcome
+func (app *App) runTranslationJob(ctx context.Context, job sqlcdb.TranslationJob) {
+	// Bookkeeping must survive a cancelled worker context
+	bgCtx := context.Background()
+
+	err := app.processTranslationJob(ctx, job)
+	if errors.Is(err, errTranslationSuperseded) {
+		if err := app.queries.DeleteTranslationJob(bgCtx, job.ID); err != nil {
+			log.Error().Err(err).Str("job_id", job.ID).Msg("Error deleting superseded translation job")
+		}
+		log.Info().
+			Str("job_id", job.ID).
+			Str("target_type", job.TargetType).
+			Str("target_id", job.TargetID).
+			Msg("Translation discarded after edit")
+		return
+	}
+	if err == nil {
+		if err := app.queries.DeleteTranslationJob(bgCtx, job.ID); err != nil {
+			log.Error().Err(err).Str("job_id", job.ID).Msg("Error deleting completed translation job")
+		}
+		log.Info().
+			Str("job_id", job.ID).
+			Str("target_type", job.TargetType).
+			Str("target_id", job.TargetID).
+			Str("target_lang", job.TargetLanguage).
+			Msg("Translation saved")
+		app.publishTranslationReady(bgCtx, job)
+		return
+	}
+
+	now := time.Now()
+	status := JobStatusPending
+	runAt := now.Add(translationBackoff(job.Attempts))
+
+	switch {
+	case ctx.Err() != nil:
+		// Interrupted by shutdown; pick it up again on the next start
+		runAt = now
+	case errors.Is(err, errNotFound), job.Attempts >= app.translations.maxAttempts:
+		status = JobStatusFailed
+	}
+
+	log.Error().Err(err).
+		Str("job_id", job.ID).
+		Str("target_type", job.TargetType).
+		Str("target_id", job.TargetID).
+		Int64("attempts", job.Attempts).
+		Str("status", status).
+		Msg("Translation job failed")
+
+	if err := app.queries.FailTranslationJob(bgCtx, sqlcdb.FailTranslationJobParams{
+		Status:    status,
+		LastError: err.Error(),
+		RunAt:     runAt,
+		UpdatedAt: now,
+		ID:        job.ID,
+	}); err != nil {
+		log.Error().Err(err).Str("job_id", job.ID).Msg("Error rescheduling translation job")
+	}
+}
+
+// processTranslationJob translates the job's source text and stores the result
+func (app *App) processTranslationJob(ctx context.Context, job sqlcdb.TranslationJob) error {
+	switch job.TargetType {
+	case JobTargetThread:
+		source, err := app.queries.GetThreadTranslation(ctx, sqlcdb.GetThreadTranslationParams{
+			ThreadID: job.TargetID,
+			Language: job.SourceLanguage,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("thread %s: %w", job.TargetID, errNotFound)
+			}
+			return err
+		}
+
+		title, err := app.translator.Translate(ctx, source.Title, job.SourceLanguage, job.TargetLanguage)
+		if err != nil {
+			return err
+		}
+		content, err := app.translator.Translate(ctx, source.Content, job.SourceLanguage, job.TargetLanguage)
+		if err != nil {
+			return err
+		}
+
+		current, err := app.queries.GetThreadTranslation(ctx, sqlcdb.GetThreadTranslationParams{
+			ThreadID: job.TargetID,
+			Language: job.SourceLanguage,
+		})
+		if err == sql.ErrNoRows || (err == nil && (current.Title != source.Title || current.Content != source.Content)) {
+			return errTranslationSuperseded
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = app.queries.UpsertThreadTranslation(ctx, sqlcdb.UpsertThreadTranslationParams{
+			ID:       fmt.Sprintf("%d", time.Now().UnixNano()),
+			ThreadID: job.TargetID,
+			Language: job.TargetLanguage,
+			Title:    title,
+			Content:  content,
+		})
+		return err
+
+	case JobTargetComment:
+		source, err := app.queries.GetCommentTranslation(ctx, sqlcdb.GetCommentTranslationParams{
+			CommentID: job.TargetID,
+			Language:  job.SourceLanguage,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("comment %s: %w", job.TargetID, errNotFound)
+			}
+			return err
+		}
+
+		content, err := app.translator.Translate(ctx, source.Content, job.SourceLanguage, job.TargetLanguage)
+		if err != nil {
+			return err
+		}
+
+		current, err := app.queries.GetCommentTranslation(ctx, sqlcdb.GetCommentTranslationParams{
+			CommentID: job.TargetID,
+			Language:  job.SourceLanguage,
+		})
+		if err == sql.ErrNoRows || (err == nil && current.Content != source.Content) {
+			return errTranslationSuperseded
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = app.queries.UpsertCommentTranslation(ctx, sqlcdb.UpsertCommentTranslationParams{
+			ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+			CommentID: job.TargetID,
+			Language:  job.TargetLanguage,
+			Content:   content,
+		})
+		return err
+
+	default:
+		return fmt.Errorf("unknown target type %q: %w", job.TargetType, errNotFound)
+	}
+}
+
+// translationBackoff doubles the retry delay with every attempt, up to translationMaxBackoff
+func translationBackoff(attempts int64) time.Duration {
+	delay := translationBaseBackoff
+	for i := int64(1); i < attempts && delay < translationMaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, translationMaxBackoff)
+}
+
+// ListTranslationJobs handles the GET /api/mod/translation-jobs endpoint
+func (app *App) ListTranslationJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	status := query.Get("status")
+	if status == "" {
+		status = JobStatusFailed
+	}
+	if status != JobStatusPending && status != JobStatusRunning && status != JobStatusFailed {
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultTranslationJobLimit
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxTranslationJobLimit)
+	}
+
+	rows, err := app.queries.ListTranslationJobs(ctx, sqlcdb.ListTranslationJobsParams{
+		Status: status,
+		Limit:  limit,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("status", status).Msg("Error listing translation jobs")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jobs := make([]TranslationJob, 0, len(rows))
+	for _, row := range rows {
+		jobs = append(jobs, toTranslationJob(row))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// RetryTranslationJob handles the POST /api/mod/translation-jobs/{id}/retry endpoint
+func (app *App) RetryTranslationJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobID := mux.Vars(r)["id"]
+
+	affected, err := app.queries.RequeueTranslationJob(ctx, sqlcdb.RequeueTranslationJobParams{
+		Now: time.Now(),
+		ID:  jobID,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("job_id", jobID).Msg("Error requeueing translation job")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if affected == 0 {
+		http.Error(w, "Failed translation job not found", http.StatusNotFound)
+		return
+	}
+
+	app.notifyTranslationWorkers()
+
+	log.Info().Str("job_id", jobID).Msg("Translation job requeued")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RetryFailedTranslationJobs handles the POST /api/mod/translation-jobs/retry endpoint
+func (app *App) RetryFailedTranslationJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	affected, err := app.queries.RequeueFailedTranslationJobs(ctx, time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Error requeueing failed translation jobs")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.notifyTranslationWorkers()
+
+	log.Info().Int64("requeued", affected).Msg("Failed translation jobs requeued")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"requeued": affected})
+}
+
+func toTranslationJob(j sqlcdb.TranslationJob) TranslationJob {
+	return TranslationJob{
+		ID:             j.ID,
+		TargetType:     j.TargetType,
+		TargetID:       j.TargetID,
+		SourceLanguage: j.SourceLanguage,
+		TargetLanguage: j.TargetLanguage,
+		Status:         j.Status,
+		Attempts:       j.Attempts,
+		LastError:      j.LastError,
+		RunAt:          j.RunAt,
+		CreatedAt:      j.CreatedAt,
+		UpdatedAt:      j.UpdatedAt,
+	}
+}
@@ -0,0 +1,608 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// uiFiles holds the templates and stylesheet of the server-rendered UI
+//
+//go:embed ui
+var uiFiles embed.FS
+
+var uiFuncs = template.FuncMap{
+	// sanitized marks HTML produced by the markdown package as safe to embed
+	"sanitized": func(s string) template.HTML { return template.HTML(s) },
+	"pageURL":   pageURL,
+	// formError is the hidden error element rendered with a form
+	"formError": func(id string) uiFormError { return uiFormError{ID: id} },
+}
+
+// uiTemplates maps each page to its template set, which also holds the layout
+// and the fragments merged by Datastar
+var uiTemplates = parseUITemplates("threads.html", "thread.html", "new.html", "auth.html")
+
+func parseUITemplates(pages ...string) map[string]*template.Template {
+	layout := template.Must(template.New("").Funcs(uiFuncs).ParseFS(uiFiles, "ui/layout.html"))
+
+	templates := make(map[string]*template.Template, len(pages))
+	for _, page := range pages {
+		templates[page] = template.Must(template.Must(layout.Clone()).ParseFS(uiFiles, "ui/"+page))
+	}
+	return templates
+}
+
+// uiPage is the data every UI template is rendered with. Pages only fill in
+// the fields they show.
+type uiPage struct {
+	Title       string
+	Lang        string
+	Languages   []string
+	DatastarURL string
+	// Path is the current page with its query, used for language links
+	Path string
+	User *User
+
+	Categories      []LocalizedCategory
+	Category        string
+	CanCreateThread bool
+	// Sort is the order of the listed threads or comments
+	Sort string
+
+	Threads    []LocalizedThread
+	NextCursor string
+	// LiveURL streams fragment updates for the page, if it has any
+	LiveURL string
+
+	Thread        LocalizedThread
+	CommentCursor string
+	CanComment    bool
+
+	// Register selects the sign-up form on the auth page
+	Register bool
+}
+
+// uiFormError is the error message shown above a form
+type uiFormError struct {
+	ID      string
+	Message string
+}
+
+// pageURL returns path with its lang query parameter set to lang, followed by
+// the given key and value pairs whose value is not empty
+func pageURL(path, lang string, params ...string) string {
+	u, err := url.Parse(path)
+	if err != nil {
+		return path
+	}
+	query := u.Query()
+	query.Set("lang", lang)
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i+1] != "" {
+			query.Set(params[i], params[i+1])
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// setupUIRoutes registers the server-rendered pages and their Datastar endpoints
+func (app *App) setupUIRoutes() {
+	app.router.HandleFunc("/", app.UIThreads).Methods("GET")
+	app.router.HandleFunc("/t/{id}", app.UIThread).Methods("GET")
+	app.router.HandleFunc("/new", app.UINewThread).Methods("GET")
+	app.router.HandleFunc("/login", app.UIAuth).Methods("GET")
+	app.router.HandleFunc("/register", app.UIAuth).Methods("GET")
+	app.router.HandleFunc("/ui/forum.css", serveUIStylesheet).Methods("GET")
+
+	app.router.HandleFunc("/ui/live", app.UIThreadsLive).Methods("GET")
+	app.router.HandleFunc("/ui/threads", app.UICreateThread).Methods("POST")
+	app.router.HandleFunc("/ui/threads/{id}/live", app.UIThreadLive).Methods("GET")
+	app.router.HandleFunc("/ui/threads/{id}/comments", app.UICreateComment).Methods("POST")
+	app.router.HandleFunc("/ui/login", app.UILogin).Methods("POST")
+	app.router.HandleFunc("/ui/register", app.UIRegister).Methods("POST")
+	app.router.HandleFunc("/ui/logout", app.UILogout).Methods("POST")
+}
+
+// serveUIStylesheet handles the GET /ui/forum.css endpoint
+func serveUIStylesheet(w http.ResponseWriter, r *http.Request) {
+	http.ServeFileFS(w, r, uiFiles, "ui/forum.css")
+}
+
+// newUIPage returns the data shared by every page: the language, the current
+// user and the category navigation
+func (app *App) newUIPage(r *http.Request, title string) (uiPage, error) {
+	ctx := r.Context()
+
+	categories, err := app.listCategories(ctx)
+	if err != nil {
+		return uiPage{}, err
+	}
+
+	// Language links replace the lang parameter and keep the rest of the query
+	query := r.URL.Query()
+	query.Del("lang")
+	path := r.URL.Path
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	page := uiPage{
+		Title:       title,
+		Lang:        GetLanguage(ctx),
+		Languages:   app.languages,
+		DatastarURL: app.datastarURL,
+		Path:        path,
+		Categories:  categories,
+	}
+	if user, ok := GetCurrentUser(ctx); ok {
+		u := toUser(user)
+		page.User = &u
+	}
+	for _, category := range categories {
+		page.CanCreateThread = page.CanCreateThread || category.CanCreateThread
+	}
+	return page, nil
+}
+
+// renderPage writes a whole page
+func renderPage(w http.ResponseWriter, name string, page uiPage) {
+	var buf bytes.Buffer
+	if err := uiTemplates[name].ExecuteTemplate(&buf, "layout", page); err != nil {
+		log.Error().Err(err).Str("page", name).Msg("Error rendering page")
+		http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	buf.WriteTo(w)
+}
+
+// renderFragment renders one of the named templates of a page for merging
+func renderFragment(name, fragment string, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := uiTemplates[name].ExecuteTemplate(&buf, fragment, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// mergeFormError shows message above the form whose error element has id.
+// An empty message hides it.
+func mergeFormError(sse *datastarSSE, id, message string) {
+	fragment, err := renderFragment("auth.html", "form-error", uiFormError{ID: id, Message: message})
+	if err != nil {
+		log.Error().Err(err).Msg("Error rendering form error")
+		return
+	}
+	sse.mergeFragments(fragment)
+}
+
+// uiErrorMessage returns the message shown to the user for err
+func uiErrorMessage(err error) string {
+	status, message := requestErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Error().Err(err).Msg("Error handling UI request")
+		return "Something went wrong, please try again"
+	}
+	return message
+}
+
+// requireDatastar rejects requests to the UI endpoints that Datastar did not make
+func requireDatastar(w http.ResponseWriter, r *http.Request) bool {
+	if !isDatastarRequest(r) {
+		http.Error(w, "Datastar request required", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// UIThreads handles the GET / page, listing the threads of every category or
+// of the one given by the category parameter
+func (app *App) UIThreads(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	category := r.URL.Query().Get("category")
+
+	limit, cursor, err := parsePageParams(r, defaultThreadPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sort, err := parseSort(r, cursor, threadSorts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := app.newUIPage(r, "Threads")
+	if err != nil {
+		log.Error().Err(err).Msg("Error preparing page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if category != "" {
+		found := false
+		for _, c := range page.Categories {
+			if c.Value == category {
+				page.Title = c.Label
+				page.CanCreateThread = c.CanCreateThread
+				found = true
+			}
+		}
+		if !found {
+			http.Error(w, "Category not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	threads, nextCursor, err := app.listThreads(ctx, category, sql.NullBool{}, sort, limit, cursor)
+	if err != nil {
+		log.Error().Err(err).Str("category", category).Msg("Error listing threads")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	page.Category = category
+	page.Sort = sort
+	page.Threads = threads
+	page.NextCursor = nextCursor
+	// Only the first page follows new threads; later ones would shift under the reader
+	if cursor == nil {
+		page.LiveURL = pageURL("/ui/live", page.Lang, "category", category, "sort", sort)
+	}
+	renderPage(w, "threads.html", page)
+}
+
+// UIThreadsLive handles the GET /ui/live endpoint, merging the refreshed first
+// page of threads whenever a thread is posted
+func (app *App) UIThreadsLive(w http.ResponseWriter, r *http.Request) {
+	if !requireDatastar(w, r) {
+		return
+	}
+	ctx := r.Context()
+	category := r.URL.Query().Get("category")
+
+	sort, err := parseSort(r, nil, threadSorts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub, _, err := app.events.subscribe(threadsTopic, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer app.events.unsubscribe(sub)
+
+	sse, ok := newDatastarSSE(w)
+	if !ok {
+		return
+	}
+
+	render := func() {
+		threads, nextCursor, err := app.listThreads(ctx, category, sql.NullBool{}, sort, defaultThreadPageSize, nil)
+		if err != nil {
+			log.Error().Err(err).Str("category", category).Msg("Error listing threads")
+			return
+		}
+		fragment, err := renderFragment("threads.html", "thread-list", uiPage{
+			Lang:       GetLanguage(ctx),
+			Category:   category,
+			Sort:       sort,
+			Threads:    threads,
+			NextCursor: nextCursor,
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("Error rendering thread list")
+			return
+		}
+		sse.mergeFragments(fragment)
+	}
+
+	// Catch up on anything posted between rendering the page and connecting
+	render()
+	relayEvents(ctx, w, sse.flusher, sub, func(event Event) {
+		var thread Thread
+		if err := json.Unmarshal(event.Data, &thread); err != nil {
+			log.Error().Err(err).Msg("Error decoding thread event")
+			return
+		}
+		if category == "" || thread.Category == category {
+			render()
+		}
+	})
+}
+
+// UIThread handles the GET /t/{id} page
+func (app *App) UIThread(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	threadID := mux.Vars(r)["id"]
+
+	_, cursor, err := parsePageParams(r, defaultCommentPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sort, err := parseSort(r, cursor, commentSorts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	thread, err := app.loadThread(ctx, threadID, sort, cursor)
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			http.Error(w, "Thread not found", http.StatusNotFound)
+			return
+		}
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error getting thread")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	page, err := app.newUIPage(r, thread.Title)
+	if err != nil {
+		log.Error().Err(err).Msg("Error preparing page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	page.Thread = thread
+	page.Category = thread.Category
+	page.Sort = sort
+	page.CommentCursor = r.URL.Query().Get("cursor")
+	for _, category := range page.Categories {
+		if category.Value == thread.Category {
+			page.CanComment = category.CanComment && (!thread.Locked || IsModerator(ctx))
+		}
+	}
+
+	page.LiveURL = pageURL("/ui/threads/"+url.PathEscape(threadID)+"/live", page.Lang, "sort", sort, "cursor", page.CommentCursor)
+	renderPage(w, "thread.html", page)
+}
+
+// mergeThread merges the refreshed thread and page of comments. It reports
+// false when the thread is gone.
+func (app *App) mergeThread(r *http.Request, sse *datastarSSE, threadID string) bool {
+	ctx := r.Context()
+
+	_, cursor, err := parsePageParams(r, defaultCommentPageSize)
+	if err != nil {
+		cursor = nil
+	}
+	sort, err := parseSort(r, cursor, commentSorts)
+	if err != nil {
+		sort, cursor = SortOld, nil
+	}
+
+	thread, err := app.loadThread(ctx, threadID, sort, cursor)
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			return false
+		}
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error getting thread")
+		return true
+	}
+
+	page := uiPage{Lang: GetLanguage(ctx), Sort: sort, Thread: thread, CommentCursor: r.URL.Query().Get("cursor")}
+	for _, fragment := range []string{"thread-body", "comments"} {
+		html, err := renderFragment("thread.html", fragment, page)
+		if err != nil {
+			log.Error().Err(err).Str("fragment", fragment).Msg("Error rendering thread")
+			return true
+		}
+		sse.mergeFragments(html)
+	}
+	return true
+}
+
+// UIThreadLive handles the GET /ui/threads/{id}/live endpoint, merging the
+// refreshed thread whenever it gets a comment, an edit or a translation
+func (app *App) UIThreadLive(w http.ResponseWriter, r *http.Request) {
+	if !requireDatastar(w, r) {
+		return
+	}
+	ctx := r.Context()
+	threadID := mux.Vars(r)["id"]
+
+	if _, err := app.getVisibleThread(ctx, threadID); err != nil {
+		if errors.Is(err, errNotFound) {
+			http.Error(w, "Thread not found", http.StatusNotFound)
+			return
+		}
+		log.Error().Err(err).Str("thread_id", threadID).Msg("Error getting thread")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sub, _, err := app.events.subscribe(threadTopic(threadID), 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer app.events.unsubscribe(sub)
+
+	sse, ok := newDatastarSSE(w)
+	if !ok {
+		return
+	}
+
+	home := pageURL("/", GetLanguage(ctx))
+	if !app.mergeThread(r, sse, threadID) {
+		sse.redirect(home)
+		return
+	}
+	relayEvents(ctx, w, sse.flusher, sub, func(Event) {
+		if !app.mergeThread(r, sse, threadID) {
+			sse.redirect(home)
+		}
+	})
+}
+
+// UICreateComment handles the POST /ui/threads/{id}/comments endpoint
+func (app *App) UICreateComment(w http.ResponseWriter, r *http.Request) {
+	if !requireDatastar(w, r) {
+		return
+	}
+	ctx := r.Context()
+	threadID := mux.Vars(r)["id"]
+
+	err := app.readUploadForm(w, r)
+	if err == nil {
+		_, err = app.createComment(ctx, commentInput{
+			ThreadID: threadID,
+			Content:  r.PostFormValue("content"),
+			Form:     r.MultipartForm,
+		})
+	}
+
+	sse, ok := newDatastarSSE(w)
+	if !ok {
+		return
+	}
+	if err != nil {
+		mergeFormError(sse, "comment-error", uiErrorMessage(err))
+		return
+	}
+
+	mergeFormError(sse, "comment-error", "")
+	app.mergeThread(r, sse, threadID)
+	sse.executeScript("document.getElementById('comment-form').reset()")
+}
+
+// UINewThread handles the GET /new page
+func (app *App) UINewThread(w http.ResponseWriter, r *http.Request) {
+	page, err := app.newUIPage(r, "New thread")
+	if err != nil {
+		log.Error().Err(err).Msg("Error preparing page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if page.User == nil && !page.CanCreateThread {
+		http.Redirect(w, r, pageURL("/login", page.Lang), http.StatusSeeOther)
+		return
+	}
+	page.Category = r.URL.Query().Get("category")
+	renderPage(w, "new.html", page)
+}
+
+// UICreateThread handles the POST /ui/threads endpoint, opening the new thread
+// once it is stored
+func (app *App) UICreateThread(w http.ResponseWriter, r *http.Request) {
+	if !requireDatastar(w, r) {
+		return
+	}
+	ctx := r.Context()
+
+	var thread Thread
+	err := app.readUploadForm(w, r)
+	if err == nil {
+		thread, err = app.createThread(ctx, threadInput{
+			Title:    r.PostFormValue("title"),
+			Content:  r.PostFormValue("content"),
+			Category: r.PostFormValue("category"),
+			Form:     r.MultipartForm,
+		})
+	}
+
+	sse, ok := newDatastarSSE(w)
+	if !ok {
+		return
+	}
+	if err != nil {
+		mergeFormError(sse, "thread-error", uiErrorMessage(err))
+		return
+	}
+	sse.redirect(pageURL("/t/"+url.PathEscape(thread.ID), GetLanguage(ctx)))
+}
+
+// UIAuth handles the GET /login and /register pages
+func (app *App) UIAuth(w http.ResponseWriter, r *http.Request) {
+	register := r.URL.Path == "/register"
+	title := "Log in"
+	if register {
+		title = "Register"
+	}
+
+	page, err := app.newUIPage(r, title)
+	if err != nil {
+		log.Error().Err(err).Msg("Error preparing page")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Register = register
+	renderPage(w, "auth.html", page)
+}
+
+// UILogin handles the POST /ui/login endpoint
+func (app *App) UILogin(w http.ResponseWriter, r *http.Request) {
+	if !requireDatastar(w, r) {
+		return
+	}
+
+	user, err := app.authenticate(r.Context(), r.PostFormValue("username"), r.PostFormValue("password"))
+	app.finishAuth(w, r, user.ID, err)
+}
+
+// UIRegister handles the POST /ui/register endpoint
+func (app *App) UIRegister(w http.ResponseWriter, r *http.Request) {
+	if !requireDatastar(w, r) {
+		return
+	}
+
+	user, err := app.registerUser(r.Context(), r.PostFormValue("username"), r.PostFormValue("password"))
+	if err == nil {
+		log.Info().Str("user_id", user.ID).Str("username", user.Username).Msg("User registered")
+	}
+	app.finishAuth(w, r, user.ID, err)
+}
+
+// finishAuth logs the user in and returns to the thread list, or shows why
+// logging in or registering failed
+func (app *App) finishAuth(w http.ResponseWriter, r *http.Request, userID string, err error) {
+	// The session cookie has to be set before the event stream starts
+	if err == nil {
+		if err = app.startSession(w, r, userID); err != nil {
+			log.Error().Err(err).Str("user_id", userID).Msg("Error starting session")
+		}
+	}
+
+	sse, ok := newDatastarSSE(w)
+	if !ok {
+		return
+	}
+	if err != nil {
+		mergeFormError(sse, "auth-error", uiErrorMessage(err))
+		return
+	}
+	sse.redirect(pageURL("/", GetLanguage(r.Context())))
+}
+
+// UILogout handles the POST /ui/logout endpoint
+func (app *App) UILogout(w http.ResponseWriter, r *http.Request) {
+	if !requireDatastar(w, r) {
+		return
+	}
+	if err := app.endSession(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sse, ok := newDatastarSSE(w)
+	if !ok {
+		return
+	}
+	sse.redirect(pageURL("/", GetLanguage(r.Context())))
+}
@@ -0,0 +1,328 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"pkoforum/internal/blob"
+	"pkoforum/internal/media"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// multipartOverhead is the room left in a request body for form fields next to the uploads
+	multipartOverhead = 1 << 20
+
+	// maxCaptionLength bounds attachment captions, in characters
+	maxCaptionLength = 500
+)
+
+// Upload form fields; repeated fields are paired by position
+const (
+	formFieldImage   = "image"
+	formFieldCaption = "caption"
+)
+
+// Errors about the upload as a whole rather than a single image
+var (
+	errTooManyAttachments = errors.New("too many attachments")
+	errCaptionTooLong     = fmt.Errorf("captions must be at most %d characters", maxCaptionLength)
+)
+
+// maxUploadRequestBytes bounds the whole multipart body of an upload request
+func (app *App) maxUploadRequestBytes() int64 {
+	return app.imageLimits.MaxBytes*int64(app.maxAttachments) + multipartOverhead
+}
+
+// parseUploadForm parses a multipart form whose body is capped to the configured
+// image size and count. It writes the error response itself and reports whether
+// parsing succeeded.
+func (app *App) parseUploadForm(w http.ResponseWriter, r *http.Request) bool {
+	if err := app.readUploadForm(w, r); err != nil {
+		writeRequestError(w, err)
+		return false
+	}
+	return true
+}
+
+// readUploadForm parses a capped multipart form like parseUploadForm but
+// leaves reporting the returned requestError to the caller
+func (app *App) readUploadForm(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, app.maxUploadRequestBytes())
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			log.Debug().Int64("limit", maxBytesErr.Limit).Msg("Upload request too large")
+			return &requestError{status: http.StatusRequestEntityTooLarge, message: media.ErrTooLarge.Error()}
+		}
+		log.Error().Err(err).Msg("Error parsing multipart form")
+		return &requestError{status: http.StatusBadRequest, message: "Error parsing form"}
+	}
+	return nil
+}
+
+// isMultipart reports whether the request carries a multipart form body
+func isMultipart(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// storedFile is an image written to the blob store
+type storedFile struct {
+	// Filename is the blob key
+	Filename string
+	// WebPath is the stable proxied path recorded alongside the key
+	WebPath string
+	Width   int
+	Height  int
+}
+
+// storedVariant is a resized copy of an upload
+type storedVariant struct {
+	storedFile
+	Name string
+}
+
+// storedImage is an upload that has been validated and written to the blob
+// store along with its resized variants
+type storedImage struct {
+	storedFile
+	Variants []storedVariant
+	Position int
+	Caption  string
+
+	store blob.Store
+}
+
+// storedImages are the attachments of one post, in upload order
+type storedImages []*storedImage
+
+// Remove deletes the blobs of every stored attachment
+func (images storedImages) Remove(ctx context.Context) {
+	for _, img := range images {
+		img.Remove(ctx)
+	}
+}
+
+// Remove deletes the stored blobs, used when the surrounding transaction fails
+func (img *storedImage) Remove(ctx context.Context) {
+	keys := []string{img.Filename}
+	for _, variant := range img.Variants {
+		keys = append(keys, variant.Filename)
+	}
+	for _, key := range keys {
+		if err := img.store.Delete(ctx, key); err != nil {
+			log.Error().Err(err).Str("key", key).Msg("Error removing uploaded image")
+		}
+	}
+}
+
+// storeAttachments stores every image of an upload form in order, pairing each
+// with the caption at the same position. Either all images are stored or none.
+func (app *App) storeAttachments(ctx context.Context, form *multipart.Form) (storedImages, error) {
+	if form == nil {
+		return nil, nil
+	}
+
+	headers := form.File[formFieldImage]
+	if len(headers) > app.maxAttachments {
+		return nil, fmt.Errorf("%w: at most %d images are allowed", errTooManyAttachments, app.maxAttachments)
+	}
+	captions := form.Value[formFieldCaption]
+
+	images := make(storedImages, 0, len(headers))
+	for i, header := range headers {
+		var caption string
+		if i < len(captions) {
+			caption = strings.TrimSpace(captions[i])
+		}
+		if utf8.RuneCountInString(caption) > maxCaptionLength {
+			images.Remove(ctx)
+			return nil, errCaptionTooLong
+		}
+
+		img, err := app.storeUpload(ctx, header)
+		if err != nil {
+			images.Remove(ctx)
+			return nil, fmt.Errorf("%s: %w", header.Filename, err)
+		}
+		img.Position = i
+		img.Caption = caption
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// storeUpload opens and stores a single uploaded file
+func (app *App) storeUpload(ctx context.Context, header *multipart.FileHeader) (*storedImage, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("opening upload: %w", err)
+	}
+	defer file.Close()
+
+	return app.storeImage(ctx, file, header)
+}
+
+// storeImage validates and re-encodes an uploaded image, then stores it and its
+// resized variants under server-generated keys. The client's filename is never
+// used for storage.
+func (app *App) storeImage(ctx context.Context, file multipart.File, header *multipart.FileHeader) (*storedImage, error) {
+	if header.Size > app.imageLimits.MaxBytes {
+		return nil, media.ErrTooLarge
+	}
+
+	img, err := media.ProcessImage(file, app.imageLimits)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := randomFilename()
+	if err != nil {
+		return nil, err
+	}
+
+	stored := &storedImage{store: app.blobs}
+	stored.storedFile, err = app.putImage(ctx, name+img.Extension, img)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, spec := range media.Variants {
+		resized, err := img.Resize(spec.MaxSize)
+		if err != nil {
+			stored.Remove(ctx)
+			return nil, err
+		}
+		if resized == nil {
+			// The original is already small enough to stand in for this variant
+			continue
+		}
+
+		file, err := app.putImage(ctx, name+"_"+spec.Name+resized.Extension, resized)
+		if err != nil {
+			stored.Remove(ctx)
+			return nil, err
+		}
+		stored.Variants = append(stored.Variants, storedVariant{storedFile: file, Name: spec.Name})
+	}
+
+	log.Debug().
+		Str("key", stored.Filename).
+		Str("original_name", header.Filename).
+		Str("content_type", img.ContentType).
+		Int("width", img.Width).
+		Int("height", img.Height).
+		Int("variants", len(stored.Variants)).
+		Msg("Image stored")
+
+	return stored, nil
+}
+
+// putImage writes an encoded image to the blob store under key
+func (app *App) putImage(ctx context.Context, key string, img *media.Image) (storedFile, error) {
+	if err := app.blobs.Put(ctx, key, bytes.NewReader(img.Data), int64(len(img.Data)), img.ContentType); err != nil {
+		return storedFile{}, fmt.Errorf("storing image: %w", err)
+	}
+	return storedFile{
+		Filename: key,
+		WebPath:  blob.ProxyPath + key,
+		Width:    img.Width,
+		Height:   img.Height,
+	}, nil
+}
+
+// blobURL returns where clients download the blob stored under key
+func (app *App) blobURL(ctx context.Context, key string) string {
+	url, err := app.blobs.URL(ctx, key)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Error resolving upload URL")
+		return ""
+	}
+	return url
+}
+
+// inlineUploadTypes are the types uploaded images are re-encoded to
+var inlineUploadTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// ServeUpload handles the GET /uploads/{key} endpoint, streaming a stored blob.
+// Keys are random and never reused, so responses are cached indefinitely.
+func (app *App) ServeUpload(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !blob.ValidKey(key) {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := app.blobs.Open(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		log.Error().Err(err).Str("key", key).Msg("Error opening upload")
+		http.Error(w, "Error reading upload", http.StatusInternalServerError)
+		return
+	}
+	defer body.Close()
+
+	// Files stored before uploads were re-encoded may have any client-chosen
+	// extension; only the image types uploads are re-encoded to are shown inline
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if !inlineUploadTypes[contentType] {
+		contentType = "application/octet-stream"
+		w.Header().Set("Content-Disposition", "attachment")
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	if _, err := io.Copy(w, body); err != nil {
+		log.Debug().Err(err).Str("key", key).Msg("Error streaming upload")
+	}
+}
+
+// imageErrorStatus returns the HTTP status and message reporting an upload failure
+func imageErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, media.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge, err.Error()
+	case errors.Is(err, media.ErrUnsupportedType):
+		return http.StatusUnsupportedMediaType, err.Error()
+	case errors.Is(err, media.ErrDimensionsTooBig):
+		return http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, media.ErrInvalidImage),
+		errors.Is(err, errTooManyAttachments),
+		errors.Is(err, errCaptionTooLong):
+		return http.StatusBadRequest, err.Error()
+	default:
+		log.Error().Err(err).Msg("Error storing image")
+		return http.StatusInternalServerError, "Error storing image"
+	}
+}
+
+// randomFilename returns a random hex name for an uploaded file
+func randomFilename() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating filename: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
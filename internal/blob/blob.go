@@ -0,0 +1,67 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"pkoforum/internal/config"
+)
+
+const (
+	BackendLocal string = "local"
+	BackendS3    string = "s3"
+)
+
+// ProxyPath is where the application serves stored blobs itself. Backends
+// without a public URL of their own link here.
+const ProxyPath = "/uploads/"
+
+// ErrNotFound is returned when a key does not exist in the store
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps uploaded files under flat, server-generated keys
+type Store interface {
+	// Put stores size bytes from r under key, replacing any existing blob
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the contents of a blob for proxying
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes a blob; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns where clients can download a blob. The URL may expire.
+	URL(ctx context.Context, key string) (string, error)
+}
+
+// New creates the blob store selected by the configuration
+func New(cfg *config.Config) (Store, error) {
+	switch cfg.BlobBackend {
+	case BackendLocal:
+		return NewLocal(cfg.UploadsPath, cfg.BlobPublicURL)
+	case BackendS3:
+		return NewS3(S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Bucket:    cfg.S3Bucket,
+			Region:    cfg.S3Region,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			UseSSL:    cfg.S3UseSSL,
+			PublicURL: cfg.BlobPublicURL,
+			URLExpiry: cfg.S3URLExpiry,
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob backend %q", cfg.BlobBackend)
+	}
+}
+
+// ValidKey reports whether key is a flat name that cannot escape the store
+func ValidKey(key string) bool {
+	return key != "" && key != "." && key != ".." && !strings.ContainsAny(key, "/\\")
+}
+
+// joinURL appends an escaped key to a base URL
+func joinURL(base, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + url.PathEscape(key)
+}
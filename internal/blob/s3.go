@@ -0,0 +1,111 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Options configures an S3-compatible store
+type S3Options struct {
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	// PublicURL serves blobs directly, e.g. from a public bucket or a CDN.
+	// When empty, clients get presigned URLs valid for URLExpiry.
+	PublicURL string
+	URLExpiry time.Duration
+}
+
+// S3 stores blobs in a bucket of any S3-compatible service
+type S3 struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+	urlExpiry time.Duration
+}
+
+// NewS3 creates a store for an existing bucket
+func NewS3(opts S3Options) (*S3, error) {
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure: opts.UseSSL,
+		// Setting the region avoids a bucket location lookup before every request
+		Region:       opts.Region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating S3 client: %w", err)
+	}
+	return &S3{
+		client:    client,
+		bucket:    opts.Bucket,
+		publicURL: opts.PublicURL,
+		urlExpiry: opts.URLExpiry,
+	}, nil
+}
+
+// Put uploads a blob as an object in the bucket
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if !ValidKey(key) {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	if err != nil {
+		return fmt.Errorf("uploading %s: %w", key, err)
+	}
+	return nil
+}
+
+// Open streams an object from the bucket
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if !ValidKey(key) {
+		return nil, ErrNotFound
+	}
+	// GetObject is lazy, so stat first to report missing keys up front
+	if _, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{}); err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("reading %s: %w", key, err)
+	}
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", key, err)
+	}
+	return obj, nil
+}
+
+// Delete removes an object from the bucket
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if !ValidKey(key) {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("deleting %s: %w", key, err)
+	}
+	return nil
+}
+
+// URL returns a public or presigned link to an object
+func (s *S3) URL(ctx context.Context, key string) (string, error) {
+	if s.publicURL != "" {
+		return joinURL(s.publicURL, key), nil
+	}
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, s.urlExpiry, url.Values{})
+	if err != nil {
+		return "", fmt.Errorf("signing URL for %s: %w", key, err)
+	}
+	return u.String(), nil
+}
@@ -3,6 +3,10 @@ package config
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration for the application
@@ -11,20 +15,225 @@ type Config struct {
 	DeepseekURL    string
 	UploadsPath    string
 	Port           string
+	SessionTTL     time.Duration
+
+	TranslationBackend     string
+	TranslationModel       string
+	LibreTranslateURL      string
+	LibreTranslateAPIKey   string
+	TranslationWorkers     int
+	TranslationMaxAttempts int
+
+	// BlobBackend selects where uploads are stored: local uses UploadsPath
+	BlobBackend   string
+	BlobPublicURL string
+	S3Endpoint    string
+	S3Bucket      string
+	S3Region      string
+	S3AccessKey   string
+	S3SecretKey   string
+	S3UseSSL      bool
+	S3URLExpiry   time.Duration
+
+	// Uploaded images larger than these limits are rejected
+	ImageMaxBytes  int64
+	ImageMaxWidth  int
+	ImageMaxHeight int
+	MaxAttachments int
+
+	// Animated GIFs with more frames, or more pixels across all frames, are rejected
+	ImageMaxFrames int
+	ImageMaxPixels int64
+
+	// MaxReplyDepth bounds how deeply comments may reply to one another
+	MaxReplyDepth int
+
+	// Languages lists the supported language codes, DefaultLanguage first
+	Languages       []string
+	DefaultLanguage string
+
+	// Reactions lists the emoji users may react with besides up and down votes
+	Reactions []string
+
+	// ServerUI serves the server-rendered Datastar pages alongside the API
+	ServerUI    bool
+	DatastarURL string
+
+	// MailBackend selects how e-mail is sent: smtp, file (MailDir), log or none
+	MailBackend  string
+	MailFrom     string
+	MailDir      string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	// SiteURL is the public address of the forum, used for links in e-mails
+	SiteURL string
 }
 
 // Load returns a Config struct populated with values from environment variables
 func Load() (*Config, error) {
+	// The Deepseek key is only needed by the default OpenAI-compatible backend
+	backend := getEnvWithDefault("TRANSLATION_BACKEND", "openai")
 	apiKey := os.Getenv("DEEPSEEK_API_KEY")
-	if apiKey == "" {
+	if apiKey == "" && backend == "openai" {
 		return nil, fmt.Errorf("DEEPSEEK_API_KEY environment variable is required")
 	}
 
+	sessionTTL, err := time.ParseDuration(getEnvWithDefault("SESSION_TTL", "720h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SESSION_TTL: %w", err)
+	}
+
+	translationWorkers, err := strconv.Atoi(getEnvWithDefault("TRANSLATION_WORKERS", "2"))
+	if err != nil || translationWorkers < 1 {
+		return nil, fmt.Errorf("invalid TRANSLATION_WORKERS: must be a positive integer")
+	}
+
+	translationMaxAttempts, err := strconv.Atoi(getEnvWithDefault("TRANSLATION_MAX_ATTEMPTS", "5"))
+	if err != nil || translationMaxAttempts < 1 {
+		return nil, fmt.Errorf("invalid TRANSLATION_MAX_ATTEMPTS: must be a positive integer")
+	}
+
+	blobBackend := getEnvWithDefault("BLOB_BACKEND", "local")
+	s3Endpoint := os.Getenv("S3_ENDPOINT")
+	s3Bucket := os.Getenv("S3_BUCKET")
+	if blobBackend == "s3" && (s3Endpoint == "" || s3Bucket == "") {
+		return nil, fmt.Errorf("S3_ENDPOINT and S3_BUCKET are required for the s3 blob backend")
+	}
+
+	s3UseSSL, err := strconv.ParseBool(getEnvWithDefault("S3_USE_SSL", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3_USE_SSL: %w", err)
+	}
+
+	s3URLExpiry, err := time.ParseDuration(getEnvWithDefault("S3_URL_EXPIRY", "1h"))
+	if err != nil || s3URLExpiry <= 0 {
+		return nil, fmt.Errorf("invalid S3_URL_EXPIRY: must be a positive duration")
+	}
+
+	imageMaxBytes, err := strconv.ParseInt(getEnvWithDefault("IMAGE_MAX_BYTES", "10485760"), 10, 64)
+	if err != nil || imageMaxBytes < 1 {
+		return nil, fmt.Errorf("invalid IMAGE_MAX_BYTES: must be a positive integer")
+	}
+
+	imageMaxWidth, err := strconv.Atoi(getEnvWithDefault("IMAGE_MAX_WIDTH", "4096"))
+	if err != nil || imageMaxWidth < 1 {
+		return nil, fmt.Errorf("invalid IMAGE_MAX_WIDTH: must be a positive integer")
+	}
+
+	imageMaxHeight, err := strconv.Atoi(getEnvWithDefault("IMAGE_MAX_HEIGHT", "4096"))
+	if err != nil || imageMaxHeight < 1 {
+		return nil, fmt.Errorf("invalid IMAGE_MAX_HEIGHT: must be a positive integer")
+	}
+
+	imageMaxFrames, err := strconv.Atoi(getEnvWithDefault("IMAGE_MAX_FRAMES", "500"))
+	if err != nil || imageMaxFrames < 1 {
+		return nil, fmt.Errorf("invalid IMAGE_MAX_FRAMES: must be a positive integer")
+	}
+
+	imageMaxPixels, err := strconv.ParseInt(getEnvWithDefault("IMAGE_MAX_PIXELS", "67108864"), 10, 64)
+	if err != nil || imageMaxPixels < 1 {
+		return nil, fmt.Errorf("invalid IMAGE_MAX_PIXELS: must be a positive integer")
+	}
+
+	maxAttachments, err := strconv.Atoi(getEnvWithDefault("MAX_ATTACHMENTS", "10"))
+	if err != nil || maxAttachments < 1 {
+		return nil, fmt.Errorf("invalid MAX_ATTACHMENTS: must be a positive integer")
+	}
+
+	maxReplyDepth, err := strconv.Atoi(getEnvWithDefault("MAX_REPLY_DEPTH", "5"))
+	if err != nil || maxReplyDepth < 0 {
+		return nil, fmt.Errorf("invalid MAX_REPLY_DEPTH: must be a non-negative integer")
+	}
+
+	reactions := parseList(getEnvWithDefault("REACTIONS", "👍,❤️,😂,🎉,😮,😢"))
+	for _, reaction := range reactions {
+		if len(reaction) > 32 || reaction == "up" || reaction == "down" {
+			return nil, fmt.Errorf("invalid REACTIONS entry %q", reaction)
+		}
+	}
+
+	serverUI, err := strconv.ParseBool(getEnvWithDefault("SERVER_UI", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SERVER_UI: %w", err)
+	}
+
+	mailBackend := getEnvWithDefault("MAIL_BACKEND", "none")
+	smtpHost := os.Getenv("SMTP_HOST")
+	if mailBackend == "smtp" && smtpHost == "" {
+		return nil, fmt.Errorf("SMTP_HOST is required for the smtp mail backend")
+	}
+
+	smtpPort, err := strconv.Atoi(getEnvWithDefault("SMTP_PORT", "587"))
+	if err != nil || smtpPort < 1 || smtpPort > 65535 {
+		return nil, fmt.Errorf("invalid SMTP_PORT: must be a port number")
+	}
+
+	languages := parseList(getEnvWithDefault("LANGUAGES", "en,ru"))
+	if len(languages) == 0 {
+		return nil, fmt.Errorf("LANGUAGES must list at least one language")
+	}
+
+	defaultLanguage := strings.ToLower(getEnvWithDefault("DEFAULT_LANGUAGE", languages[0]))
+	if !slices.Contains(languages, defaultLanguage) {
+		return nil, fmt.Errorf("DEFAULT_LANGUAGE %q is not listed in LANGUAGES", defaultLanguage)
+	}
+	languages = append([]string{defaultLanguage}, slices.DeleteFunc(languages, func(lang string) bool {
+		return lang == defaultLanguage
+	})...)
+
 	config := &Config{
 		DeepseekAPIKey: apiKey,
 		DeepseekURL:    getEnvWithDefault("DEEPSEEK_URL", "https://api.deepseek.com"),
 		UploadsPath:    getEnvWithDefault("UPLOADS_PATH", "static/uploads"),
 		Port:           getEnvWithDefault("PORT", "8080"),
+		SessionTTL:     sessionTTL,
+
+		TranslationBackend:     backend,
+		TranslationModel:       getEnvWithDefault("TRANSLATION_MODEL", "deepseek-chat"),
+		LibreTranslateURL:      getEnvWithDefault("LIBRETRANSLATE_URL", "http://localhost:5000"),
+		LibreTranslateAPIKey:   os.Getenv("LIBRETRANSLATE_API_KEY"),
+		TranslationWorkers:     translationWorkers,
+		TranslationMaxAttempts: translationMaxAttempts,
+
+		BlobBackend:   blobBackend,
+		BlobPublicURL: os.Getenv("BLOB_PUBLIC_URL"),
+		S3Endpoint:    s3Endpoint,
+		S3Bucket:      s3Bucket,
+		S3Region:      getEnvWithDefault("S3_REGION", "us-east-1"),
+		S3AccessKey:   os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:   os.Getenv("S3_SECRET_KEY"),
+		S3UseSSL:      s3UseSSL,
+		S3URLExpiry:   s3URLExpiry,
+
+		ImageMaxBytes:  imageMaxBytes,
+		ImageMaxWidth:  imageMaxWidth,
+		ImageMaxHeight: imageMaxHeight,
+		ImageMaxFrames: imageMaxFrames,
+		ImageMaxPixels: imageMaxPixels,
+		MaxAttachments: maxAttachments,
+
+		MaxReplyDepth: maxReplyDepth,
+
+		Languages:       languages,
+		DefaultLanguage: defaultLanguage,
+
+		Reactions: reactions,
+
+		ServerUI:    serverUI,
+		DatastarURL: getEnvWithDefault("DATASTAR_URL", "https://cdn.jsdelivr.net/gh/starfederation/datastar@v1.0.0-beta.11/bundles/datastar.js"),
+
+		MailBackend:  mailBackend,
+		MailFrom:     getEnvWithDefault("MAIL_FROM", "pkoforum <noreply@localhost>"),
+		MailDir:      getEnvWithDefault("MAIL_DIR", "mail"),
+		SMTPHost:     smtpHost,
+		SMTPPort:     smtpPort,
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+
+		SiteURL: strings.TrimSuffix(getEnvWithDefault("SITE_URL", "http://localhost:8080"), "/"),
 	}
 
 	return config, nil
@@ -37,3 +246,15 @@ func getEnvWithDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// parseList splits a comma-separated value into unique, lower-cased, non-empty items
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" && !slices.Contains(items, item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,66 @@
+package langdetect
+
+import (
+	"strings"
+
+	"github.com/abadojack/whatlanggo"
+)
+
+// DefaultMinConfidence is the confidence below which a detection is not trusted
+const DefaultMinConfidence = 0.25
+
+// Result is the outcome of detecting the language of a text
+type Result struct {
+	// Language is the ISO 639-1 code of the most likely language, or "" if none
+	// matched. It may be outside the configured set, in which case the result
+	// has zero confidence and is never reliable.
+	Language   string
+	Confidence float64
+	Reliable   bool
+}
+
+// Detector identifies the language of a text using trigram profiles and
+// trusts only detections of a fixed set of configured languages
+type Detector struct {
+	codes         map[string]bool
+	minConfidence float64
+}
+
+// New creates a detector trusting the given ISO 639-1 language codes.
+// Codes without a detection profile are ignored.
+func New(languages []string) *Detector {
+	codes := make(map[string]bool)
+	for lang := range whatlanggo.Langs {
+		for _, code := range languages {
+			if lang.Iso6391() == strings.ToLower(code) {
+				codes[lang.Iso6391()] = true
+			}
+		}
+	}
+
+	return &Detector{
+		codes:         codes,
+		minConfidence: DefaultMinConfidence,
+	}
+}
+
+// Detect returns the most likely language of text. All known languages are
+// considered, so that text in a close relative of a configured language (e.g.
+// Ukrainian when only Russian is configured) is not mistaken for it.
+func (d *Detector) Detect(text string) Result {
+	if len(d.codes) == 0 || strings.TrimSpace(text) == "" {
+		return Result{}
+	}
+
+	info := whatlanggo.Detect(text)
+	code := info.Lang.Iso6391()
+	if !d.codes[code] {
+		return Result{Language: code}
+	}
+
+	return Result{
+		Language:   code,
+		Confidence: info.Confidence,
+		Reliable:   info.Confidence >= d.minConfidence,
+	}
+}
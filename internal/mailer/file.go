@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// File writes every message as an .eml file into a directory instead of
+// sending it, for local testing
+type File struct {
+	dir  string
+	from string
+}
+
+// NewFile creates a mailer writing to dir, which is created if missing
+func NewFile(dir, from string) (*File, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating mail directory: %w", err)
+	}
+	return &File{dir: dir, from: from}, nil
+}
+
+// Send writes msg to a new file named after the current time
+func (m *File) Send(ctx context.Context, msg Message) error {
+	data, err := encode(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(m.dir, fmt.Sprintf("%d-*.eml", time.Now().UnixNano()))
+	if err != nil {
+		return fmt.Errorf("creating mail file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("writing mail file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing mail file: %w", err)
+	}
+	return nil
+}
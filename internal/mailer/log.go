@@ -0,0 +1,30 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Log writes every message to the application log instead of sending it,
+// for local testing
+type Log struct {
+	from string
+}
+
+// NewLog creates a mailer that logs messages
+func NewLog(from string) *Log {
+	return &Log{from: from}
+}
+
+// Send logs msg with its headers and body
+func (m *Log) Send(ctx context.Context, msg Message) error {
+	log.Info().
+		Str("from", m.from).
+		Str("to", msg.To).
+		Str("subject", msg.Subject).
+		Interface("headers", msg.Headers).
+		Str("text", msg.Text).
+		Msg("Mail")
+	return nil
+}
@@ -0,0 +1,106 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	"pkoforum/internal/config"
+)
+
+const (
+	BackendSMTP string = "smtp"
+	BackendFile string = "file"
+	BackendLog  string = "log"
+	BackendNone string = "none"
+)
+
+// Message is a plain-text e-mail to one recipient
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	// Headers are added to the standard ones, e.g. List-Unsubscribe
+	Headers map[string]string
+}
+
+// Mailer sends e-mail
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New creates the mailer selected by the configuration. It returns nil when
+// e-mail is disabled.
+func New(cfg *config.Config) (Mailer, error) {
+	if cfg.MailBackend != BackendNone {
+		if _, err := mail.ParseAddress(cfg.MailFrom); err != nil {
+			return nil, fmt.Errorf("invalid MAIL_FROM: %w", err)
+		}
+	}
+
+	switch cfg.MailBackend {
+	case BackendSMTP:
+		return NewSMTP(SMTPOptions{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		}), nil
+	case BackendFile:
+		return NewFile(cfg.MailDir, cfg.MailFrom)
+	case BackendLog:
+		return NewLog(cfg.MailFrom), nil
+	case BackendNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown mail backend %q", cfg.MailBackend)
+	}
+}
+
+// encode renders msg as an RFC 5322 message sent by from
+func encode(from string, msg Message) ([]byte, error) {
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return nil, fmt.Errorf("invalid recipient %q", msg.To)
+	}
+
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		// Header values come from templates and settings; never let them add lines
+		value = strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+
+	header("From", from)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+
+	names := make([]string, 0, len(msg.Headers))
+	for name := range msg.Headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		header(name, msg.Headers[name])
+	}
+	buf.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write([]byte(strings.ReplaceAll(msg.Text, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,64 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+)
+
+// SMTPOptions configures an SMTP mailer
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// SMTP sends e-mail through an SMTP server, upgrading to TLS with STARTTLS
+// when the server offers it
+type SMTP struct {
+	opts SMTPOptions
+}
+
+// NewSMTP creates a mailer that relays through the configured server. It
+// authenticates only when a username is set.
+func NewSMTP(opts SMTPOptions) *SMTP {
+	return &SMTP{opts: opts}
+}
+
+// Send delivers msg. net/smtp does not take a context, so ctx is only checked
+// before connecting.
+func (m *SMTP) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := encode(m.opts.From, msg)
+	if err != nil {
+		return err
+	}
+
+	from, err := mail.ParseAddress(m.opts.From)
+	if err != nil {
+		return fmt.Errorf("parsing sender: %w", err)
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("parsing recipient: %w", err)
+	}
+
+	var auth smtp.Auth
+	if m.opts.Username != "" {
+		auth = smtp.PlainAuth("", m.opts.Username, m.opts.Password, m.opts.Host)
+	}
+
+	addr := net.JoinHostPort(m.opts.Host, strconv.Itoa(m.opts.Port))
+	if err := smtp.SendMail(addr, auth, from.Address, []string{to.Address}, data); err != nil {
+		return fmt.Errorf("sending mail: %w", err)
+	}
+	return nil
+}
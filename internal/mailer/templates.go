@@ -0,0 +1,114 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+// Names of the e-mail templates. Each language has its own copy under
+// templates/{lang}/{name}.txt defining a "subject" and the body.
+const (
+	TemplateNotification = "notification"
+	TemplateDigest       = "digest"
+	TemplateConfirmation = "confirmation"
+)
+
+// fallbackLanguage is used for recipients whose language has no templates
+const fallbackLanguage = "en"
+
+// templates maps each language to its templates by name
+var templates = parseTemplates()
+
+func parseTemplates() map[string]map[string]*template.Template {
+	langs, err := fs.ReadDir(templateFiles, "templates")
+	if err != nil {
+		panic(err)
+	}
+
+	parsed := make(map[string]map[string]*template.Template, len(langs))
+	for _, lang := range langs {
+		files, err := fs.Glob(templateFiles, path.Join("templates", lang.Name(), "*.txt"))
+		if err != nil {
+			panic(err)
+		}
+		parsed[lang.Name()] = make(map[string]*template.Template, len(files))
+		for _, file := range files {
+			base := path.Base(file)
+			parsed[lang.Name()][strings.TrimSuffix(base, ".txt")] = template.Must(template.New(base).ParseFS(templateFiles, file))
+		}
+	}
+	return parsed
+}
+
+// NotificationData fills the notification template
+type NotificationData struct {
+	// Kind is one of the api.Notification kinds
+	Kind           string
+	ActorName      string
+	ThreadTitle    string
+	URL            string
+	UnsubscribeURL string
+}
+
+// DigestData fills the digest template
+type DigestData struct {
+	// Frequency is daily or weekly
+	Frequency      string
+	Categories     []DigestCategory
+	UnsubscribeURL string
+}
+
+// DigestCategory lists the new threads of one followed category
+type DigestCategory struct {
+	Label   string
+	Threads []DigestThread
+}
+
+// DigestThread is a thread listed in a digest
+type DigestThread struct {
+	Title      string
+	AuthorName string
+	URL        string
+	CreatedAt  time.Time
+}
+
+// ConfirmationData fills the confirmation template sent to new addresses
+type ConfirmationData struct {
+	Email string
+	URL   string
+	// Hours is how long the link stays valid
+	Hours int
+}
+
+// Render fills the named template in lang, or in English when there is no
+// translation, and returns the message without a recipient
+func Render(name, lang string, data any) (Message, error) {
+	tmpl, ok := templates[lang][name]
+	if !ok {
+		tmpl, ok = templates[fallbackLanguage][name]
+	}
+	if !ok {
+		return Message{}, fmt.Errorf("unknown mail template %q", name)
+	}
+
+	var subject, text bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("rendering subject: %w", err)
+	}
+	if err := tmpl.Execute(&text, data); err != nil {
+		return Message{}, fmt.Errorf("rendering body: %w", err)
+	}
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimLeft(text.String(), "\n"),
+	}, nil
+}
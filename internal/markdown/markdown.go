@@ -0,0 +1,85 @@
+// Package markdown renders user-written CommonMark into HTML that is safe to
+// embed in a page.
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// renderer converts CommonMark to HTML. Raw HTML in the source is not passed
+// through; goldmark replaces it with a comment unless WithUnsafe is set.
+var renderer = goldmark.New()
+
+// policy strips anything the renderer produced that is not plain formatting,
+// such as javascript: links, and marks links as user content
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	// Keep the language of fenced code blocks for client-side highlighting
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// Render converts CommonMark source into sanitized HTML
+func Render(source string) string {
+	var buf bytes.Buffer
+	if err := renderer.Convert([]byte(source), &buf); err != nil {
+		// goldmark only fails when writing to buf does; fall back to escaped text
+		return "<p>" + html.EscapeString(source) + "</p>"
+	}
+	return policy.Sanitize(buf.String())
+}
+
+// mentionPattern matches @username outside of words, so e-mail addresses are
+// not taken for mentions
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_-]{3,32})\b`)
+
+// Mentions returns the usernames mentioned as @username in the text of source,
+// in order of first appearance. Mentions inside code are ignored.
+func Mentions(source string) []string {
+	src := []byte(source)
+	doc := renderer.Parser().Parse(text.NewReader(src))
+
+	// Gather the plain text; the parser splits it at emphasis delimiters, so
+	// matching node by node would cut names containing underscores
+	var plain bytes.Buffer
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		switch n := n.(type) {
+		case *ast.CodeSpan:
+			plain.WriteByte(' ')
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			if entering {
+				plain.Write(n.Segment.Value(src))
+				if n.SoftLineBreak() || n.HardLineBreak() {
+					plain.WriteByte('\n')
+				}
+			}
+		default:
+			if !entering && n.Type() == ast.TypeBlock {
+				plain.WriteByte('\n')
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range mentionPattern.FindAllSubmatch(plain.Bytes(), -1) {
+		name := string(m[1])
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,62 @@
+package media
+
+import "encoding/binary"
+
+// gifFrames counts the frames of a GIF and the pixels they cover by walking
+// its block structure, without decompressing any image data. ok is false
+// when the structure is malformed.
+func gifFrames(data []byte) (frames int, pixels int64, ok bool) {
+	if len(data) < 13 || (string(data[:6]) != "GIF87a" && string(data[:6]) != "GIF89a") {
+		return 0, 0, false
+	}
+
+	// Skip the logical screen descriptor and the global color table
+	pos := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		pos += 3 << (flags&0x07 + 1)
+	}
+
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21: // extension: introducer, label, sub-blocks
+			pos = skipSubBlocks(data, pos+2)
+
+		case 0x2C: // image descriptor
+			if pos+10 > len(data) {
+				return 0, 0, false
+			}
+			width := int64(binary.LittleEndian.Uint16(data[pos+5 : pos+7]))
+			height := int64(binary.LittleEndian.Uint16(data[pos+7 : pos+9]))
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << (flags&0x07 + 1)
+			}
+			// LZW minimum code size, then the compressed sub-blocks
+			pos = skipSubBlocks(data, pos+1)
+			frames++
+			pixels += width * height
+
+		case 0x3B: // trailer
+			return frames, pixels, true
+
+		default:
+			return 0, 0, false
+		}
+	}
+	// A missing trailer is left for the decoder to judge
+	return frames, pixels, true
+}
+
+// skipSubBlocks returns the position after the data sub-blocks starting at pos
+func skipSubBlocks(data []byte, pos int) int {
+	for pos < len(data) {
+		size := int(data[pos])
+		pos++
+		if size == 0 {
+			break
+		}
+		pos += size
+	}
+	return pos
+}
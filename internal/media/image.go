@@ -0,0 +1,148 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	_ "golang.org/x/image/webp" // registers the WebP decoder
+)
+
+// Errors returned by ProcessImage; all of them are the uploader's fault
+var (
+	ErrTooLarge         = errors.New("image file is too large")
+	ErrUnsupportedType  = errors.New("unsupported image type, allowed types are JPEG, PNG, GIF and WebP")
+	ErrDimensionsTooBig = errors.New("image dimensions are too large")
+	ErrInvalidImage     = errors.New("image file is corrupt or unreadable")
+)
+
+const jpegQuality = 90
+
+// Limits bounds what ProcessImage accepts
+type Limits struct {
+	MaxBytes  int64
+	MaxWidth  int
+	MaxHeight int
+
+	// MaxFrames and MaxPixels bound animated GIFs, whose frames are all
+	// decoded: the number of frames and their total width×height
+	MaxFrames int
+	MaxPixels int64
+}
+
+// Image is a re-encoded upload, free of any metadata the original carried
+type Image struct {
+	Data        []byte
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+
+	// decoded holds the pixels Resize scales from
+	decoded image.Image
+}
+
+// allowedTypes maps sniffed MIME types to the format re-encoded images are stored in.
+// WebP has no encoder in the standard library, so it is stored as lossless PNG.
+var allowedTypes = map[string]string{
+	"image/jpeg": "image/jpeg",
+	"image/png":  "image/png",
+	"image/gif":  "image/gif",
+	"image/webp": "image/png",
+}
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ProcessImage validates an uploaded image and re-encodes it. The type is
+// sniffed from the content rather than trusted from the client, and
+// re-encoding drops EXIF (including GPS) and any other embedded metadata.
+func ProcessImage(r io.Reader, limits Limits) (*Image, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limits.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading image: %w", err)
+	}
+	if int64(len(data)) > limits.MaxBytes {
+		return nil, ErrTooLarge
+	}
+
+	sniffed := http.DetectContentType(data)
+	outputType, ok := allowedTypes[sniffed]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	// Check dimensions before decoding so oversized images are never expanded in memory
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width > limits.MaxWidth || config.Height > limits.MaxHeight {
+		return nil, ErrDimensionsTooBig
+	}
+
+	var buf bytes.Buffer
+	var decoded image.Image
+	width, height := config.Width, config.Height
+
+	if sniffed == "image/gif" {
+		// Every frame is about to be expanded, so bound them before decoding
+		frames, pixels, ok := gifFrames(data)
+		if !ok {
+			return nil, ErrInvalidImage
+		}
+		if frames > limits.MaxFrames || pixels > limits.MaxPixels {
+			return nil, ErrDimensionsTooBig
+		}
+
+		// Decode every frame so animations survive re-encoding
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil || len(anim.Image) == 0 {
+			return nil, ErrInvalidImage
+		}
+		if err := gif.EncodeAll(&buf, anim); err != nil {
+			return nil, fmt.Errorf("encoding gif: %w", err)
+		}
+		decoded = anim.Image[0]
+	} else {
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrInvalidImage
+		}
+
+		// The orientation tag is about to be stripped, so apply it to the pixels
+		if sniffed == "image/jpeg" {
+			img = applyOrientation(img, jpegOrientation(data))
+		}
+		width, height = img.Bounds().Dx(), img.Bounds().Dy()
+		decoded = img
+
+		switch outputType {
+		case "image/jpeg":
+			err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+		default:
+			err = png.Encode(&buf, img)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("encoding image: %w", err)
+		}
+	}
+
+	return &Image{
+		Data:        buf.Bytes(),
+		ContentType: outputType,
+		Extension:   extensions[outputType],
+		Width:       width,
+		Height:      height,
+		decoded:     decoded,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// VariantOriginal names the full-size image in variant lists
+const VariantOriginal = "original"
+
+// VariantSpec describes a resized copy generated for every upload
+type VariantSpec struct {
+	Name string
+	// MaxSize bounds both the width and the height of the variant
+	MaxSize int
+}
+
+// Variants are the resized copies generated for uploads, smallest first
+var Variants = []VariantSpec{
+	{Name: "thumbnail", MaxSize: 320},
+	{Name: "medium", MaxSize: 1024},
+}
+
+// Resize returns a copy of the image scaled down to fit within maxSize×maxSize.
+// It returns nil when the image already fits, as upscaling would only waste space.
+// Animated GIFs are resized to a still PNG of their first frame.
+func (img *Image) Resize(maxSize int) (*Image, error) {
+	if img.Width <= maxSize && img.Height <= maxSize {
+		return nil, nil
+	}
+
+	width, height := maxSize, img.Height*maxSize/img.Width
+	if img.Height > img.Width {
+		width, height = img.Width*maxSize/img.Height, maxSize
+	}
+	width, height = max(width, 1), max(height, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img.decoded, img.decoded.Bounds(), draw.Over, nil)
+
+	var buf bytes.Buffer
+	contentType := img.ContentType
+	var err error
+	switch contentType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality})
+	default:
+		contentType = "image/png"
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encoding resized image: %w", err)
+	}
+
+	return &Image{
+		Data:        buf.Bytes(),
+		ContentType: contentType,
+		Extension:   extensions[contentType],
+		Width:       width,
+		Height:      height,
+		decoded:     dst,
+	}, nil
+}
@@ -0,0 +1,77 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LibreTranslate translates through a LibreTranslate-compatible HTTP API
+type LibreTranslate struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+type libreTranslateRequest struct {
+	Q      string `json:"q"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Format string `json:"format"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+type libreTranslateResponse struct {
+	TranslatedText string `json:"translatedText"`
+	Error          string `json:"error"`
+}
+
+// NewLibreTranslate creates a translator for the server at baseURL
+func NewLibreTranslate(baseURL, apiKey string) *LibreTranslate {
+	return &LibreTranslate{
+		url:    strings.TrimRight(baseURL, "/") + "/translate",
+		apiKey: apiKey,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Translate posts text to the /translate endpoint
+func (t *LibreTranslate) Translate(ctx context.Context, text, sourceLang, targetLang string) (string, error) {
+	body, err := json.Marshal(libreTranslateRequest{
+		Q:      text,
+		Source: sourceLang,
+		Target: targetLang,
+		Format: "text",
+		APIKey: t.apiKey,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("translation error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result libreTranslateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decoding translation response (status %d): %v", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("translation error: status %d: %s", resp.StatusCode, result.Error)
+	}
+
+	return result.TranslatedText, nil
+}
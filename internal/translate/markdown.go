@@ -0,0 +1,190 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Markdown wraps a Translator so that code in Markdown text is never
+// translated: fenced code blocks and inline code spans are swapped for
+// placeholders before the text is sent and restored in the translation.
+type Markdown struct {
+	Translator
+}
+
+// placeholderPattern matches the markers left by placeholder
+var placeholderPattern = regexp.MustCompile(`⟦(\d+)⟧`)
+
+// placeholder returns the marker standing in for the i-th masked code segment
+func placeholder(i int) string {
+	return fmt.Sprintf("⟦%d⟧", i)
+}
+
+// Translate translates text with its code masked
+func (m Markdown) Translate(ctx context.Context, text, sourceLang, targetLang string) (string, error) {
+	masked, code := maskCode(text)
+	if len(code) == 0 {
+		return m.Translator.Translate(ctx, text, sourceLang, targetLang)
+	}
+
+	translated, err := m.Translator.Translate(ctx, masked, sourceLang, targetLang)
+	if err != nil {
+		return "", err
+	}
+
+	return restoreCode(translated, code)
+}
+
+// restoreCode puts the code segments back in place of their placeholders. It
+// works in a single pass so that marker-like text inside restored code is left
+// alone; only the first occurrence of each marker is replaced.
+func restoreCode(translated string, code []string) (string, error) {
+	restored := make([]bool, len(code))
+	translated = placeholderPattern.ReplaceAllStringFunc(translated, func(marker string) string {
+		i, err := strconv.Atoi(placeholderPattern.FindStringSubmatch(marker)[1])
+		if err != nil || i >= len(code) || restored[i] {
+			return marker
+		}
+		restored[i] = true
+		return code[i]
+	})
+
+	for i, ok := range restored {
+		if !ok {
+			return "", fmt.Errorf("translation dropped code placeholder %s", placeholder(i))
+		}
+	}
+	return translated, nil
+}
+
+// maskCode replaces fenced code blocks and inline code spans, as well as any
+// text that looks like a placeholder, with placeholders, returning the masked
+// text and the segments in order
+func maskCode(text string) (string, []string) {
+	var code []string
+	var out strings.Builder
+
+	lines := strings.SplitAfter(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		fence := openingFence(lines[i])
+		if fence == "" {
+			out.WriteString(maskInlineCode(lines[i], &code))
+			continue
+		}
+
+		// The block runs to a closing fence or, per CommonMark, the end of the text
+		end := len(lines) - 1
+		for j := i + 1; j < len(lines); j++ {
+			if isClosingFence(lines[j], fence) {
+				end = j
+				break
+			}
+		}
+
+		block := strings.Join(lines[i:end+1], "")
+		trailing := ""
+		if strings.HasSuffix(block, "\n") {
+			block, trailing = block[:len(block)-1], "\n"
+		}
+		out.WriteString(placeholder(len(code)) + trailing)
+		code = append(code, block)
+		i = end
+	}
+	return out.String(), code
+}
+
+// openingFence returns the fence (``` or ~~~, possibly longer) that opens a
+// code block on line, or "" when the line does not open one
+func openingFence(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < 3 {
+		return ""
+	}
+	char := trimmed[0]
+	if char != '`' && char != '~' {
+		return ""
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == char {
+		n++
+	}
+	if n < 3 {
+		return ""
+	}
+	// Backtick fences may not have backticks in their info string
+	if char == '`' && strings.ContainsRune(trimmed[n:], '`') {
+		return ""
+	}
+	return trimmed[:n]
+}
+
+// isClosingFence reports whether line closes a block opened with fence
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return false
+	}
+	rest := strings.TrimLeft(trimmed, fence[:1])
+	return len(trimmed)-len(rest) >= len(fence) && strings.TrimSpace(rest) == ""
+}
+
+// maskInlineCode replaces the code spans of a single line with placeholders
+func maskInlineCode(line string, code *[]string) string {
+	var out strings.Builder
+	for {
+		start := strings.IndexByte(line, '`')
+		if start < 0 {
+			break
+		}
+		n := backtickRun(line[start:])
+
+		// A span closes at the next run of exactly as many backticks
+		end := -1
+		for i := start + n; i < len(line); {
+			j := strings.IndexByte(line[i:], '`')
+			if j < 0 {
+				break
+			}
+			m := backtickRun(line[i+j:])
+			if m == n {
+				end = i + j + m
+				break
+			}
+			i += j + m
+		}
+
+		if end < 0 {
+			out.WriteString(maskMarkers(line[:start+n], code))
+			line = line[start+n:]
+			continue
+		}
+		out.WriteString(maskMarkers(line[:start], code))
+		out.WriteString(placeholder(len(*code)))
+		*code = append(*code, line[start:end])
+		line = line[end:]
+	}
+	out.WriteString(maskMarkers(line, code))
+	return out.String()
+}
+
+// maskMarkers masks marker-like text the author wrote like a code segment,
+// so that it comes back verbatim and cannot be mistaken for a placeholder
+func maskMarkers(text string, code *[]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(marker string) string {
+		masked := placeholder(len(*code))
+		*code = append(*code, marker)
+		return masked
+	})
+}
+
+// backtickRun returns the number of backticks s starts with
+func backtickRun(s string) int {
+	n := 0
+	for n < len(s) && s[n] == '`' {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,55 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// OpenAI translates through any OpenAI-compatible chat completions endpoint
+type OpenAI struct {
+	client *openai.Client
+	model  string
+}
+
+// NewOpenAI creates a translator for the chat endpoint at baseURL
+func NewOpenAI(apiKey, baseURL, model string) *OpenAI {
+	config := openai.DefaultConfig(apiKey)
+	config.BaseURL = baseURL
+	return &OpenAI{
+		client: openai.NewClientWithConfig(config),
+		model:  model,
+	}
+}
+
+// Translate asks the model for a translation of text
+func (t *OpenAI) Translate(ctx context.Context, text, sourceLang, targetLang string) (string, error) {
+	prompt := fmt.Sprintf("Translate the following %s Markdown text to %s:\n\n%s\n\n"+
+		"Keep the Markdown formatting, link targets and placeholders such as ⟦0⟧ exactly as they are. "+
+		"Answer with only translated variant without anything else, if you can't translate, return the original text",
+		languageName(sourceLang), languageName(targetLang), text)
+
+	resp, err := t.client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: t.model,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: prompt,
+				},
+			},
+		},
+	)
+
+	if err != nil {
+		return "", fmt.Errorf("translation error: %v", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no translation received")
+	}
+
+	return resp.Choices[0].Message.Content, nil
+}
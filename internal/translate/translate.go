@@ -0,0 +1,60 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+
+	"pkoforum/internal/config"
+)
+
+const (
+	BackendOpenAI         string = "openai"
+	BackendLibreTranslate string = "libretranslate"
+	BackendNone           string = "none"
+)
+
+// Translator translates text from one language to another
+type Translator interface {
+	Translate(ctx context.Context, text, sourceLang, targetLang string) (string, error)
+}
+
+// languageNames maps language codes to the names used in prompts
+var languageNames = map[string]string{
+	"de": "German",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"uk": "Ukrainian",
+	"zh": "Chinese",
+}
+
+// languageName returns the human-readable name for a language code
+func languageName(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+// New creates the translator selected by the configuration. Posts are
+// Markdown, so real backends are wrapped to leave code untranslated. It
+// returns nil for BackendNone: nothing is translated and readers get the
+// original text.
+func New(cfg *config.Config) (Translator, error) {
+	switch cfg.TranslationBackend {
+	case BackendOpenAI:
+		return Markdown{NewOpenAI(cfg.DeepseekAPIKey, cfg.DeepseekURL, cfg.TranslationModel)}, nil
+	case BackendLibreTranslate:
+		return Markdown{NewLibreTranslate(cfg.LibreTranslateURL, cfg.LibreTranslateAPIKey)}, nil
+	case BackendNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown translation backend %q", cfg.TranslationBackend)
+	}
+}
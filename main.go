@@ -1,23 +1,37 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
 	"pkoforum/db"
 	sqlcdb "pkoforum/db/sqlc"
 	"pkoforum/internal/api"
+	"pkoforum/internal/blob"
 	"pkoforum/internal/config"
+	"pkoforum/internal/mailer"
+	"pkoforum/internal/translate"
 
 	"github.com/gorilla/handlers"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
-	"github.com/sashabaranov/go-openai"
 )
 
+// staticDir holds the files served under /static/
+const staticDir = "static"
+
+// shutdownTimeout bounds how long in-flight requests and translations may run after a stop signal
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	// Configure zerolog
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
@@ -26,6 +40,22 @@ func main() {
 		TimeFormat: time.RFC3339,
 	})
 
+	// Administrative subcommands run without the full application configuration
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err := runMigrate(os.Args[2:]); err != nil {
+				log.Fatal().Err(err).Msg("Migration failed")
+			}
+			return
+		case "user":
+			if err := runUser(os.Args[2:]); err != nil {
+				log.Fatal().Err(err).Msg("User command failed")
+			}
+			return
+		}
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -41,43 +71,110 @@ func main() {
 	// Initialize queries
 	queries := sqlcdb.New(db.DB)
 
-	// Initialize OpenAI client
-	config := openai.DefaultConfig(cfg.DeepseekAPIKey)
-	config.BaseURL = cfg.DeepseekURL
-	openaiClient := openai.NewClientWithConfig(config)
+	// Initialize the translation backend
+	translator, err := translate.New(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize translator")
+	}
+	log.Info().Str("backend", cfg.TranslationBackend).Msg("Translator initialized")
+
+	// Initialize upload storage
+	blobs, err := blob.New(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize blob storage")
+	}
+	log.Info().Str("backend", cfg.BlobBackend).Msg("Blob storage initialized")
 
-	// Create uploads directory with proper permissions
-	if err := os.MkdirAll(cfg.UploadsPath, 0755); err != nil {
-		log.Fatal().Err(err).Str("path", cfg.UploadsPath).Msg("Failed to create uploads directory")
+	// Initialize e-mail delivery
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize mailer")
 	}
+	log.Info().Str("backend", cfg.MailBackend).Msg("Mailer initialized")
 
 	// Initialize the application
-	app := api.NewApp(db.DB, queries, openaiClient, cfg.UploadsPath)
+	app := api.NewApp(db.DB, queries, translator, blobs, mail, cfg)
 	router := app.Router()
 
 	// CORS middleware
 	corsMiddleware := handlers.CORS(
 		handlers.AllowedOrigins([]string{"*"}),
-		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
 		handlers.AllowedHeaders([]string{"Content-Type", "Accept", "Accept-Language", "Accept-Encoding", "X-CSRF-Token", "Authorization"}),
 		handlers.AllowCredentials(),
 	)
 	router.Use(corsMiddleware)
-	router.Use(api.LanguageMiddleware)
+	router.Use(app.LanguageMiddleware)
+	router.Use(app.AuthMiddleware)
 
-	// Serve static files with proper headers
-	fs := http.FileServer(http.Dir("static"))
+	// Serve static files with proper headers. Uploads are streamed from /uploads/,
+	// so the uploads directory is hidden here: files stored before uploads were
+	// validated would otherwise be served with a type taken from their name.
+	uploadsDir, err := filepath.Abs(cfg.UploadsPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to resolve uploads path")
+	}
+	fs := http.FileServer(http.Dir(staticDir))
 	router.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if filepath.Ext(r.URL.Path) == ".jpg" || filepath.Ext(r.URL.Path) == ".jpeg" || filepath.Ext(r.URL.Path) == ".png" {
-			w.Header().Set("Content-Type", "image/"+filepath.Ext(r.URL.Path)[1:])
+		if insideDir(uploadsDir, filepath.Join(staticDir, filepath.FromSlash(path.Clean("/"+r.URL.Path)))) {
+			http.NotFound(w, r)
+			return
 		}
+		// The file server derives Content-Type from the extension; never let browsers guess
+		w.Header().Set("X-Content-Type-Options", "nosniff")
 		w.Header().Set("Cache-Control", "public, max-age=31536000")
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		fs.ServeHTTP(w, r)
 	})))
 
+	// Start translation workers
+	app.StartTranslationWorkers()
+
+	// Start sending e-mail digests
+	app.StartDigests()
+
 	// Start server
 	addr := fmt.Sprintf(":%s", cfg.Port)
-	log.Info().Str("addr", addr).Msg("Starting server")
-	log.Fatal().Err(http.ListenAndServe(addr, router)).Msg("Server stopped")
+	server := &http.Server{Addr: addr, Handler: router}
+	// Event streams never finish on their own; end them when shutdown starts
+	server.RegisterOnShutdown(app.StopEvents)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		log.Info().Str("addr", addr).Msg("Starting server")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("Server error")
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	log.Info().Msg("Shutting down")
+
+	// Stop accepting requests, then let in-flight translations finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Error shutting down server")
+	}
+	if err := app.StopTranslationWorkers(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Error draining translation workers")
+	}
+	app.StopDigests()
+
+	log.Info().Msg("Server stopped")
+}
+
+// insideDir reports whether name is dir or lies beneath it. The comparison
+// ignores case so case-insensitive filesystems cannot be used to bypass it.
+func insideDir(dir, name string) bool {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return true
+	}
+	abs, dir = strings.ToLower(abs), strings.ToLower(dir)
+	return abs == dir || strings.HasPrefix(abs, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
 }
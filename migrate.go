@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"pkoforum/db"
+
+	"github.com/rs/zerolog/log"
+)
+
+// migrateUsage is printed when the migrate subcommand is misused
+const migrateUsage = "usage: pkoforum migrate up|down [steps]|status"
+
+// runMigrate handles the `migrate up|down [steps]|status` subcommand. Errors are
+// returned rather than fatal so the database is closed before the process exits.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	steps := 1
+	switch args[0] {
+	case "up", "status":
+		if len(args) > 1 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			os.Exit(2)
+		}
+	case "down":
+		if len(args) > 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			os.Exit(2)
+		}
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("steps must be a positive integer, got %q", args[1])
+			}
+			steps = n
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q\n%s\n", args[0], migrateUsage)
+		os.Exit(2)
+	}
+
+	if err := db.OpenDB(); err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer db.CloseDB()
+
+	switch args[0] {
+	case "up":
+		applied, err := db.MigrateUp(db.DB)
+		if err != nil {
+			return fmt.Errorf("applying migrations: %w", err)
+		}
+		log.Info().Int("applied", applied).Msg("Migrations applied")
+
+	case "down":
+		rolledBack, err := db.MigrateDown(db.DB, steps)
+		if err != nil {
+			return fmt.Errorf("rolling back migrations: %w", err)
+		}
+		log.Info().Int("rolled_back", rolledBack).Msg("Migrations rolled back")
+
+	case "status":
+		statuses, err := db.GetMigrationStatus(db.DB)
+		if err != nil {
+			return fmt.Errorf("reading migration status: %w", err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		tw.Flush()
+	}
+	return nil
+}
@@ -1,22 +1,160 @@
-export type Category = 'general' | 'help' | 'discussion' | 'announcement';
+// Category slugs are managed by admins; these are the ones created by default
+export type Category = 'general' | 'help' | 'discussion' | 'announcement' | (string & {});
 
 export interface Comment {
     id: string;
+    parent_comment_id?: string;
+    depth: number;
     content: string | Record<string, string>;
+    // sanitized HTML rendered from the Markdown in content
+    content_html: string | Record<string, string>;
     created_at: string;
+    edited_at?: string;
     image_url?: string;
     image_path?: string;
+    attachments?: Attachment[];
+    reactions: Reactions;
+    // set on the comment marked as the thread's solution
+    accepted?: boolean;
+}
+
+// 'up' and 'down' are votes; the other reactions are emoji from ReactionTypes
+export interface Reactions {
+    // up votes minus down votes
+    score: number;
+    counts: Record<string, number>;
+    // the current user's reactions
+    mine?: string[];
+}
+
+export interface ReactionTypes {
+    votes: ['up', 'down'];
+    emoji: string[];
+}
+
+export type ThreadSort = 'new' | 'top' | 'active';
+export type ThreadState = 'solved' | 'unsolved';
+export type CommentSort = 'old' | 'new' | 'top' | 'active';
+
+export interface Attachment {
+    id: string;
+    url: string;
+    caption?: string;
+    position: number;
+    width?: number;
+    height?: number;
+    variants?: ImageVariant[];
+}
+
+export interface ImageVariant {
+    name: string;
+    url: string;
+    width: number;
+    height: number;
 }
 
 export interface Thread {
     id: string;
     title: string;
     content: string;
+    // sanitized HTML rendered from the Markdown in content
+    content_html: string;
     category: Category;
     created_at: string;
+    edited_at?: string;
+    pending?: boolean;
+    reactions: Reactions;
+    accepted_comment_id?: string;
+    // only returned by GET /api/threads/{id}
+    accepted_comment?: Comment;
     comments: Comment[];
+    attachments?: Attachment[];
+}
+
+// Payload of thread_updated events; comment_id is set for changes to a comment
+export interface ThreadUpdate {
+    thread_id: string;
+    comment_id?: string;
+    action: string;
+}
+
+// Payload of translation_ready events
+export interface TranslationReady {
+    target_type: 'thread' | 'comment';
+    target_id: string;
+    thread_id: string;
+    language: string;
+}
+
+export interface Revision {
+    id: string;
+    title?: string;
+    content: string;
+    language: string;
+    edited_by?: string;
+    editor_name?: string;
+    created_at: string;
+}
+
+export interface Page<T> {
+    data: T[];
+    next_cursor?: string;
+}
+
+export interface SearchResult {
+    thread_id: string;
+    comment_id?: string;
+    kind: 'thread' | 'comment';
+    title: string;
+    category: Category;
+    snippet: string;
+    language?: string;
+    created_at: string;
+}
+
+export type NotificationKind = 'mention' | 'comment_reply' | 'thread_reply' | 'subscription';
+
+export interface Notification {
+    id: string;
+    user_id: string;
+    kind: NotificationKind;
+    thread_id: string;
+    thread_title: string;
+    comment_id?: string;
+    actor_id?: string;
+    actor_name?: string;
+    created_at: string;
+    read_at?: string;
+}
+
+export interface NotificationPage extends Page<Notification> {
+    // all unread notifications, not only those on the page
+    unread: number;
+}
+
+export interface ThreadSubscription {
+    thread_id: string;
+    subscribed: boolean;
 }
 
+export type DigestFrequency = 'off' | 'daily' | 'weekly';
+
+export interface EmailSettings {
+    email: string;
+    // nothing is sent until the address is confirmed through the e-mailed link
+    verified: boolean;
+    language: string;
+    // send each notification as it happens
+    notifications: boolean;
+    digest: DigestFrequency;
+    // categories whose new threads the digest lists
+    categories: Category[];
+}
+
+export type EmailSettingsUpdate = Partial<Omit<EmailSettings, 'verified'>>;
+
+export type PostingRole = 'anyone' | 'user' | 'moderator' | 'admin';
+
 export interface CategoryOption {
     value: Category;
     label: string;
@@ -25,4 +163,15 @@ export interface CategoryOption {
 export interface CategoryResponse {
     value: Category;
     label: string;
+    description?: string;
+    icon?: string;
+    thread_count: number;
+    last_activity_at?: string;
+    thread_role: PostingRole;
+    comment_role: PostingRole;
+    requires_approval: boolean;
+    solutions_enabled: boolean;
+    read_only: boolean;
+    can_create_thread: boolean;
+    can_comment: boolean;
 } 
\ No newline at end of file
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"pkoforum/db"
+	sqlcdb "pkoforum/db/sqlc"
+	"pkoforum/internal/api"
+
+	"github.com/rs/zerolog/log"
+)
+
+// runUser handles the `user set-role <username> <role>` subcommand, used to bootstrap the first admin.
+// Errors are returned rather than fatal so the database is closed before the process exits.
+func runUser(args []string) error {
+	if len(args) != 3 || args[0] != "set-role" {
+		fmt.Fprintln(os.Stderr, "usage: pkoforum user set-role <username> user|moderator|admin")
+		os.Exit(2)
+	}
+	username, role := args[1], args[2]
+
+	if !api.ValidateRole(role) {
+		return fmt.Errorf("invalid role %q", role)
+	}
+
+	if err := db.InitDB(); err != nil {
+		return fmt.Errorf("initializing database: %w", err)
+	}
+	defer db.CloseDB()
+
+	ctx := context.Background()
+	queries := sqlcdb.New(db.DB)
+
+	user, err := queries.GetUserByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("finding user %q: %w", username, err)
+	}
+
+	if _, err := queries.SetUserRole(ctx, sqlcdb.SetUserRoleParams{Role: role, ID: user.ID}); err != nil {
+		return fmt.Errorf("setting role of %q: %w", username, err)
+	}
+
+	log.Info().Str("username", user.Username).Str("role", role).Msg("User role updated")
+	return nil
+}
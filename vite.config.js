@@ -6,7 +6,8 @@ export default defineConfig({
   plugins: [sveltekit()],
   server: {
     proxy: {
-      '/api': 'http://localhost:8080'
+      '/api': 'http://localhost:8080',
+      '/uploads': 'http://localhost:8080'
     },
     fs: {
       allow: [